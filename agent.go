@@ -15,26 +15,40 @@ package agent
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"regexp"
 	"runtime/debug"
-	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/last9/go-agent/config"
 	"github.com/last9/go-agent/instrumentation/codeattr"
+	"github.com/last9/go-agent/instrumentation/redaction"
+	"github.com/last9/go-agent/instrumentation/tailsampling"
+	"github.com/last9/go-agent/internal/destination"
+	"github.com/last9/go-agent/internal/logging"
+	"github.com/last9/go-agent/internal/resourcedetect"
 	"github.com/last9/go-agent/internal/routematcher"
+	"github.com/last9/go-agent/internal/sampling"
+	"github.com/last9/go-agent/internal/selftelemetry"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -43,9 +57,18 @@ var (
 )
 
 // Option is a functional option for configuring the agent.
-// Options override environment variable values.
+// Options override environment variable and config file values.
 type Option func(*config.Config)
 
+// WithConfigFile loads configuration from a YAML or JSON file, overriding
+// LAST9_CONFIG_FILE. The file still sits below environment variables and the
+// other options in precedence, regardless of where this option appears.
+func WithConfigFile(path string) Option {
+	return func(cfg *config.Config) {
+		cfg.ConfigFile = path
+	}
+}
+
 // WithServiceName sets the service name, overriding OTEL_SERVICE_NAME.
 func WithServiceName(name string) Option {
 	return func(cfg *config.Config) {
@@ -75,6 +98,108 @@ func WithEndpoint(endpoint string) Option {
 	}
 }
 
+// WithTracesEndpoint sets the OTLP endpoint for traces only, overriding
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT. The URL is used as-is, so for
+// http/protobuf it must include the /v1/traces path.
+func WithTracesEndpoint(endpoint string) Option {
+	return func(cfg *config.Config) {
+		cfg.TracesEndpoint = endpoint
+	}
+}
+
+// WithMetricsEndpoint sets the OTLP endpoint for metrics only, overriding
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT. The URL is used as-is, so for
+// http/protobuf it must include the /v1/metrics path.
+func WithMetricsEndpoint(endpoint string) Option {
+	return func(cfg *config.Config) {
+		cfg.MetricsEndpoint = endpoint
+	}
+}
+
+// WithLogsEndpoint sets the OTLP endpoint for logs only, overriding
+// OTEL_EXPORTER_OTLP_LOGS_ENDPOINT. The URL is used as-is, so for
+// http/protobuf it must include the /v1/logs path.
+func WithLogsEndpoint(endpoint string) Option {
+	return func(cfg *config.Config) {
+		cfg.LogsEndpoint = endpoint
+	}
+}
+
+// WithInsecure sends to endpoints without a scheme, such as
+// "otel-collector:4317", over plaintext instead of TLS, for all signals. It
+// overrides OTEL_EXPORTER_OTLP_INSECURE and the per-signal variants.
+func WithInsecure() Option {
+	return func(cfg *config.Config) {
+		cfg.Insecure = true
+		cfg.TracesInsecure, cfg.MetricsInsecure, cfg.LogsInsecure = true, true, true
+	}
+}
+
+// WithProtocol sets the OTLP transport for all exporters, overriding
+// OTEL_EXPORTER_OTLP_PROTOCOL. Supported values are "grpc" and "http/protobuf".
+func WithProtocol(protocol string) Option {
+	return func(cfg *config.Config) {
+		switch protocol {
+		case config.ProtocolGRPC, config.ProtocolHTTPProtobuf:
+			cfg.Protocol = protocol
+		default:
+			logging.Warnf("Unsupported protocol %q (want grpc or http/protobuf), ignoring", protocol)
+		}
+	}
+}
+
+// WithExporter selects where telemetry goes, overriding LAST9_EXPORTER:
+// "otlp", "console" to print spans as trace trees and metrics as tables
+// to stderr during local development, or "file" to write OTLP/JSON lines
+// (see WithFileExporter).
+func WithExporter(exporter string) Option {
+	return func(cfg *config.Config) {
+		switch exporter {
+		case config.ExporterOTLP, config.ExporterConsole, config.ExporterFile:
+			cfg.Exporter = exporter
+		default:
+			logging.Warnf("Unsupported exporter %q (want otlp, console or file), ignoring", exporter)
+		}
+	}
+}
+
+// WithFileExporter writes traces and metrics as OTLP/JSON lines under dir
+// instead of sending them, for hosts that cannot reach Last9. It overrides
+// LAST9_EXPORTER and LAST9_FILE_EXPORTER_PATH. Upload the files later with
+// cmd/last9-replay.
+func WithFileExporter(dir string) Option {
+	return func(cfg *config.Config) {
+		cfg.Exporter = config.ExporterFile
+		cfg.FileExporterPath = dir
+	}
+}
+
+// WithDestination sends a copy of traces and metrics to another OTLP
+// endpoint as well, filtered by d.Filter, in addition to the primary exporter
+// and any destinations from the config file. Each destination has its own
+// batcher. Invalid destinations are logged and ignored.
+//
+//	agent.WithDestination(config.Destination{
+//	    Name:     "in-house",
+//	    Endpoint: "https://collector.internal:4318",
+//	    Filter:   config.DestinationFilter{SpanStatus: []string{"error"}},
+//	})
+func WithDestination(d config.Destination) Option {
+	return func(cfg *config.Config) {
+		if err := d.Validate(); err != nil {
+			logging.Warnf("Invalid destination %q: %v, ignoring", d.Name, err)
+			return
+		}
+		for _, existing := range cfg.Destinations {
+			if existing.Name == d.Name {
+				logging.Warnf("Duplicate destination name %q, ignoring", d.Name)
+				return
+			}
+		}
+		cfg.Destinations = append(cfg.Destinations, d)
+	}
+}
+
 // WithHeaders sets OTLP exporter headers (e.g., Authorization),
 // overriding OTEL_EXPORTER_OTLP_HEADERS.
 func WithHeaders(headers map[string]string) Option {
@@ -93,7 +218,7 @@ func WithHeaders(headers map[string]string) Option {
 func WithSamplingRate(rate float64) Option {
 	return func(cfg *config.Config) {
 		if rate < 0 || rate > 1 {
-			log.Printf("[Last9 Agent] Warning: Invalid sampling rate %f (must be 0.0-1.0), ignoring", rate)
+			logging.Warnf("Invalid sampling rate %f (must be 0.0-1.0), ignoring", rate)
 			return
 		}
 		if rate == 0 {
@@ -106,33 +231,539 @@ func WithSamplingRate(rate float64) Option {
 		}
 		cfg.Sampler = "traceidratio"
 		cfg.SamplerRatio = rate
+		cfg.SamplerRatioSet = true
+	}
+}
+
+// WithSamplingRateLimit selects the ratelimited sampler, keeping at most
+// tracesPerSecond new traces per second while honoring parent decisions.
+// Sampled root spans carry last9.sampling.probability so the backend can
+// extrapolate request counts. Overrides OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG.
+func WithSamplingRateLimit(tracesPerSecond float64) Option {
+	return func(cfg *config.Config) {
+		if tracesPerSecond <= 0 {
+			logging.Warnf("Invalid sampling rate limit %f (must be positive), ignoring", tracesPerSecond)
+			return
+		}
+		cfg.Sampler = "ratelimited"
+		cfg.SamplerRateLimit = tracesPerSecond
+	}
+}
+
+// WithSamplingRules sets per-route, per-span sampling rules for new traces,
+// overriding LAST9_SAMPLING_RULES. The first matching rule wins; spans no
+// rule matches use the sampler configured otherwise.
+//
+// Example:
+//
+//	agent.WithSamplingRules(
+//	    config.SamplingRule{Route: "/api/v1/poll", Ratio: 0.01},
+//	    config.SamplingRule{Route: "/checkout/**", Ratio: 1},
+//	)
+func WithSamplingRules(rules ...config.SamplingRule) Option {
+	return func(cfg *config.Config) {
+		cfg.SamplingRules = rules
+	}
+}
+
+// WithBodyCapture enables or disables HTTP request/response body capture,
+// overriding LAST9_BODY_CAPTURE_ENABLED. Combined with Reload it lets you
+// turn capture on during an incident without a redeploy.
+func WithBodyCapture(enabled bool) Option {
+	return func(cfg *config.Config) {
+		cfg.BodyCaptureEnabled = enabled
+	}
+}
+
+// WithReloadOnSIGHUP reloads the config when the process receives SIGHUP,
+// overriding LAST9_RELOAD_ON_SIGHUP. See Reload.
+func WithReloadOnSIGHUP() Option {
+	return func(cfg *config.Config) {
+		cfg.ReloadOnSIGHUP = true
+	}
+}
+
+// WithConfigWatch checks the config file for changes every interval and
+// reloads when it changes, overriding LAST9_CONFIG_WATCH_INTERVAL. See Reload.
+func WithConfigWatch(interval time.Duration) Option {
+	return func(cfg *config.Config) {
+		cfg.ConfigWatchInterval = interval
+	}
+}
+
+// WithShutdownTimeout bounds how long Shutdown waits for pending telemetry
+// to be exported, overriding LAST9_SHUTDOWN_TIMEOUT. Non-positive values are
+// ignored with a warning.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(cfg *config.Config) {
+		if timeout <= 0 {
+			logging.Warnf("WithShutdownTimeout(%s) ignored, timeout must be positive", timeout)
+			return
+		}
+		cfg.ShutdownTimeout = timeout
+	}
+}
+
+// WithSignalHandling makes Start trap SIGTERM and SIGINT, overriding
+// LAST9_SIGNAL_HANDLING. On either signal the agent runs the hooks
+// registered with OnShutdown, flushes and shuts down within the shutdown
+// timeout, and exits with status 128+signal. A second signal during shutdown
+// terminates the process immediately. Agents created with New do not trap
+// signals.
+func WithSignalHandling() Option {
+	return func(cfg *config.Config) {
+		cfg.SignalHandling = true
+	}
+}
+
+// WithPropagators sets the trace context formats read from incoming
+// requests and written to outgoing ones, overriding OTEL_PROPAGATORS:
+// "tracecontext", "baggage", "b3" (single header), "b3multi", "jaeger",
+// "xray", "ottrace", or "none". Incoming context is accepted in any of the
+// listed formats and outgoing requests carry all of them, so listing both
+// "tracecontext" and "b3" bridges services that only understand one.
+func WithPropagators(names ...string) Option {
+	return func(cfg *config.Config) {
+		cfg.Propagators = names
+	}
+}
+
+// WithBatchScheduleDelay sets the longest a finished span waits before it is
+// exported, overriding OTEL_BSP_SCHEDULE_DELAY. Non-positive values are
+// ignored with a warning.
+func WithBatchScheduleDelay(delay time.Duration) Option {
+	return func(cfg *config.Config) {
+		if delay <= 0 {
+			logging.Warnf("WithBatchScheduleDelay(%s) ignored, delay must be positive", delay)
+			return
+		}
+		cfg.BatchScheduleDelay = delay
+	}
+}
+
+// WithBatchMaxQueueSize sets the number of finished spans buffered for
+// export, overriding OTEL_BSP_MAX_QUEUE_SIZE. Spans beyond it are dropped and
+// counted in last9.agent.spans.dropped. Non-positive values are ignored with
+// a warning.
+func WithBatchMaxQueueSize(size int) Option {
+	return func(cfg *config.Config) {
+		if size <= 0 {
+			logging.Warnf("WithBatchMaxQueueSize(%d) ignored, size must be positive", size)
+			return
+		}
+		cfg.BatchMaxQueueSize = int64(size)
+	}
+}
+
+// WithBatchMaxExportBatchSize sets the largest number of spans sent in one
+// export request, overriding OTEL_BSP_MAX_EXPORT_BATCH_SIZE. It is capped at
+// the queue size. Non-positive values are ignored with a warning.
+func WithBatchMaxExportBatchSize(size int) Option {
+	return func(cfg *config.Config) {
+		if size <= 0 {
+			logging.Warnf("WithBatchMaxExportBatchSize(%d) ignored, size must be positive", size)
+			return
+		}
+		cfg.BatchMaxExportBatchSize = int64(size)
+	}
+}
+
+// WithSpanAttributeCountLimit sets the maximum number of attributes kept per
+// span, overriding OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT. Non-positive values are
+// ignored with a warning.
+func WithSpanAttributeCountLimit(limit int) Option {
+	return func(cfg *config.Config) {
+		if limit <= 0 {
+			logging.Warnf("WithSpanAttributeCountLimit(%d) ignored, limit must be positive", limit)
+			return
+		}
+		cfg.SpanAttributeCountLimit = int64(limit)
+	}
+}
+
+// WithAttributeValueLengthLimit truncates string attribute values, such as
+// db.statement and captured HTTP bodies, to limit bytes, overriding
+// OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT. Zero removes the limit; negative values
+// are ignored with a warning.
+func WithAttributeValueLengthLimit(limit int) Option {
+	return func(cfg *config.Config) {
+		if limit < 0 {
+			logging.Warnf("WithAttributeValueLengthLimit(%d) ignored, limit must not be negative", limit)
+			return
+		}
+		cfg.AttributeValueLengthLimit = int64(limit)
+	}
+}
+
+// WithSpanEventCountLimit sets the maximum number of events, including
+// recorded errors, kept per span, overriding OTEL_SPAN_EVENT_COUNT_LIMIT.
+// Non-positive values are ignored with a warning.
+func WithSpanEventCountLimit(limit int) Option {
+	return func(cfg *config.Config) {
+		if limit <= 0 {
+			logging.Warnf("WithSpanEventCountLimit(%d) ignored, limit must be positive", limit)
+			return
+		}
+		cfg.SpanEventCountLimit = int64(limit)
+	}
+}
+
+// WithMetricExportInterval sets how often metrics are collected and
+// exported, overriding OTEL_METRIC_EXPORT_INTERVAL. Non-positive values are
+// ignored with a warning.
+func WithMetricExportInterval(interval time.Duration) Option {
+	return func(cfg *config.Config) {
+		if interval <= 0 {
+			logging.Warnf("WithMetricExportInterval(%s) ignored, interval must be positive", interval)
+			return
+		}
+		cfg.MetricExportInterval = interval
+	}
+}
+
+// WithMetricTemporality sets the aggregation temporality of exported
+// metrics, overriding OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE:
+// "cumulative", "delta" (counters and histograms report the change since the
+// last export) or "lowmemory" (only synchronous counters and histograms do).
+// Unsupported values are ignored with a warning.
+func WithMetricTemporality(temporality string) Option {
+	return func(cfg *config.Config) {
+		switch temporality {
+		case config.MetricTemporalityCumulative, config.MetricTemporalityDelta, config.MetricTemporalityLowMemory:
+			cfg.MetricTemporality = temporality
+		default:
+			logging.Warnf("WithMetricTemporality(%q) ignored, want cumulative, delta or lowmemory", temporality)
+		}
+	}
+}
+
+// WithExponentialHistograms records histograms without a view as base2
+// exponential histograms instead of explicit buckets, overriding
+// OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION.
+func WithExponentialHistograms() Option {
+	return func(cfg *config.Config) {
+		cfg.MetricHistogramAggregation = config.HistogramAggregationExponential
+	}
+}
+
+// WithExemplarFilter selects the measurements recorded as exemplars,
+// overriding OTEL_METRICS_EXEMPLAR_FILTER: "trace_based" (measurements made
+// with a sampled span in their context), "always_on" or "always_off".
+// Unsupported filters are ignored with a warning.
+func WithExemplarFilter(filter string) Option {
+	return func(cfg *config.Config) {
+		switch filter {
+		case config.ExemplarFilterTraceBased, config.ExemplarFilterAlwaysOn, config.ExemplarFilterAlwaysOff:
+			cfg.MetricExemplarFilter = filter
+		default:
+			logging.Warnf("WithExemplarFilter(%q) ignored, want trace_based, always_on or always_off", filter)
+		}
+	}
+}
+
+// WithRuntimeMetricsInterval sets the minimum interval between reads of Go
+// memory statistics for runtime metrics, overriding
+// LAST9_RUNTIME_METRICS_INTERVAL. Non-positive values are ignored with a
+// warning.
+func WithRuntimeMetricsInterval(interval time.Duration) Option {
+	return func(cfg *config.Config) {
+		if interval <= 0 {
+			logging.Warnf("WithRuntimeMetricsInterval(%s) ignored, interval must be positive", interval)
+			return
+		}
+		cfg.RuntimeMetricsInterval = interval
+	}
+}
+
+// WithMetricViews adds views that drop, rename, filter the attributes of or
+// re-bucket matching instruments, in addition to those from the config file.
+// Invalid views are logged and ignored.
+//
+//	agent.WithMetricViews(
+//	    config.MetricView{Instrument: "db.sql.connection.*", Drop: true},
+//	    config.MetricView{
+//	        Instrument:    "http.server.request.duration",
+//	        AttributeKeys: []string{"http.route", "http.response.status_code"},
+//	        Buckets:       []float64{0.01, 0.05, 0.1, 0.5, 1, 5},
+//	    },
+//	)
+func WithMetricViews(views ...config.MetricView) Option {
+	return func(cfg *config.Config) {
+		for _, v := range views {
+			if err := v.Validate(); err != nil {
+				logging.Warnf("Invalid metric view for %q: %v, ignoring", v.Instrument+v.Scope, err)
+				continue
+			}
+			cfg.MetricViews = append(cfg.MetricViews, v)
+		}
+	}
+}
+
+// WithPrometheus registers a Prometheus reader alongside the OTLP metric
+// exporter, overriding LAST9_PROMETHEUS_ENABLED. Mount its handler, returned
+// by PrometheusHandler, on your own server, or use WithPrometheusAddr to
+// have the agent serve it.
+func WithPrometheus() Option {
+	return func(cfg *config.Config) {
+		cfg.PrometheusEnabled = true
+	}
+}
+
+// WithPrometheusAddr serves the agent's metrics for Prometheus to scrape on
+// addr, e.g. ":9464", at the path set by LAST9_PROMETHEUS_PATH (default
+// /metrics), overriding LAST9_PROMETHEUS_ADDR. Start fails if addr cannot be
+// listened on.
+func WithPrometheusAddr(addr string) Option {
+	return func(cfg *config.Config) {
+		cfg.PrometheusEnabled = true
+		cfg.PrometheusAddr = addr
+	}
+}
+
+// WithRedaction removes personal data and secrets from spans before they
+// are exported, overriding LAST9_REDACTION_ENABLED. The other WithRedaction
+// options configure what is redacted and how.
+func WithRedaction() Option {
+	return func(cfg *config.Config) {
+		cfg.RedactionEnabled = true
+	}
+}
+
+// WithRedactionDetectors sets the built-in redaction detectors, overriding
+// LAST9_REDACTION_DETECTORS: "email", "credit_card", "jwt", "aws_key" and
+// "ip". Unsupported names are ignored with a warning.
+func WithRedactionDetectors(names ...string) Option {
+	return func(cfg *config.Config) {
+		cfg.RedactionDetectors = config.ValidRedactionDetectors(names, "WithRedactionDetectors")
+	}
+}
+
+// WithRedactionPatterns adds regular expressions whose matches are redacted.
+// Patterns that do not compile are ignored with a warning.
+//
+// Example:
+//
+//	agent.WithRedactionPatterns(`order-secret-[0-9a-f]{32}`)
+func WithRedactionPatterns(patterns ...string) Option {
+	return func(cfg *config.Config) {
+		cfg.RedactionPatterns = append(cfg.RedactionPatterns,
+			config.ValidRedactionPatterns(patterns, "WithRedactionPatterns")...)
+	}
+}
+
+// WithRedactionAllowKeys sets the attribute keys that are never redacted,
+// overriding LAST9_REDACTION_ALLOW_KEYS.
+func WithRedactionAllowKeys(keys ...string) Option {
+	return func(cfg *config.Config) {
+		cfg.RedactionAllowKeys = keys
+	}
+}
+
+// WithRedactionDenyKeys sets the attribute keys whose values are always
+// redacted in full, overriding LAST9_REDACTION_DENY_KEYS.
+func WithRedactionDenyKeys(keys ...string) Option {
+	return func(cfg *config.Config) {
+		cfg.RedactionDenyKeys = keys
+	}
+}
+
+// WithRedactionMode sets what happens to sensitive data, overriding
+// LAST9_REDACTION_MODE: "mask", "hash" or "drop". Unsupported modes are
+// ignored with a warning.
+func WithRedactionMode(mode string) Option {
+	return func(cfg *config.Config) {
+		switch mode {
+		case config.RedactionModeMask, config.RedactionModeHash, config.RedactionModeDrop:
+			cfg.RedactionMode = mode
+		default:
+			logging.Warnf("WithRedactionMode(%q) ignored, want mask, hash or drop", mode)
+		}
+	}
+}
+
+// WithRedactionHashKey sets the secret key of the "hash" redaction mode,
+// overriding LAST9_REDACTION_HASH_KEY. Matches are then replaced with an
+// HMAC-SHA256 that cannot be reversed by hashing candidate values without
+// the key.
+func WithRedactionHashKey(key string) Option {
+	return func(cfg *config.Config) {
+		cfg.RedactionHashKey = key
+	}
+}
+
+// WithStrictConfig makes Start and New return a *config.ValidationError
+// when config.Validate reports an error, such as an unknown sampler or an
+// invalid LAST9_TRACE_SAMPLE_RATE, instead of starting with defaults in their
+// place. It overrides LAST9_STRICT_CONFIG. Warnings, such as a missing
+// endpoint, are still only logged.
+func WithStrictConfig() Option {
+	return func(cfg *config.Config) {
+		cfg.StrictConfig = true
+	}
+}
+
+// WithLogger sends the agent's own messages — warnings about the
+// configuration, startup and shutdown notices, and the debug output enabled
+// by LAST9_LOG_LEVEL=debug — to l instead of the standard log package. They
+// carry the attribute component=last9-agent. The logger is process-wide, so
+// it also receives the messages of the instrumentation packages.
+//
+// Example (route agent messages through the application's JSON logger):
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+//	agent.Start(agent.WithLogger(logger))
+func WithLogger(l *slog.Logger) Option {
+	return func(cfg *config.Config) {
+		cfg.Logger = l
+	}
+}
+
+// WithLogLevel sets the lowest level of the agent's own messages that are
+// logged, overriding LAST9_LOG_LEVEL: "debug", which also logs every export
+// batch and sampling decision, "info", "warn" or "error". Unsupported levels
+// are ignored with a warning.
+func WithLogLevel(level string) Option {
+	return func(cfg *config.Config) {
+		switch level {
+		case config.LogLevelDebug, config.LogLevelInfo, config.LogLevelWarn, config.LogLevelError:
+			cfg.LogLevel = level
+		default:
+			logging.Warnf("WithLogLevel(%q) ignored, want debug, info, warn or error", level)
+		}
+	}
+}
+
+// WithPersistentQueue spools trace and metric batches that cannot be
+// exported to dir and replays them once the endpoint recovers, overriding
+// LAST9_PERSISTENT_QUEUE_DIR.
+func WithPersistentQueue(dir string) Option {
+	return func(cfg *config.Config) {
+		cfg.PersistentQueueDir = dir
+	}
+}
+
+// WithResourceDetectors enables cloud metadata detectors that add cloud.*,
+// host.* and platform attributes to the resource, overriding
+// LAST9_RESOURCE_DETECTORS: "ec2", "ecs", "eks", "gce", "azure", or "all".
+func WithResourceDetectors(names ...string) Option {
+	return func(cfg *config.Config) {
+		cfg.ResourceDetectors = names
 	}
 }
 
 // Agent represents the Last9 telemetry agent
 type Agent struct {
-	config         *config.Config
-	routeMatcher   *routematcher.RouteMatcher
+	// settings holds the configuration and route matcher; Reload swaps both
+	// together while requests are in flight.
+	settings    atomic.Pointer[settings]
+	sampler     *reloadableSampler
+	tailSampler *tailsampling.Processor // nil unless tail sampling is enabled
+	telemetry   *selftelemetry.Telemetry
+
+	// startOpts are the options given to Start and reloadOpts those given to
+	// the latest Reload; both are re-applied on every reload. reloadMu
+	// guards reloadOpts.
+	startOpts  []Option
+	reloadOpts []Option
+	reloadMu   sync.Mutex
+	stopReload func()
+
+	// stopSignals stops the SIGTERM/SIGINT handler installed by Start when
+	// signal handling is enabled; nil otherwise.
+	stopSignals func()
+
+	// hooks are the functions registered with OnShutdown, run before the
+	// providers are flushed.
+	hooksMu sync.Mutex
+	hooks   []func(context.Context) error
+
 	tracerProvider *sdktrace.TracerProvider
 	meterProvider  *metric.MeterProvider
-	shutdown       func(context.Context) error
+	loggerProvider *sdklog.LoggerProvider
+	propagator     propagation.TextMapPropagator
+
+	// promHandler serves the Prometheus reader, and promServer serves
+	// promHandler on PrometheusAddr; both are nil unless enabled.
+	promHandler http.Handler
+	promServer  *http.Server
+
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
 // Start initializes the Last9 agent with configuration from environment variables,
 // optionally overridden by functional options.
 //
-// Configuration priority: environment variables (base) < functional options (override).
+// Configuration priority: config file (base) < environment variables < functional options (override).
 //
 // Environment variables:
+//   - LAST9_CONFIG_FILE: YAML or JSON file (.json) holding any of the settings below,
+//     e.g. for Kubernetes ConfigMaps; see the README for the schema. Start returns
+//     an error if the file cannot be read or parsed
 //   - OTEL_EXPORTER_OTLP_ENDPOINT: Last9 OTLP endpoint (optional for development)
+//   - OTEL_EXPORTER_OTLP_TRACES_ENDPOINT / OTEL_EXPORTER_OTLP_METRICS_ENDPOINT /
+//     OTEL_EXPORTER_OTLP_LOGS_ENDPOINT: Per-signal endpoints, used as-is and
+//     taking precedence over OTEL_EXPORTER_OTLP_ENDPOINT
+//   - OTEL_EXPORTER_OTLP_INSECURE: Use plaintext for endpoints without a
+//     scheme, such as "otel-collector:4317" (default: false, TLS)
+//   - OTEL_EXPORTER_OTLP_PROTOCOL: "grpc" or "http/protobuf" for all signals
+//     (default: http/protobuf for traces and logs, grpc for metrics)
 //   - OTEL_EXPORTER_OTLP_HEADERS: Authorization header (required for production)
+//   - LAST9_EXPORTER: "otlp", "console", which prints spans and metrics to
+//     stderr instead of exporting them, or "file", which writes them as
+//     OTLP/JSON lines to LAST9_FILE_EXPORTER_PATH (default: console when no
+//     endpoint is set and deployment.environment is development, dev or
+//     local; otherwise otlp)
 //   - OTEL_SERVICE_NAME: Service name (default: "unknown-service")
 //   - OTEL_RESOURCE_ATTRIBUTES: Additional resource attributes as key=value pairs
 //   - LAST9_TRACE_SAMPLE_RATE: Simple probabilistic sampling ratio (0.0 to 1.0).
 //     Maps to parentbased_traceidratio. Takes precedence over OTEL_TRACES_SAMPLER.
 //     Example: "0.5" samples 50% of new traces while respecting parent decisions.
 //   - OTEL_TRACES_SAMPLER: Trace sampling strategy (default: "always_on")
-//   - OTEL_TRACES_SAMPLER_ARG: Sampling ratio for traceidratio samplers (default: "1.0")
+//   - OTEL_TRACES_SAMPLER_ARG: Sampling ratio for traceidratio samplers (default: "1.0"),
+//     or traces per second for the ratelimited sampler (default: "100")
+//   - LAST9_SAMPLING_RATE_LIMIT_PER_ROUTE: Give each route its own ratelimited budget
+//   - LAST9_SAMPLING_RULES: Per-route sampling ratios for new traces, e.g.
+//     "route=/api/v1/poll,ratio=0.01;route=/checkout/**,ratio=1"
+//   - LAST9_TAIL_SAMPLING_ENABLED: Buffer spans per trace and always export
+//     traces with errors or a slow root span, sampling the rest by
+//     LAST9_TAIL_SAMPLING_RATIO; see the tailsampling package
+//   - LAST9_RELOAD_ON_SIGHUP: Call Reload when the process receives SIGHUP
+//   - LAST9_CONFIG_WATCH_INTERVAL: Call Reload when the config file changes,
+//     checking at this interval (e.g. "30s")
+//   - OTEL_PROPAGATORS: Trace context formats to read and write, e.g.
+//     "tracecontext,baggage,b3" (default: tracecontext,baggage)
+//   - OTEL_BSP_SCHEDULE_DELAY, OTEL_BSP_MAX_QUEUE_SIZE,
+//     OTEL_BSP_MAX_EXPORT_BATCH_SIZE: Batch span processor tuning
+//   - OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT, OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT,
+//     OTEL_SPAN_EVENT_COUNT_LIMIT: Span limits; set the value length limit
+//     to truncate long db.statement and captured body attributes
+//   - OTEL_METRIC_EXPORT_INTERVAL, OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE,
+//     OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION: Metric export
+//     interval (milliseconds), temporality and histogram aggregation
+//   - OTEL_METRICS_EXEMPLAR_FILTER: Which measurements link to their trace as
+//     exemplars (default: trace_based)
+//   - LAST9_PROMETHEUS_ADDR: Also serve metrics for Prometheus scrapes on
+//     this address, at LAST9_PROMETHEUS_PATH (default: /metrics)
+//   - LAST9_REDACTION_ENABLED: Redact emails, card numbers, JWTs and AWS keys
+//     in span attributes before export, as configured by
+//     LAST9_REDACTION_DETECTORS, LAST9_REDACTION_ALLOW_KEYS,
+//     LAST9_REDACTION_DENY_KEYS and LAST9_REDACTION_MODE (mask, hash or drop)
+//   - LAST9_STRICT_CONFIG: Return an error instead of starting when
+//     config.Validate reports an error; see WithStrictConfig
+//   - LAST9_LOG_LEVEL: Lowest level of the agent's own messages to log:
+//     debug, which also logs export batches and sampling decisions, info,
+//     warn or error (default: info); see WithLogger
+//   - LAST9_SHUTDOWN_TIMEOUT: How long Shutdown waits for pending telemetry
+//     to export (default: 5s)
+//   - LAST9_SIGNAL_HANDLING: Flush and shut down on SIGTERM or SIGINT, then
+//     exit; see WithSignalHandling
+//   - LAST9_RESOURCE_DETECTORS: Cloud metadata detectors to run at startup
+//     (ec2, ecs, eks, gce, azure or all), adding cloud.* and host.* attributes
+//   - LAST9_PERSISTENT_QUEUE_DIR: Spool trace and metric batches that fail to
+//     export to this directory and replay them when the endpoint recovers,
+//     bounded by LAST9_PERSISTENT_QUEUE_MAX_BYTES and LAST9_PERSISTENT_QUEUE_MAX_AGE
 //
 // Example with environment variables only:
 //
@@ -151,92 +782,253 @@ type Agent struct {
 func Start(opts ...Option) error {
 	var err error
 	once.Do(func() {
-		cfg := config.Load()
-
-		for _, opt := range opts {
-			opt(cfg)
-		}
-
-		res, resErr := createResource(cfg)
-		if resErr != nil {
-			err = fmt.Errorf("failed to create resource: %w", resErr)
+		a, newErr := New(opts...)
+		if newErr != nil {
+			err = newErr
 			return
 		}
 
-		tp, tpErr := initTracerProvider(res, cfg)
-		if tpErr != nil {
-			err = fmt.Errorf("failed to initialize tracer provider: %w", tpErr)
-			return
-		}
+		otel.SetErrorHandler(a.telemetry.ErrorHandler(otel.GetErrorHandler()))
+		otel.SetTracerProvider(a.tracerProvider)
+		otel.SetMeterProvider(a.meterProvider)
+		global.SetLoggerProvider(a.loggerProvider)
+		otel.SetTextMapPropagator(a.propagator)
 
-		mp, mpErr := initMeterProvider(res)
-		if mpErr != nil {
-			err = fmt.Errorf("failed to initialize meter provider: %w", mpErr)
-			return
+		// Start runtime metrics collection (version-specific implementation via build tags)
+		if runtimeErr := startRuntimeInstrumentation(a.Config().RuntimeMetricsInterval); runtimeErr != nil {
+			logging.Warnf("Failed to start runtime metrics: %v", runtimeErr)
 		}
 
-		otel.SetTracerProvider(tp)
-		otel.SetMeterProvider(mp)
-		otel.SetTextMapPropagator(
-			propagation.NewCompositeTextMapPropagator(
-				propagation.TraceContext{},
-				propagation.Baggage{},
-			),
-		)
+		if a.Config().SignalHandling {
+			a.stopSignals = a.handleSignals()
+		}
 
-		// Start runtime metrics collection (version-specific implementation via build tags)
-		if runtimeErr := startRuntimeInstrumentation(15 * time.Second); runtimeErr != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to start runtime metrics: %v", runtimeErr)
-		}
-
-		rm := routematcher.New(cfg.ExcludedPaths, cfg.ExcludedPathPrefixes, cfg.ExcludedPathPatterns)
-
-		globalAgent.Store(&Agent{
-			config:         cfg,
-			routeMatcher:   rm,
-			tracerProvider: tp,
-			meterProvider:  mp,
-			shutdown: func(ctx context.Context) error {
-				var errs []error
-				if err := tp.Shutdown(ctx); err != nil {
-					errs = append(errs, fmt.Errorf("tracer provider shutdown: %w", err))
-				}
-				if err := mp.Shutdown(ctx); err != nil {
-					errs = append(errs, fmt.Errorf("meter provider shutdown: %w", err))
-				}
-				if len(errs) > 0 {
-					return fmt.Errorf("shutdown errors: %v", errs)
-				}
-				return nil
-			},
-		})
+		globalAgent.Store(a)
 
-		log.Printf("[Last9 Agent] Started successfully for service: %s (with runtime metrics)", cfg.ServiceName)
+		logging.Infof("Started successfully for service: %s (with runtime metrics)", a.Config().ServiceName)
 	})
 	return err
 }
 
-// Shutdown gracefully shuts down the agent, flushing any pending telemetry.
-// It should be called before application exit, typically with defer.
+// New creates an agent independent of the global one started by Start, with
+// its own TracerProvider, MeterProvider, LoggerProvider, config and route
+// matcher. It reads the same configuration sources as Start but installs
+// nothing globally, so it suits libraries and binaries that embed several
+// logical services, each with its own service.name:
 //
-// Example:
+//	orders, err := agent.New(agent.WithServiceName("orders"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer orders.Shutdown(context.Background())
+//	http.Handle("/orders", nethttp.HandlerFor(orders, ordersHandler, "/orders"))
 //
-//	agent.Start()
-//	defer agent.Shutdown()
-func Shutdown() error {
-	if globalAgent.Load() == nil {
+// Pass the agent to the framework packages' *For variants, or use its
+// providers directly. Go runtime metrics describe the whole process and are
+// only reported by the agent created with Start.
+func New(opts ...Option) (*Agent, error) {
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	issues := config.Validate(cfg)
+	if cfg.StrictConfig {
+		if err := strictConfigError(issues); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := createResource(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	switch cfg.ResolvedExporter() {
+	case config.ExporterConsole:
+		logging.Infof("Printing spans and metrics to stderr (LAST9_EXPORTER=console)")
+	case config.ExporterFile:
+		logging.Infof("Writing spans and metrics to %s (LAST9_EXPORTER=file)", cfg.FileExporterPath)
+	}
+
+	for _, d := range cfg.Destinations {
+		logging.Infof("Sending a copy of telemetry to destination %q at %s", d.Name, d.Endpoint)
+	}
+
+	tel := selftelemetry.New()
+	sampler := newReloadableSampler(buildSampler(cfg))
+	tp, tail, err := initTracerProvider(res, cfg, sampler, tel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracer provider: %w", err)
+	}
+
+	var promReader metric.Reader
+	var promHandler http.Handler
+	if cfg.PrometheusEnabled {
+		if promReader, promHandler, err = newPrometheusReader(); err != nil {
+			_ = tp.Shutdown(context.Background())
+			return nil, fmt.Errorf("failed to create Prometheus reader: %w", err)
+		}
+	}
+
+	mp, err := initMeterProvider(res, cfg, tel, promReader)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		return nil, fmt.Errorf("failed to initialize meter provider: %w", err)
+	}
+	if tail != nil {
+		if tailErr := tail.RegisterMetrics(mp); tailErr != nil {
+			logging.Warnf("Failed to register tail sampling metrics: %v", tailErr)
+		}
+	}
+	if telErr := tel.RegisterMetrics(mp); telErr != nil {
+		logging.Warnf("Failed to register agent self-telemetry metrics: %v", telErr)
+	}
+
+	lp, err := initLoggerProvider(res, cfg)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		_ = mp.Shutdown(context.Background())
+		return nil, fmt.Errorf("failed to initialize logger provider: %w", err)
+	}
+
+	var promServer *http.Server
+	if cfg.PrometheusAddr != "" {
+		if promServer, err = servePrometheus(cfg.PrometheusAddr, cfg.PrometheusPath, promHandler); err != nil {
+			_ = tp.Shutdown(context.Background())
+			_ = mp.Shutdown(context.Background())
+			_ = lp.Shutdown(context.Background())
+			return nil, err
+		}
+	}
+
+	a := &Agent{
+		sampler:        sampler,
+		tailSampler:    tail,
+		telemetry:      tel,
+		startOpts:      opts,
+		tracerProvider: tp,
+		meterProvider:  mp,
+		loggerProvider: lp,
+		propagator:     buildPropagator(cfg.Propagators),
+		promHandler:    promHandler,
+		promServer:     promServer,
+	}
+	a.settings.Store(newSettings(cfg))
+	a.stopReload = a.startReloadTriggers(cfg)
+	logStartupSummary(cfg, sampler, issues)
+	return a, nil
+}
+
+// strictConfigError returns a *config.ValidationError holding the errors
+// among issues, or nil when there are none.
+func strictConfigError(issues []config.Issue) error {
+	var errs []config.Issue
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) == 0 {
 		return nil
 	}
+	return &config.ValidationError{Issues: errs}
+}
+
+// logStartupSummary logs the effective configuration in one line, so the
+// settings a service actually runs with can be checked in its logs. Header
+// values are never logged, as they usually hold credentials.
+func logStartupSummary(cfg *config.Config, sampler sdktrace.Sampler, issues []config.Issue) {
+	exporter := cfg.ResolvedExporter()
+	var endpoint string
+	switch exporter {
+	case config.ExporterFile:
+		endpoint = cfg.FileExporterPath
+	case config.ExporterOTLP:
+		endpoint = cfg.Endpoint
+		if cfg.TracesEndpoint != "" {
+			endpoint = cfg.TracesEndpoint
+		}
+		if endpoint == "" {
+			endpoint = "none"
+		}
+	default:
+		endpoint = "stderr"
+	}
+	protocol := cfg.Protocol
+	if protocol == "" {
+		protocol = "default"
+	}
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return "none"
+		}
+		return strings.Join(values, ",")
+	}
+	logging.Infof("Config: service=%s exporter=%s endpoint=%s protocol=%s sampler=%s "+
+		"excluded_paths=%s excluded_prefixes=%s excluded_patterns=%s resource_detectors=%s propagators=%s issues=%d",
+		cfg.ServiceName, exporter, endpoint, protocol, sampler.Description(),
+		list(cfg.ExcludedPaths), list(cfg.ExcludedPathPrefixes), list(cfg.ExcludedPathPatterns),
+		list(cfg.ResourceDetectors), list(cfg.Propagators), len(issues))
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// loadConfig builds the agent configuration from the config file, environment
+// variables and opts, in increasing order of precedence. When an option names
+// a different config file, the configuration is rebuilt from that file so the
+// options are applied on top of it.
+//
+// It then installs the configured logger and level. Messages logged while
+// loading are held until then, so that warnings about the configuration
+// reach the logger it sets.
+func loadConfig(opts []Option) (*config.Config, error) {
+	defer logging.Hold()()
 
-	if err := globalAgent.Load().shutdown(ctx); err != nil {
-		return fmt.Errorf("agent shutdown failed: %w", err)
+	envFile := os.Getenv("LAST9_CONFIG_FILE")
+	cfg, err := config.LoadFile(envFile)
+	for _, opt := range opts {
+		opt(cfg)
 	}
 
-	log.Println("[Last9 Agent] Shutdown complete")
-	return nil
+	if cfg.ConfigFile != "" && cfg.ConfigFile != envFile {
+		cfg, err = config.LoadFile(cfg.ConfigFile)
+		for _, opt := range opts {
+			opt(cfg)
+		}
+	}
+
+	if cfg.Logger != nil {
+		logging.SetLogger(cfg.Logger)
+	}
+	logging.SetLevel(logLevel(cfg.LogLevel))
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// logLevel returns the slog level named by name, one of the config.LogLevel
+// values.
+func logLevel(name string) slog.Level {
+	switch name {
+	case config.LogLevelDebug:
+		return slog.LevelDebug
+	case config.LogLevelWarn:
+		return slog.LevelWarn
+	case config.LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// TailSamplingStats returns the tail sampler's keep and drop counters, and
+// false when the agent is not started or tail sampling is disabled
+// (LAST9_TAIL_SAMPLING_ENABLED).
+func TailSamplingStats() (tailsampling.Stats, bool) {
+	a := globalAgent.Load()
+	if a == nil || a.tailSampler == nil {
+		return tailsampling.Stats{}, false
+	}
+	return a.tailSampler.Stats(), true
 }
 
 // IsInitialized returns true if the agent has been started
@@ -244,23 +1036,85 @@ func IsInitialized() bool {
 	return globalAgent.Load() != nil
 }
 
-// GetConfig returns the agent configuration (or nil if not initialized)
+// GetConfig returns the agent configuration (or nil if not initialized).
+// The returned Config is replaced, not modified, by Reload; call GetConfig
+// again rather than caching it to observe reloaded settings.
 func GetConfig() *config.Config {
-	if globalAgent.Load() == nil {
-		return nil
+	if a := globalAgent.Load(); a != nil {
+		return a.settings.Load().config
 	}
-	return globalAgent.Load().config
+	return nil
 }
 
 // GetRouteMatcher returns the route matcher for path exclusion (or nil if not initialized).
 // Nil is safe to use — RouteMatcher.ShouldExclude on nil returns false (no exclusion).
+// Like GetConfig, it reflects the latest Reload: middlewares call it per
+// request instead of keeping the result, so that reloaded exclusions apply
+// to them.
 func GetRouteMatcher() *routematcher.RouteMatcher {
 	if a := globalAgent.Load(); a != nil {
-		return a.routeMatcher
+		return a.settings.Load().routeMatcher
 	}
 	return nil
 }
 
+// The accessors below are safe to call on a nil *Agent, which stands for the
+// global agent started by Start and the global OpenTelemetry providers. The
+// framework packages' *For variants rely on this, so that Middleware() and
+// MiddlewareFor(nil) behave the same.
+
+// Config returns a's configuration. Like GetConfig, it reflects the latest
+// Reload, so call it again rather than caching it.
+func (a *Agent) Config() *config.Config {
+	if a == nil {
+		return GetConfig()
+	}
+	return a.settings.Load().config
+}
+
+// RouteMatcher returns a's route matcher for path exclusion. Like
+// GetRouteMatcher, it reflects the latest Reload, so call it per request.
+func (a *Agent) RouteMatcher() *routematcher.RouteMatcher {
+	if a == nil {
+		return GetRouteMatcher()
+	}
+	return a.settings.Load().routeMatcher
+}
+
+// TracerProvider returns a's tracer provider.
+func (a *Agent) TracerProvider() trace.TracerProvider {
+	if a == nil {
+		return otel.GetTracerProvider()
+	}
+	return a.tracerProvider
+}
+
+// MeterProvider returns a's meter provider.
+func (a *Agent) MeterProvider() otelmetric.MeterProvider {
+	if a == nil {
+		return otel.GetMeterProvider()
+	}
+	return a.meterProvider
+}
+
+// LoggerProvider returns a's logger provider, for the slog and zap export
+// handlers.
+func (a *Agent) LoggerProvider() otellog.LoggerProvider {
+	if a == nil {
+		return global.GetLoggerProvider()
+	}
+	return a.loggerProvider
+}
+
+// Propagator returns the propagator a uses to read and write trace context
+// in request headers.
+func (a *Agent) Propagator() propagation.TextMapPropagator {
+	if a == nil {
+		return otel.GetTextMapPropagator()
+	}
+	return a.propagator
+}
+
 // distroName is stamped onto every resource as telemetry.distro.name so that
 // telemetry produced by this agent can be identified on the backend.
 const distroName = "last9-go-agent"
@@ -308,8 +1162,12 @@ func createResource(cfg *config.Config) (*resource.Resource, error) {
 		resource.WithOS(),
 		resource.WithContainer(),
 		resource.WithHost(),
-		resource.WithAttributes(baseAttrs...),
+		resource.WithDetectors(resourcedetect.K8sDetector{}),
 	}
+	if detector := resourcedetect.New(cfg.ResourceDetectors); detector != nil {
+		attrs = append(attrs, resource.WithDetectors(detector))
+	}
+	attrs = append(attrs, resource.WithAttributes(baseAttrs...))
 
 	// Add custom attributes from config
 	if len(cfg.ResourceAttributes) > 0 {
@@ -319,31 +1177,131 @@ func createResource(cfg *config.Config) (*resource.Resource, error) {
 	return resource.New(context.Background(), attrs...)
 }
 
-// initTracerProvider creates and configures the trace provider
-func initTracerProvider(res *resource.Resource, cfg *config.Config) (*sdktrace.TracerProvider, error) {
-	exporter, err := otlptracehttp.New(context.Background())
+// initTracerProvider creates and configures the trace provider. When tail
+// sampling is enabled, the tail sampler sits in front of the batcher and is
+// returned as well. tel counts spans through the pipeline.
+func initTracerProvider(res *resource.Resource, cfg *config.Config, sampler sdktrace.Sampler, tel *selftelemetry.Telemetry) (*sdktrace.TracerProvider, *tailsampling.Processor, error) {
+	exporter, err := newTraceExporter(context.Background(), cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		return nil, nil, fmt.Errorf("failed to create trace exporter: %w", err)
 	}
 
-	// LAST9_TRACE_SAMPLE_RATE takes precedence over all other sampler config.
-	// It maps directly to parentbased_traceidratio for simplicity.
-	var sampler sdktrace.Sampler
-	if cfg.SampleRate >= 0 {
-		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))
-		log.Printf("[Last9 Agent] Using LAST9_TRACE_SAMPLE_RATE=%.4f (parentbased_traceidratio)", cfg.SampleRate)
-	} else {
-		sampler = createSampler(cfg)
+	// The batch processor's exporting batch is no longer in its queue, so up
+	// to a batch more spans than the queue size can be in flight.
+	maxBatch := min(cfg.BatchMaxExportBatchSize, cfg.BatchMaxQueueSize)
+	processors := []sdktrace.SpanProcessor{tel.LimitQueue(
+		sdktrace.NewBatchSpanProcessor(tel.WrapSpanExporter(exporter), batchOptions(cfg)...),
+		int(cfg.BatchMaxQueueSize+maxBatch),
+	)}
+	// Each destination gets its own batcher, so a slow one only drops its
+	// own spans.
+	for _, d := range cfg.Destinations {
+		if !d.HasSignal(config.SignalTraces) {
+			continue
+		}
+		destExporter, err := newTraceExporter(context.Background(), destinationConfig(cfg, d))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create trace exporter for destination %q: %w", d.Name, err)
+		}
+		processors = append(processors, destination.SpanProcessor(sdktrace.NewBatchSpanProcessor(destExporter, batchOptions(cfg)...), d.Filter))
+	}
+
+	sp := destination.Fanout(processors...)
+	// Spans are redacted as they leave the tail sampler, so dropped traces
+	// are never scanned.
+	if cfg.RedactionEnabled {
+		sp = redaction.New(sp, redactionOptions(cfg))
+		logging.Infof("Redaction enabled (mode: %s, detectors: %s)",
+			cfg.RedactionMode, strings.Join(cfg.RedactionDetectors, ","))
+	}
+
+	var tail *tailsampling.Processor
+	if cfg.TailSamplingEnabled {
+		tail = tailsampling.New(sp, tailsampling.Options{
+			Ratio:            cfg.TailSamplingRatio,
+			LatencyThreshold: cfg.TailSamplingLatencyThreshold,
+			DecisionWait:     cfg.TailSamplingDecisionWait,
+			MaxSpans:         int(cfg.TailSamplingMaxSpans),
+		})
+		sp = tail
+		logging.Infof("Tail sampling enabled (ratio: %.4f, latency threshold: %s)",
+			cfg.TailSamplingRatio, cfg.TailSamplingLatencyThreshold)
 	}
 
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSpanProcessor(sp),
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sampler),
+		sdktrace.WithRawSpanLimits(spanLimits(cfg)),
 		sdktrace.WithSpanProcessor(codeattr.New()),
+		sdktrace.WithSpanProcessor(tel.SpanCounter()),
 	)
 
-	return tp, nil
+	return tp, tail, nil
+}
+
+// redactionOptions returns the redaction processor options for cfg. Its
+// patterns were validated when cfg was loaded.
+func redactionOptions(cfg *config.Config) redaction.Options {
+	patterns := make([]*regexp.Regexp, 0, len(cfg.RedactionPatterns))
+	for _, p := range cfg.RedactionPatterns {
+		patterns = append(patterns, regexp.MustCompile(p))
+	}
+	return redaction.Options{
+		Detectors: cfg.RedactionDetectors,
+		Patterns:  patterns,
+		AllowKeys: cfg.RedactionAllowKeys,
+		DenyKeys:  cfg.RedactionDenyKeys,
+		Mode:      redaction.Mode(cfg.RedactionMode),
+		HashKey:   []byte(cfg.RedactionHashKey),
+	}
+}
+
+// batchOptions returns the batch span processor options for cfg.
+func batchOptions(cfg *config.Config) []sdktrace.BatchSpanProcessorOption {
+	return []sdktrace.BatchSpanProcessorOption{
+		sdktrace.WithBatchTimeout(cfg.BatchScheduleDelay),
+		sdktrace.WithMaxQueueSize(int(cfg.BatchMaxQueueSize)),
+		sdktrace.WithMaxExportBatchSize(int(cfg.BatchMaxExportBatchSize)),
+	}
+}
+
+// spanLimits returns the span limits for cfg. Limits the agent does not
+// configure keep the SDK's defaults and OTEL_* environment variables.
+func spanLimits(cfg *config.Config) sdktrace.SpanLimits {
+	limits := sdktrace.NewSpanLimits()
+	limits.AttributeCountLimit = int(cfg.SpanAttributeCountLimit)
+	limits.EventCountLimit = int(cfg.SpanEventCountLimit)
+	limits.AttributeValueLengthLimit = -1 // no limit
+	if cfg.AttributeValueLengthLimit > 0 {
+		limits.AttributeValueLengthLimit = int(cfg.AttributeValueLengthLimit)
+	}
+	return limits
+}
+
+// buildSampler returns the sampler for cfg.
+// LAST9_TRACE_SAMPLE_RATE takes precedence over all other sampler config.
+// It maps directly to parentbased_traceidratio for simplicity.
+// Sampling rules, when configured, are consulted first for new traces.
+// The ratelimited sampler's limit also caps the traces they sample.
+func buildSampler(cfg *config.Config) sdktrace.Sampler {
+	var sampler, limiter sdktrace.Sampler
+	switch {
+	case cfg.SampleRate >= 0:
+		logging.Infof("Using LAST9_TRACE_SAMPLE_RATE=%.4f (parentbased_traceidratio)", cfg.SampleRate)
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))
+	case cfg.Sampler == "ratelimited" && len(cfg.SamplingRules) > 0:
+		// Rules share the token bucket, so they cannot exceed the limit.
+		limiter = newRateLimiter(cfg)
+		sampler = sdktrace.ParentBased(limiter)
+	default:
+		sampler = createSampler(cfg)
+	}
+	if len(cfg.SamplingRules) > 0 {
+		logging.Infof("Using %d sampling rules", len(cfg.SamplingRules))
+		sampler = sampling.NewRules(cfg.SamplingRules, sampler, limiter)
+	}
+	return sampler
 }
 
 // createSampler creates an OpenTelemetry sampler based on the config.
@@ -354,62 +1312,162 @@ func initTracerProvider(res *resource.Resource, cfg *config.Config) (*sdktrace.T
 //   - parentbased_always_on: Always sample if parent is sampled, otherwise always sample
 //   - parentbased_always_off: Always sample if parent is sampled, otherwise never sample
 //   - parentbased_traceidratio: Always sample if parent is sampled, otherwise use ratio
+//
+// and a Last9 extension:
+//   - ratelimited: Follow the parent's decision, otherwise keep at most
+//     OTEL_TRACES_SAMPLER_ARG new traces per second (token bucket)
 func createSampler(cfg *config.Config) sdktrace.Sampler {
 	switch cfg.Sampler {
 	case "always_off":
 		return sdktrace.NeverSample()
 	case "traceidratio":
-		ratio := cfg.SamplerRatio
-		if ratio == 0 {
-			ratio = parseSamplerRatio(os.Getenv("OTEL_TRACES_SAMPLER_ARG"))
-		}
-		return sdktrace.TraceIDRatioBased(ratio)
+		return sdktrace.TraceIDRatioBased(samplerRatio(cfg))
 	case "parentbased_always_on":
 		return sdktrace.ParentBased(sdktrace.AlwaysSample())
 	case "parentbased_always_off":
 		return sdktrace.ParentBased(sdktrace.NeverSample())
 	case "parentbased_traceidratio":
-		ratio := cfg.SamplerRatio
-		if ratio == 0 {
-			ratio = parseSamplerRatio(os.Getenv("OTEL_TRACES_SAMPLER_ARG"))
-		}
-		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplerRatio(cfg)))
+	case "ratelimited":
+		return sdktrace.ParentBased(newRateLimiter(cfg))
 	case "always_on", "":
 		return sdktrace.AlwaysSample()
 	default:
-		log.Printf("[Last9 Agent] Warning: Unknown sampler %q, using always_on", cfg.Sampler)
+		logging.Warnf("Unknown sampler %q, using always_on", cfg.Sampler)
 		return sdktrace.AlwaysSample()
 	}
 }
 
-// parseSamplerRatio parses the sampling ratio from a string.
-// Returns 1.0 if the string is empty or invalid.
-// The ratio must be between 0.0 and 1.0.
-func parseSamplerRatio(ratioStr string) float64 {
-	if ratioStr == "" {
+// samplerRatio returns the ratio of traceidratio samplers, 1.0 when unset.
+func samplerRatio(cfg *config.Config) float64 {
+	if cfg.SamplerRatio == 0 && !cfg.SamplerRatioSet {
 		return 1.0
 	}
-	ratio, err := strconv.ParseFloat(ratioStr, 64)
-	if err != nil || ratio < 0 || ratio > 1 {
-		log.Printf("[Last9 Agent] Warning: Invalid sampler ratio %q, using 1.0", ratioStr)
-		return 1.0
+	return cfg.SamplerRatio
+}
+
+// newRateLimiter creates the token bucket of the ratelimited sampler, with
+// a limit of 100 traces per second when unset.
+func newRateLimiter(cfg *config.Config) sdktrace.Sampler {
+	limit := cfg.SamplerRateLimit
+	if limit <= 0 {
+		limit = 100
 	}
-	return ratio
+	return sampling.NewRateLimited(limit, cfg.SamplerRateLimitPerRoute)
 }
 
-// initMeterProvider creates and configures the meter provider
-func initMeterProvider(res *resource.Resource) (*metric.MeterProvider, error) {
-	exporter, err := otlpmetricgrpc.New(context.Background())
+// initMeterProvider creates and configures the meter provider. tel times
+// and counts the metric exports. prom, when not nil, is registered as an
+// additional reader.
+func initMeterProvider(res *resource.Resource, cfg *config.Config, tel *selftelemetry.Telemetry, prom metric.Reader) (*metric.MeterProvider, error) {
+	exporter, err := newMetricExporter(context.Background(), cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
 	}
 
-	mp := metric.NewMeterProvider(
+	opts := []metric.Option{
 		metric.WithResource(res),
+		metric.WithView(metricViews(cfg.MetricViews)...),
+		metric.WithExemplarFilter(exemplarFilter(cfg.MetricExemplarFilter)),
 		metric.WithReader(
-			metric.NewPeriodicReader(exporter, metric.WithInterval(1*time.Minute)),
+			newPeriodicReader(tel.WrapMetricExporter(exporter), cfg),
 		),
-	)
+	}
+	for _, d := range cfg.Destinations {
+		if !d.HasSignal(config.SignalMetrics) {
+			continue
+		}
+		destExporter, err := newMetricExporter(context.Background(), destinationConfig(cfg, d))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric exporter for destination %q: %w", d.Name, err)
+		}
+		opts = append(opts, metric.WithReader(
+			newPeriodicReader(destination.MetricExporter(destExporter, d.Filter), cfg),
+		))
+	}
+
+	if prom != nil {
+		opts = append(opts, metric.WithReader(prom))
+	}
+
+	mp := metric.NewMeterProvider(opts...)
 
 	return mp, nil
 }
+
+// exemplarFilter returns the exemplar filter named by name, one of the
+// config.ExemplarFilter values.
+func exemplarFilter(name string) exemplar.Filter {
+	switch name {
+	case config.ExemplarFilterAlwaysOn:
+		return exemplar.AlwaysOnFilter
+	case config.ExemplarFilterAlwaysOff:
+		return exemplar.AlwaysOffFilter
+	default:
+		return exemplar.TraceBasedFilter
+	}
+}
+
+// metricViews converts the configured views to SDK views. Bucket
+// boundaries only apply to histograms, so that a glob matching counters too
+// does not turn them into histograms.
+func metricViews(views []config.MetricView) []metric.View {
+	out := make([]metric.View, 0, len(views))
+	for _, v := range views {
+		stream := metric.Stream{Name: v.Name}
+		if v.Drop {
+			stream.Aggregation = metric.AggregationDrop{}
+		}
+		if len(v.AttributeKeys) > 0 {
+			keys := make([]attribute.Key, len(v.AttributeKeys))
+			for i, k := range v.AttributeKeys {
+				keys[i] = attribute.Key(k)
+			}
+			stream.AttributeFilter = attribute.NewAllowKeysFilter(keys...)
+		}
+		view := metric.NewView(
+			metric.Instrument{Name: v.Instrument, Scope: instrumentation.Scope{Name: v.Scope}},
+			stream,
+		)
+		if !v.Drop && len(v.Buckets) > 0 {
+			view = withBuckets(view, v.Buckets)
+		}
+		out = append(out, view)
+	}
+	return out
+}
+
+// withBuckets returns view, setting the bucket boundaries of the histograms
+// it matches.
+func withBuckets(view metric.View, buckets []float64) metric.View {
+	return func(inst metric.Instrument) (metric.Stream, bool) {
+		stream, ok := view(inst)
+		if ok && inst.Kind == metric.InstrumentKindHistogram {
+			stream.Aggregation = metric.AggregationExplicitBucketHistogram{Boundaries: buckets}
+		}
+		return stream, ok
+	}
+}
+
+// newPeriodicReader returns a reader that exports to exporter every
+// cfg.MetricExportInterval with the configured temporality and histogram
+// aggregation.
+func newPeriodicReader(exporter metric.Exporter, cfg *config.Config) metric.Reader {
+	return metric.NewPeriodicReader(withMetricSelectors(exporter, cfg), metric.WithInterval(cfg.MetricExportInterval))
+}
+
+// initLoggerProvider creates and configures the logger provider used by the
+// slog and zap bridges to ship log records.
+func initLoggerProvider(res *resource.Resource, cfg *config.Config) (*sdklog.LoggerProvider, error) {
+	exporter, err := newLogExporter(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log exporter: %w", err)
+	}
+
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	)
+
+	return lp, nil
+}
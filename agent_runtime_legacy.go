@@ -4,10 +4,10 @@ package agent
 
 import (
 	"context"
-	"log"
 	"runtime"
 	"time"
 
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
 )
@@ -24,7 +24,7 @@ import (
 // This version is used for Go 1.22 and 1.23, which cannot use the full
 // contrib/instrumentation/runtime package.
 func startRuntimeInstrumentation(interval time.Duration) error {
-	log.Printf("[Last9 Agent] Using legacy runtime instrumentation (Go 1.22/1.23) - basic metrics only")
+	logging.Infof("Using legacy runtime instrumentation (Go 1.22/1.23) - basic metrics only")
 
 	meter := otel.Meter("github.com/last9/go-agent/runtime-legacy")
 
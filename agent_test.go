@@ -3,10 +3,34 @@
 package agent
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/last9/go-agent/config"
+	"github.com/last9/go-agent/internal/console"
+	"github.com/last9/go-agent/internal/diskqueue"
+	"github.com/last9/go-agent/internal/logging"
+	"github.com/last9/go-agent/internal/otlpfile"
+	slogagent "github.com/last9/go-agent/instrumentation/slog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
 )
 
@@ -145,6 +169,7 @@ func TestCreateSampler(t *testing.T) {
 		{"empty", "", "should default to AlwaysSample"},
 		{"traceidratio", "traceidratio", "should create TraceIDRatioBased sampler"},
 		{"parentbased_always_on", "parentbased_always_on", "should create ParentBased(AlwaysSample) sampler"},
+		{"ratelimited", "ratelimited", "should create ParentBased(RateLimited) sampler"},
 		{"unknown", "invalid_sampler", "should default to AlwaysSample with warning"},
 	}
 
@@ -410,28 +435,38 @@ func TestGetRouteMatcher(t *testing.T) {
 	}
 }
 
-func TestParseSamplerRatio(t *testing.T) {
+func TestCreateSamplerRatio(t *testing.T) {
 	tests := []struct {
-		input string
-		want  float64
+		ratio float64
+		set   bool
+		want  string
 	}{
-		{"", 1.0},           // empty defaults to 1.0
-		{"0.5", 0.5},        // valid ratio
-		{"0.0", 0.0},        // min valid
-		{"1.0", 1.0},        // max valid
-		{"invalid", 1.0},    // invalid defaults to 1.0
-		{"-0.5", 1.0},       // negative defaults to 1.0
-		{"1.5", 1.0},        // > 1.0 defaults to 1.0
-		{"0.123456", 0.123456}, // precise value
+		{0, true, "TraceIDRatioBased{0}"},
+		{0.25, true, "TraceIDRatioBased{0.25}"},
+		{0.25, false, "TraceIDRatioBased{0.25}"}, // hand-built config without the flag
+		{0, false, "AlwaysOnSampler"},            // unset: ratio 1
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got := parseSamplerRatio(tt.input)
-			if got != tt.want {
-				t.Errorf("parseSamplerRatio(%q) = %v, want %v", tt.input, got, tt.want)
-			}
-		})
+		cfg := &config.Config{Sampler: "traceidratio", SamplerRatio: tt.ratio, SamplerRatioSet: tt.set}
+		if got := createSampler(cfg).Description(); got != tt.want {
+			t.Errorf("createSampler(ratio %v, set %t) = %q, want %q", tt.ratio, tt.set, got, tt.want)
+		}
+	}
+}
+
+func TestWithSamplingRateLimit(t *testing.T) {
+	cfg := &config.Config{Sampler: "always_on"}
+	WithSamplingRateLimit(5)(cfg)
+	if cfg.Sampler != "ratelimited" || cfg.SamplerRateLimit != 5 {
+		t.Fatalf("Sampler/SamplerRateLimit = %q/%v, want ratelimited/5", cfg.Sampler, cfg.SamplerRateLimit)
+	}
+	if got := createSampler(cfg).Description(); !strings.HasPrefix(got, "ParentBased{root:RateLimited{5}") {
+		t.Errorf("sampler = %q, want ParentBased{root:RateLimited{5}...", got)
+	}
+
+	WithSamplingRateLimit(0)(cfg)
+	if cfg.SamplerRateLimit != 5 {
+		t.Errorf("SamplerRateLimit = %v, want 5 (invalid limit ignored)", cfg.SamplerRateLimit)
 	}
 }
 
@@ -456,3 +491,1275 @@ func TestCreateResourceStampsDistroAttributes(t *testing.T) {
 		t.Errorf("%s should be non-empty", versionKey)
 	}
 }
+
+func TestSignalEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		signal   string
+		protocol string
+		insecure bool
+		want     string
+	}{
+		{"nothing set", "", "", config.ProtocolHTTPProtobuf, false, ""},
+		{"http appends path", "https://otlp.last9.io", "", config.ProtocolHTTPProtobuf, false, "https://otlp.last9.io/v1/traces"},
+		{"http trims trailing slash", "https://otlp.last9.io/", "", config.ProtocolHTTPProtobuf, false, "https://otlp.last9.io/v1/traces"},
+		{"grpc uses base as-is", "http://sidecar:4317", "", config.ProtocolGRPC, false, "http://sidecar:4317"},
+		{"signal endpoint wins", "https://otlp.last9.io", "http://traces:4318/custom", config.ProtocolHTTPProtobuf, false, "http://traces:4318/custom"},
+		{"missing scheme defaults to https", "sidecar:4317", "", config.ProtocolGRPC, false, "https://sidecar:4317"},
+		{"missing scheme with insecure is http", "localhost:4317", "", config.ProtocolGRPC, true, "http://localhost:4317"},
+		{"insecure signal endpoint", "", "localhost:4318/v1/traces", config.ProtocolHTTPProtobuf, true, "http://localhost:4318/v1/traces"},
+		{"insecure keeps explicit scheme", "https://otlp.last9.io", "", config.ProtocolGRPC, true, "https://otlp.last9.io"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := signalEndpoint(tt.base, tt.signal, tracesURLPath, tt.protocol, tt.insecure)
+			if got != tt.want {
+				t.Errorf("signalEndpoint(%q, %q) = %q, want %q", tt.base, tt.signal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistentQueueExporters(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Endpoint:                "http://localhost:4318",
+		Protocol:                config.ProtocolHTTPProtobuf,
+		PersistentQueueMaxBytes: 1 << 20,
+		PersistentQueueMaxAge:   time.Hour,
+	}
+	WithPersistentQueue(dir)(cfg)
+
+	te, err := newTraceExporter(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newTraceExporter() error = %v", err)
+	}
+	defer te.Shutdown(context.Background())
+	if _, ok := te.(*diskqueue.TraceExporter); !ok {
+		t.Errorf("newTraceExporter() = %T, want *diskqueue.TraceExporter", te)
+	}
+
+	me, err := newMetricExporter(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMetricExporter() error = %v", err)
+	}
+	defer me.Shutdown(context.Background())
+	if _, ok := me.(*diskqueue.MetricExporter); !ok {
+		t.Errorf("newMetricExporter() = %T, want *diskqueue.MetricExporter", me)
+	}
+
+	for _, signal := range []string{"traces", "metrics"} {
+		if _, err := os.Stat(filepath.Join(dir, signal)); err != nil {
+			t.Errorf("queue directory for %s: %v", signal, err)
+		}
+	}
+}
+
+func TestConsoleExporters(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Config
+	}{
+		{"explicit", &config.Config{Endpoint: "http://localhost:4318"}},
+		{"no endpoint in development", &config.Config{Environment: "development"}},
+	}
+	WithExporter(config.ExporterConsole)(tests[0].cfg)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te, err := newTraceExporter(context.Background(), tt.cfg)
+			if err != nil {
+				t.Fatalf("newTraceExporter() error = %v", err)
+			}
+			if _, ok := te.(*console.TraceExporter); !ok {
+				t.Errorf("newTraceExporter() = %T, want *console.TraceExporter", te)
+			}
+			me, err := newMetricExporter(context.Background(), tt.cfg)
+			if err != nil {
+				t.Fatalf("newMetricExporter() error = %v", err)
+			}
+			if _, ok := me.(*console.MetricExporter); !ok {
+				t.Errorf("newMetricExporter() = %T, want *console.MetricExporter", me)
+			}
+		})
+	}
+}
+
+func TestFileExporters(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Endpoint: "http://localhost:4318", FileExporterMaxBytes: 1 << 20}
+	WithFileExporter(dir)(cfg)
+
+	te, err := newTraceExporter(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newTraceExporter() error = %v", err)
+	}
+	defer te.Shutdown(context.Background())
+	if _, ok := te.(*otlpfile.TraceExporter); !ok {
+		t.Errorf("newTraceExporter() = %T, want *otlpfile.TraceExporter", te)
+	}
+	me, err := newMetricExporter(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMetricExporter() error = %v", err)
+	}
+	defer me.Shutdown(context.Background())
+	if _, ok := me.(*otlpfile.MetricExporter); !ok {
+		t.Errorf("newMetricExporter() = %T, want *otlpfile.MetricExporter", me)
+	}
+
+	for _, name := range []string{"traces.jsonl", "metrics.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("file exporter output: %v", err)
+		}
+	}
+}
+
+func TestMetricTemporalityAndHistograms(t *testing.T) {
+	dir := t.TempDir()
+	a, err := New(
+		WithServiceName("test-service"),
+		WithFileExporter(dir),
+		WithMetricExportInterval(time.Hour),
+		WithMetricTemporality(config.MetricTemporalityDelta),
+		WithMetricTemporality("sometimes"),
+		WithExponentialHistograms(),
+		WithRuntimeMetricsInterval(-time.Second),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if cfg := a.Config(); cfg.MetricExportInterval != time.Hour || cfg.MetricTemporality != config.MetricTemporalityDelta ||
+		cfg.RuntimeMetricsInterval != config.DefaultRuntimeMetricsInterval {
+		t.Errorf("config = %v/%q/%v, want 1h, delta and the default runtime interval",
+			cfg.MetricExportInterval, cfg.MetricTemporality, cfg.RuntimeMetricsInterval)
+	}
+
+	meter := a.MeterProvider().Meter("test")
+	counter, _ := meter.Int64Counter("orders")
+	counter.Add(context.Background(), 3)
+	histogram, _ := meter.Float64Histogram("latency")
+	histogram.Record(context.Background(), 0.25)
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "metrics.jsonl"))
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	for _, want := range []string{
+		`"name":"orders","sum":{"aggregationTemporality":1`,
+		`{"exponentialHistogram":{"aggregationTemporality":1`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported metrics do not contain %s:\n%s", want, data)
+		}
+	}
+}
+
+func TestExemplars(t *testing.T) {
+	tests := []struct {
+		filter        string
+		wantExemplars bool
+	}{
+		{config.ExemplarFilterTraceBased, true},
+		{config.ExemplarFilterAlwaysOff, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			dir := t.TempDir()
+			a, err := New(WithServiceName("test-service"), WithFileExporter(dir), WithExemplarFilter(tt.filter))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			ctx, span := a.TracerProvider().Tracer("test").Start(context.Background(), "checkout")
+			latency, _ := a.MeterProvider().Meter("test").Float64Histogram("latency")
+			latency.Record(ctx, 0.25)
+			span.End()
+			if err := a.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "metrics.jsonl"))
+			if err != nil {
+				t.Fatalf("read metrics: %v", err)
+			}
+			traceID := span.SpanContext().TraceID().String()
+			if got := strings.Contains(string(data), `"exemplars":[{`) && strings.Contains(string(data), traceID); got != tt.wantExemplars {
+				t.Errorf("exemplar with trace %s exported = %t, want %t", traceID, got, tt.wantExemplars)
+			}
+		})
+	}
+}
+
+func TestMetricViews(t *testing.T) {
+	dir := t.TempDir()
+	a, err := New(
+		WithServiceName("test-service"),
+		WithFileExporter(dir),
+		WithMetricViews(
+			config.MetricView{Instrument: "orders", Name: "shop.orders", AttributeKeys: []string{"region"}},
+			config.MetricView{Instrument: "cache.*", Drop: true},
+			config.MetricView{Instrument: "latency", Buckets: []float64{0.5, 1}},
+			config.MetricView{Instrument: "*", Name: "invalid"},
+		),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if n := len(a.Config().MetricViews); n != 3 {
+		t.Errorf("len(MetricViews) = %d, want 3 (the invalid view ignored)", n)
+	}
+
+	ctx := context.Background()
+	meter := a.MeterProvider().Meter("test")
+	orders, _ := meter.Int64Counter("orders")
+	orders.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("region", "eu"), attribute.String("user.id", "42")))
+	hits, _ := meter.Int64Counter("cache.hits")
+	hits.Add(ctx, 1)
+	latency, _ := meter.Float64Histogram("latency")
+	latency.Record(ctx, 0.25)
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "metrics.jsonl"))
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{`"name":"shop.orders"`, `"region"`, `"explicitBounds":[0.5,1]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exported metrics do not contain %s", want)
+		}
+	}
+	for _, unwanted := range []string{`"name":"orders"`, `"user.id"`, `"name":"cache.hits"`} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("exported metrics contain %s", unwanted)
+		}
+	}
+}
+
+func TestMetricViewBucketsOnlyHistograms(t *testing.T) {
+	reader := metric.NewManualReader()
+	mp := metric.NewMeterProvider(
+		metric.WithReader(reader),
+		metric.WithView(metricViews([]config.MetricView{
+			{Instrument: "http.server.*", AttributeKeys: []string{"http.route"}, Buckets: []float64{1, 2}},
+		})...),
+	)
+	ctx := context.Background()
+	meter := mp.Meter("test")
+	active, _ := meter.Int64UpDownCounter("http.server.active_requests")
+	active.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("http.route", "/a"), attribute.String("user.id", "42")))
+	duration, _ := meter.Float64Histogram("http.server.request.duration")
+	duration.Record(ctx, 1.5)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if n := len(rm.ScopeMetrics[0].Metrics); n != 2 {
+		t.Fatalf("collected %d metrics, want 2", n)
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Sum[int64]:
+			if m.Name != "http.server.active_requests" {
+				t.Errorf("sum %q, want http.server.active_requests", m.Name)
+			}
+			if attrs := data.DataPoints[0].Attributes; attrs.Len() != 1 {
+				t.Errorf("active_requests attributes = %v, want only http.route", attrs.ToSlice())
+			}
+		case metricdata.Histogram[float64]:
+			if got := data.DataPoints[0].Bounds; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+				t.Errorf("histogram bounds = %v, want [1 2]", got)
+			}
+		default:
+			t.Errorf("%s is a %T, want the counter to stay a sum", m.Name, m.Data)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	dir := t.TempDir()
+	a, err := New(
+		WithServiceName("test-service"),
+		WithFileExporter(dir),
+		WithRedaction(),
+		WithRedactionDenyKeys("enduser.id"),
+		WithRedactionPatterns(`order-secret-[0-9]+`, `(unclosed`),
+		WithRedactionMode("shred"),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if cfg := a.Config(); len(cfg.RedactionPatterns) != 1 || cfg.RedactionMode != config.RedactionModeMask {
+		t.Errorf("RedactionPatterns = %q, RedactionMode = %q, want the valid pattern and mask", cfg.RedactionPatterns, cfg.RedactionMode)
+	}
+
+	ctx := context.Background()
+	_, span := a.TracerProvider().Tracer("test").Start(ctx, "checkout")
+	span.SetAttributes(
+		attribute.String("enduser.id", "42"),
+		attribute.String("db.statement", "SELECT * FROM users WHERE email = 'jane@example.com'"),
+		attribute.String("note", "ref order-secret-1234"),
+	)
+	span.End()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "traces.jsonl"))
+	if err != nil {
+		t.Fatalf("read traces: %v", err)
+	}
+	out := string(data)
+	if n := strings.Count(out, "[REDACTED]"); n != 3 {
+		t.Errorf("exported spans contain %d redacted values, want 3", n)
+	}
+	for _, unwanted := range []string{`"42"`, "jane@example.com", "order-secret-1234"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("exported spans contain %s", unwanted)
+		}
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	os.Setenv("LAST9_EXCLUDED_PATHS", "")
+	defer os.Unsetenv("LAST9_EXCLUDED_PATHS")
+	os.Setenv("LAST9_PROMETHEUS_PATH", "/prom")
+	defer os.Unsetenv("LAST9_PROMETHEUS_PATH")
+
+	a, err := New(
+		WithServiceName("test-service"),
+		WithFileExporter(t.TempDir()),
+		WithPrometheusAddr("127.0.0.1:0"),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer a.Shutdown(context.Background())
+	if a.PrometheusHandler() == nil {
+		t.Fatal("PrometheusHandler() = nil with WithPrometheusAddr")
+	}
+	if !a.RouteMatcher().ShouldExclude("/prom") {
+		t.Error("the Prometheus path is not excluded from tracing")
+	}
+
+	counter, _ := a.MeterProvider().Meter("test").Int64Counter("orders")
+	counter.Add(context.Background(), 3)
+
+	resp, err := http.Get("http://" + a.promServer.Addr + "/prom")
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "orders_total{") || !strings.Contains(string(body), "last9_agent_sdk_errors_total") {
+		t.Errorf("scrape does not contain the application and agent metrics:\n%s", body)
+	}
+
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if _, err := http.Get("http://" + a.promServer.Addr + "/prom"); err == nil {
+		t.Error("Prometheus endpoint still serving after Shutdown")
+	}
+}
+
+func TestStrictConfig(t *testing.T) {
+	os.Setenv("LAST9_TRACE_SAMPLE_RATE", "2")
+	defer os.Unsetenv("LAST9_TRACE_SAMPLE_RATE")
+
+	a, err := New(WithServiceName("test-service"), WithFileExporter(t.TempDir()))
+	if err != nil {
+		t.Fatalf("New() without strict config failed: %v", err)
+	}
+	_ = a.Shutdown(context.Background())
+
+	_, err = New(WithServiceName("test-service"), WithFileExporter(t.TempDir()), WithStrictConfig())
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("New() with strict config error = %v, want a *config.ValidationError", err)
+	}
+	if len(verr.Issues) != 1 || verr.Issues[0].Field != "LAST9_TRACE_SAMPLE_RATE" {
+		t.Errorf("Issues = %v, want one for LAST9_TRACE_SAMPLE_RATE", verr.Issues)
+	}
+
+	// Warnings, such as the missing service name, do not fail strict mode.
+	os.Unsetenv("LAST9_TRACE_SAMPLE_RATE")
+	a, err = New(WithFileExporter(t.TempDir()), WithStrictConfig())
+	if err != nil {
+		t.Fatalf("New() with only warnings failed: %v", err)
+	}
+	_ = a.Shutdown(context.Background())
+}
+
+func TestWithLogger(t *testing.T) {
+	os.Setenv("LAST9_REDACTION_MODE", "bogus")
+	defer os.Unsetenv("LAST9_REDACTION_MODE")
+	defer logging.SetLogger(nil)
+	defer logging.SetLevel(slog.LevelInfo)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	a, err := New(WithServiceName("test-service"), WithFileExporter(t.TempDir()),
+		WithLogger(logger), WithLogLevel(config.LogLevelDebug), WithMetricTemporality("bogus"))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	_, span := a.TracerProvider().Tracer("test").Start(context.Background(), "checkout")
+	span.End()
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		// Warnings logged while loading the config reach the logger too.
+		`level=WARN msg="Unsupported LAST9_REDACTION_MODE`,
+		`level=WARN msg="WithMetricTemporality(\"bogus\") ignored`,
+		`level=INFO msg="Config: service=test-service`,
+		`level=DEBUG msg="Sampler AlwaysOnSampler: sampled span \"checkout\"`,
+		`level=DEBUG msg="Exported traces batch of 1 spans`,
+		"component=last9-agent",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output does not contain %s:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	a, err = New(WithServiceName("test-service"), WithFileExporter(t.TempDir()), WithLogLevel(config.LogLevelError))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	_ = a.Shutdown(context.Background())
+	if buf.Len() != 0 {
+		t.Errorf("messages below LAST9_LOG_LEVEL=error logged:\n%s", buf.String())
+	}
+}
+
+func TestPrometheusDisabled(t *testing.T) {
+	if PrometheusHandler() != nil {
+		t.Error("PrometheusHandler() != nil before Start")
+	}
+	a, err := New(WithServiceName("test-service"), WithFileExporter(t.TempDir()))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer a.Shutdown(context.Background())
+	if a.PrometheusHandler() != nil {
+		t.Error("PrometheusHandler() != nil without WithPrometheus")
+	}
+
+	if _, err := New(WithServiceName("test-service"), WithFileExporter(t.TempDir()), WithPrometheusAddr("127.0.0.1:-1")); err == nil {
+		t.Error("New() with an invalid Prometheus address succeeded")
+	}
+}
+
+func TestTemporalitySelector(t *testing.T) {
+	tests := []struct {
+		preference string
+		want       map[metric.InstrumentKind]metricdata.Temporality
+	}{
+		{config.MetricTemporalityDelta, map[metric.InstrumentKind]metricdata.Temporality{
+			metric.InstrumentKindCounter:                 metricdata.DeltaTemporality,
+			metric.InstrumentKindObservableCounter:       metricdata.DeltaTemporality,
+			metric.InstrumentKindHistogram:               metricdata.DeltaTemporality,
+			metric.InstrumentKindUpDownCounter:           metricdata.CumulativeTemporality,
+			metric.InstrumentKindObservableUpDownCounter: metricdata.CumulativeTemporality,
+		}},
+		{config.MetricTemporalityLowMemory, map[metric.InstrumentKind]metricdata.Temporality{
+			metric.InstrumentKindCounter:                 metricdata.DeltaTemporality,
+			metric.InstrumentKindObservableCounter:       metricdata.CumulativeTemporality,
+			metric.InstrumentKindHistogram:               metricdata.DeltaTemporality,
+			metric.InstrumentKindUpDownCounter:           metricdata.CumulativeTemporality,
+			metric.InstrumentKindObservableUpDownCounter: metricdata.CumulativeTemporality,
+		}},
+	}
+	for _, tt := range tests {
+		selector := temporalitySelector(tt.preference)
+		for kind, want := range tt.want {
+			if got := selector(kind); got != want {
+				t.Errorf("%s: temporality of %v = %v, want %v", tt.preference, kind, got, want)
+			}
+		}
+	}
+	if temporalitySelector(config.MetricTemporalityCumulative) != nil {
+		t.Error("temporalitySelector(cumulative) != nil, want the exporter's own selector")
+	}
+}
+
+func TestQueueEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint, protocol string
+		insecure           bool
+		want               string
+	}{
+		{"http://collector:4318/v1/traces", config.ProtocolHTTPProtobuf, false, "http://collector:4318/v1/traces"},
+		{"", config.ProtocolHTTPProtobuf, false, "https://localhost:4318/v1/traces"},
+		{"", config.ProtocolGRPC, false, "https://localhost:4317"},
+		{"", config.ProtocolGRPC, true, "http://localhost:4317"},
+	}
+	for _, tt := range tests {
+		if got := queueEndpoint(tt.endpoint, tracesURLPath, tt.protocol, tt.insecure); got != tt.want {
+			t.Errorf("queueEndpoint(%q, %q) = %q, want %q", tt.endpoint, tt.protocol, got, tt.want)
+		}
+	}
+}
+
+func TestWithProtocolIgnoresUnsupported(t *testing.T) {
+	cfg := &config.Config{Protocol: config.ProtocolGRPC}
+	WithProtocol("http/json")(cfg)
+	if cfg.Protocol != config.ProtocolGRPC {
+		t.Errorf("Protocol = %q, want unchanged %q", cfg.Protocol, config.ProtocolGRPC)
+	}
+	WithProtocol(config.ProtocolHTTPProtobuf)(cfg)
+	if cfg.Protocol != config.ProtocolHTTPProtobuf {
+		t.Errorf("Protocol = %q, want %q", cfg.Protocol, config.ProtocolHTTPProtobuf)
+	}
+}
+
+func TestStartExportsToOptionEndpoint(t *testing.T) {
+	defer Reset()
+
+	var traceRequests atomic.Int32
+	var authHeader atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			traceRequests.Add(1)
+			authHeader.Store(r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithEndpoint(srv.URL),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+		WithHeaders(map[string]string{"Authorization": "Basic test-token"}),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	_, span := StartSpan(context.Background(), "exported-span")
+	span.End()
+	_ = Shutdown()
+
+	if traceRequests.Load() == 0 {
+		t.Fatal("expected the trace exporter to POST to the WithEndpoint server")
+	}
+	if got, _ := authHeader.Load().(string); got != "Basic test-token" {
+		t.Errorf("Authorization header = %q, want %q", got, "Basic test-token")
+	}
+}
+
+func TestInsecureSchemelessEndpoint(t *testing.T) {
+	os.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_INSECURE")
+
+	var traceRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			traceRequests.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// A plaintext sidecar addressed as host:port, like localhost:4317.
+	a, err := New(
+		WithServiceName("test-service"),
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	_, span := a.TracerProvider().Tracer("test").Start(context.Background(), "exported-span")
+	span.End()
+	_ = a.Shutdown(context.Background())
+
+	if traceRequests.Load() == 0 {
+		t.Fatal("expected the trace exporter to POST over plaintext with OTEL_EXPORTER_OTLP_INSECURE=true")
+	}
+}
+
+func TestShutdownFlushesLogs(t *testing.T) {
+	defer Reset()
+
+	var logRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/logs" {
+			logRequests.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithEndpoint(srv.URL),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	ctx, span := StartSpan(context.Background(), "logging-span")
+	slog.New(slogagent.NewExportHandler(nil, nil)).InfoContext(ctx, "shipped")
+	span.End()
+
+	// The batch processor exports on a 1s interval; Shutdown must flush
+	// the record before that fires.
+	_ = Shutdown()
+
+	if logRequests.Load() == 0 {
+		t.Fatal("expected Shutdown to flush the log record to /v1/logs")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	defer Reset()
+
+	var traceRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			traceRequests.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if err := Flush(context.Background()); err != nil {
+		t.Errorf("Flush() before Start = %v, want nil", err)
+	}
+	if err := Start(WithServiceName("test-service"), WithEndpoint(srv.URL), WithProtocol(config.ProtocolHTTPProtobuf)); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	defer Shutdown()
+
+	_, span := StartSpan(context.Background(), "job")
+	span.End()
+	if err := Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if traceRequests.Load() == 0 {
+		t.Fatal("expected Flush to export the span")
+	}
+	if !IsInitialized() {
+		t.Error("Flush() should not shut the agent down")
+	}
+}
+
+func TestShutdownHooks(t *testing.T) {
+	defer Reset()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithExporter(config.ExporterConsole),
+		WithShutdownTimeout(time.Second),
+		WithShutdownTimeout(-1),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if got := GetConfig().ShutdownTimeout; got != time.Second {
+		t.Errorf("ShutdownTimeout = %v, want 1s", got)
+	}
+
+	var order []string
+	OnShutdown(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("hook context has no deadline")
+		}
+		order = append(order, "first")
+		return nil
+	})
+	OnShutdown(func(context.Context) error {
+		order = append(order, "second")
+		return errors.New("drain failed")
+	})
+
+	err = Shutdown()
+	if err == nil || !strings.Contains(err.Error(), "drain failed") {
+		t.Errorf("Shutdown() error = %v, want the hook error", err)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("hooks ran as %v, want first,second", order)
+	}
+	if err := ShutdownWithContext(context.Background()); err == nil {
+		t.Error("second shutdown should return the first result")
+	}
+	if len(order) != 2 {
+		t.Errorf("hooks ran %d times, want once", len(order))
+	}
+}
+
+func TestSignalHandling(t *testing.T) {
+	defer Reset()
+
+	exitCodes := make(chan int, 1)
+	exit = func(code int) { exitCodes <- code }
+	defer func() { exit = os.Exit }()
+
+	var traceRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			traceRequests.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithEndpoint(srv.URL),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+		WithSignalHandling(),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	var hookRan atomic.Bool
+	OnShutdown(func(context.Context) error {
+		hookRan.Store(true)
+		return nil
+	})
+
+	_, span := StartSpan(context.Background(), "last-span")
+	span.End()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess() failed: %v", err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal() failed: %v", err)
+	}
+	select {
+	case code := <-exitCodes:
+		if code != 128+int(syscall.SIGTERM) {
+			t.Errorf("exit code = %d, want %d", code, 128+int(syscall.SIGTERM))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM did not shut the agent down")
+	}
+	if !hookRan.Load() {
+		t.Error("shutdown hook did not run")
+	}
+	if traceRequests.Load() == 0 {
+		t.Error("expected the span to be flushed before exit")
+	}
+}
+
+func TestSpanLimits(t *testing.T) {
+	defer Reset()
+
+	var mu sync.Mutex
+	var traces bytes.Buffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			traces.Write(body)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithEndpoint(srv.URL),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+		WithAttributeValueLengthLimit(10),
+		WithSpanEventCountLimit(1),
+		WithBatchScheduleDelay(10*time.Millisecond),
+		WithBatchMaxQueueSize(-1),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if cfg := GetConfig(); cfg.BatchScheduleDelay != 10*time.Millisecond || cfg.BatchMaxQueueSize != config.DefaultBatchMaxQueueSize {
+		t.Errorf("batch = %v/%d, want 10ms and the default queue size", cfg.BatchScheduleDelay, cfg.BatchMaxQueueSize)
+	}
+
+	_, span := StartSpan(context.Background(), "query")
+	span.SetAttributes(semconv.DBStatement("SELECT * FROM orders WHERE id = 1"))
+	span.AddEvent("first-event")
+	span.AddEvent("second-event")
+	span.End()
+	_ = Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	body := traces.Bytes()
+	if !bytes.Contains(body, []byte("SELECT * F")) || bytes.Contains(body, []byte("SELECT * FROM")) {
+		t.Error("db.statement was not truncated to 10 bytes")
+	}
+	if bytes.Contains(body, []byte("first-event")) == bytes.Contains(body, []byte("second-event")) {
+		t.Error("expected exactly one of the two events to be kept")
+	}
+}
+
+func TestStartWithConfigFilePrecedence(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	content := "service_name: from-file\nservice_version: file-version\nenvironment: file-env\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	os.Setenv("OTEL_SERVICE_VERSION", "env-version")
+	defer os.Unsetenv("OTEL_SERVICE_VERSION")
+
+	// WithConfigFile comes last, yet the file stays below env and options.
+	err := Start(
+		WithServiceName("from-option"),
+		WithConfigFile(path),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	cfg := GetConfig()
+	if cfg.ConfigFile != path {
+		t.Errorf("ConfigFile = %q, want %q", cfg.ConfigFile, path)
+	}
+	if cfg.ServiceName != "from-option" {
+		t.Errorf("ServiceName = %q, want from-option (options override file)", cfg.ServiceName)
+	}
+	if cfg.ServiceVersion != "env-version" {
+		t.Errorf("ServiceVersion = %q, want env-version (env overrides file)", cfg.ServiceVersion)
+	}
+	if cfg.Environment != "file-env" {
+		t.Errorf("Environment = %q, want file-env", cfg.Environment)
+	}
+}
+
+func TestStartWithInvalidConfigFile(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	if err := os.WriteFile(path, []byte("unknown_key: true\n"), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	if err := Start(WithConfigFile(path)); err == nil {
+		t.Fatal("expected Start() to fail for an invalid config file")
+	}
+	if IsInitialized() {
+		t.Error("agent must not be initialized after a config error")
+	}
+}
+
+func TestReload(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config file: %v", err)
+		}
+	}
+	write("service_name: before\nsampling:\n  sample_rate: 0.1\nexclusions:\n  paths: [/health]\n")
+
+	if err := Start(WithConfigFile(path)); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	before := GetConfig()
+
+	write("service_name: after\nsampling:\n  sample_rate: 1\nexclusions:\n  paths: [/ready]\nbody_capture:\n  enabled: true\n")
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	cfg := GetConfig()
+	if cfg == before {
+		t.Fatal("Reload() must replace the config, not modify it in place")
+	}
+	if cfg.SampleRate != 1 || !cfg.BodyCaptureEnabled {
+		t.Errorf("SampleRate/BodyCaptureEnabled = %v/%v, want 1/true", cfg.SampleRate, cfg.BodyCaptureEnabled)
+	}
+	if cfg.ServiceName != "before" {
+		t.Errorf("ServiceName = %q, want before (requires restart)", cfg.ServiceName)
+	}
+	if before.SampleRate != 0.1 || before.BodyCaptureEnabled {
+		t.Errorf("previous config was modified: SampleRate=%v BodyCaptureEnabled=%v", before.SampleRate, before.BodyCaptureEnabled)
+	}
+
+	rm := GetRouteMatcher()
+	if rm.ShouldExclude("/health") || !rm.ShouldExclude("/ready") {
+		t.Error("route matcher should exclude /ready and no longer /health after Reload()")
+	}
+
+	if got, want := globalAgent.Load().sampler.Description(), "ParentBased{root:AlwaysOnSampler"; !strings.HasPrefix(got, want) {
+		t.Errorf("sampler = %q, want prefix %q", got, want)
+	}
+}
+
+func TestReloadKeepsOptions(t *testing.T) {
+	defer Reset()
+
+	os.Setenv("LAST9_TRACE_SAMPLE_RATE", "0.5")
+	defer os.Unsetenv("LAST9_TRACE_SAMPLE_RATE")
+
+	if err := Start(WithServiceName("test-service")); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if err := Reload(WithBodyCapture(true)); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	// A reload from SIGHUP or the config watcher re-applies the options of
+	// the latest Reload.
+	os.Setenv("LAST9_TRACE_SAMPLE_RATE", "0.25")
+	a := globalAgent.Load()
+	a.triggerReload("test")
+
+	cfg := GetConfig()
+	if !cfg.BodyCaptureEnabled {
+		t.Error("BodyCaptureEnabled = false, want true (kept from earlier Reload)")
+	}
+	if cfg.SampleRate != 0.25 {
+		t.Errorf("SampleRate = %v, want 0.25 (env re-read on Reload)", cfg.SampleRate)
+	}
+
+	// Each Reload replaces the options of the previous one instead of
+	// accumulating them.
+	for i := 0; i < 3; i++ {
+		if err := Reload(WithBodyCapture(true)); err != nil {
+			t.Fatalf("Reload() failed: %v", err)
+		}
+	}
+	if len(a.reloadOpts) != 1 || len(a.startOpts) != 1 {
+		t.Errorf("kept %d reload and %d start options, want 1 and 1", len(a.reloadOpts), len(a.startOpts))
+	}
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if GetConfig().BodyCaptureEnabled {
+		t.Error("BodyCaptureEnabled = true after Reload(), want the Start options only")
+	}
+}
+
+func TestReloadErrors(t *testing.T) {
+	defer Reset()
+
+	if err := Reload(); err == nil {
+		t.Error("expected Reload() to fail before Start()")
+	}
+
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	if err := os.WriteFile(path, []byte("body_capture:\n  enabled: true\n"), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	if err := Start(WithConfigFile(path)); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("body_capture: [\n"), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	if err := Reload(); err == nil {
+		t.Error("expected Reload() to fail for an invalid config file")
+	}
+	if !GetConfig().BodyCaptureEnabled {
+		t.Error("a failed Reload() must keep the previous settings")
+	}
+}
+
+func TestConfigWatchReloadsOnChange(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	if err := os.WriteFile(path, []byte("body_capture:\n  enabled: false\n"), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	if err := Start(WithConfigFile(path), WithConfigWatch(10*time.Millisecond)); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("body_capture:\n  enabled: true\n"), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !GetConfig().BodyCaptureEnabled {
+		if time.Now().After(deadline) {
+			t.Fatal("config file change was not picked up by the watcher")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadOnSIGHUP(t *testing.T) {
+	defer Reset()
+
+	if err := Start(WithServiceName("test-service"), WithReloadOnSIGHUP()); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	os.Setenv("LAST9_BODY_CAPTURE_ENABLED", "true")
+	defer os.Unsetenv("LAST9_BODY_CAPTURE_ENABLED")
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess() failed: %v", err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("Signal() failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !GetConfig().BodyCaptureEnabled {
+		if time.Now().After(deadline) {
+			t.Fatal("SIGHUP did not trigger a reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStartWithTailSampling(t *testing.T) {
+	defer Reset()
+
+	if _, ok := TailSamplingStats(); ok {
+		t.Error("TailSamplingStats() ok = true before Start()")
+	}
+
+	os.Setenv("LAST9_TAIL_SAMPLING_ENABLED", "true")
+	os.Setenv("LAST9_TAIL_SAMPLING_RATIO", "0")
+	defer os.Unsetenv("LAST9_TAIL_SAMPLING_ENABLED")
+	defer os.Unsetenv("LAST9_TAIL_SAMPLING_RATIO")
+
+	if err := Start(WithServiceName("test-service")); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	_, span := globalAgent.Load().tracerProvider.Tracer("test").Start(context.Background(), "ok")
+	span.End()
+
+	stats, ok := TailSamplingStats()
+	if !ok {
+		t.Fatal("TailSamplingStats() ok = false with LAST9_TAIL_SAMPLING_ENABLED=true")
+	}
+	if stats.DroppedTraces != 1 {
+		t.Errorf("DroppedTraces = %d, want 1", stats.DroppedTraces)
+	}
+}
+
+func TestStartWithSamplingRules(t *testing.T) {
+	defer Reset()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithSamplingRules(config.SamplingRule{Route: "/api/v1/poll", Ratio: 0.01}),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	if got := GetConfig().SamplingRules; len(got) != 1 || got[0].Route != "/api/v1/poll" {
+		t.Errorf("SamplingRules = %+v, want the /api/v1/poll rule", got)
+	}
+	if got := globalAgent.Load().sampler.Description(); !strings.HasPrefix(got, "Last9Rules{rules:1,") {
+		t.Errorf("sampler = %q, want the rules sampler", got)
+	}
+}
+
+func TestStartReportsSelfTelemetry(t *testing.T) {
+	defer Reset()
+
+	var metricsBody atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/metrics" {
+			body, _ := io.ReadAll(r.Body)
+			metricsBody.Store(body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithEndpoint(srv.URL),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	tel := globalAgent.Load().telemetry
+
+	_, span := StartSpan(context.Background(), "counted-span")
+	span.End()
+	_ = Shutdown()
+
+	if s := tel.Stats(); s.SpansStarted != 1 || s.SpansEnded != 1 || s.SpansExported != 1 || s.SpansQueued != 0 {
+		t.Errorf("Stats() = %+v, want one span started, ended and exported", s)
+	}
+	body, _ := metricsBody.Load().([]byte)
+	for _, name := range []string{"last9.agent.spans.exported", "last9.agent.export.duration", "telemetry.distro.name"} {
+		if !bytes.Contains(body, []byte(name)) {
+			t.Errorf("metrics export does not contain %s", name)
+		}
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	defer Reset()
+
+	// record returns a server storing request bodies by path.
+	record := func(bodies *sync.Map) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			prev, _ := bodies.LoadOrStore(r.URL.Path, []byte(nil))
+			bodies.Store(r.URL.Path, append(prev.([]byte), body...))
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	var primaryBodies, destBodies sync.Map
+	primary := record(&primaryBodies)
+	defer primary.Close()
+	dest := record(&destBodies)
+	defer dest.Close()
+
+	err := Start(
+		WithServiceName("test-service"),
+		WithEndpoint(primary.URL),
+		WithProtocol(config.ProtocolHTTPProtobuf),
+		WithDestination(config.Destination{
+			Name:     "errors-only",
+			Endpoint: dest.URL,
+			Signals:  []string{"traces"},
+			Filter:   config.DestinationFilter{SpanStatus: []string{"error"}},
+		}),
+		WithDestination(config.Destination{Name: "invalid"}),
+	)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if got := len(GetConfig().Destinations); got != 1 {
+		t.Errorf("Destinations = %d, want the invalid one ignored", got)
+	}
+
+	_, okSpan := StartSpan(context.Background(), "ok-span")
+	okSpan.End()
+	_, failedSpan := StartSpan(context.Background(), "failed-span")
+	failedSpan.SetStatus(codes.Error, "boom")
+	failedSpan.End()
+	_ = Shutdown()
+
+	load := func(bodies *sync.Map, path string) []byte {
+		b, _ := bodies.Load(path)
+		body, _ := b.([]byte)
+		return body
+	}
+	if body := load(&primaryBodies, "/v1/traces"); !bytes.Contains(body, []byte("ok-span")) || !bytes.Contains(body, []byte("failed-span")) {
+		t.Error("primary endpoint did not receive both spans")
+	}
+	body := load(&destBodies, "/v1/traces")
+	if !bytes.Contains(body, []byte("failed-span")) || bytes.Contains(body, []byte("ok-span")) {
+		t.Error("destination should receive only the failed span")
+	}
+	if _, ok := destBodies.Load("/v1/metrics"); ok {
+		t.Error("traces-only destination received metrics")
+	}
+}
+
+func TestNew(t *testing.T) {
+	defer Reset()
+
+	// record returns a server storing the traces request bodies.
+	record := func(bodies *bytes.Buffer, mu *sync.Mutex) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.URL.Path == "/v1/traces" {
+				mu.Lock()
+				bodies.Write(body)
+				mu.Unlock()
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	var mu sync.Mutex
+	var ordersBodies, billingBodies bytes.Buffer
+	ordersSrv := record(&ordersBodies, &mu)
+	defer ordersSrv.Close()
+	billingSrv := record(&billingBodies, &mu)
+	defer billingSrv.Close()
+
+	globalTP := otel.GetTracerProvider()
+	orders, err := New(WithServiceName("orders"), WithEndpoint(ordersSrv.URL), WithProtocol(config.ProtocolHTTPProtobuf))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	billing, err := New(WithServiceName("billing"), WithEndpoint(billingSrv.URL), WithProtocol(config.ProtocolHTTPProtobuf))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if IsInitialized() || GetConfig() != nil {
+		t.Error("New() initialized the global agent")
+	}
+	if otel.GetTracerProvider() != globalTP {
+		t.Error("New() replaced the global tracer provider")
+	}
+	if orders.Config().ServiceName != "orders" || billing.Config().ServiceName != "billing" {
+		t.Errorf("service names = %q, %q", orders.Config().ServiceName, billing.Config().ServiceName)
+	}
+	if orders.RouteMatcher() == nil || orders.Propagator() == nil {
+		t.Error("RouteMatcher() or Propagator() is nil")
+	}
+
+	_, span := orders.StartSpan(context.Background(), "orders-span")
+	span.End()
+	_, span = billing.StartSpan(context.Background(), "billing-span")
+	span.End()
+
+	if err := orders.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() failed: %v", err)
+	}
+	if err := orders.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown() failed: %v", err)
+	}
+	if err := billing.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if b := ordersBodies.Bytes(); !bytes.Contains(b, []byte("orders-span")) || bytes.Contains(b, []byte("billing-span")) {
+		t.Error("orders endpoint should receive only the orders span")
+	}
+	if b := billingBodies.Bytes(); !bytes.Contains(b, []byte("billing-span")) || bytes.Contains(b, []byte("orders-span")) {
+		t.Error("billing endpoint should receive only the billing span")
+	}
+}
+
+func TestNilAgentUsesGlobals(t *testing.T) {
+	defer Reset()
+
+	var a *Agent
+	if a.Config() != nil {
+		t.Error("Config() on a nil agent before Start should be nil")
+	}
+	if err := Start(WithServiceName("global-service")); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if a.Config() != GetConfig() || a.RouteMatcher() != GetRouteMatcher() {
+		t.Error("nil agent should use the global agent's settings")
+	}
+	if a.TracerProvider() != otel.GetTracerProvider() || a.MeterProvider() != otel.GetMeterProvider() {
+		t.Error("nil agent should use the global providers")
+	}
+}
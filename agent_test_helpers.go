@@ -12,8 +12,17 @@ import "sync"
 // previous test have exited.
 //
 // Note: This does NOT call Shutdown(). If you need to flush telemetry data
-// before resetting, call Shutdown() first.
+// before resetting, call Shutdown() first. It does stop the SIGHUP handler,
+// config file watcher and SIGTERM/SIGINT handler, if any.
 func Reset() {
+	if a := globalAgent.Load(); a != nil {
+		if a.stopReload != nil {
+			a.stopReload()
+		}
+		if a.stopSignals != nil {
+			a.stopSignals()
+		}
+	}
 	globalAgent.Store(nil)
 	once = sync.Once{}
 }
@@ -0,0 +1,285 @@
+// Command last9-replay uploads telemetry written by the agent's file
+// exporter (LAST9_EXPORTER=file) to an OTLP endpoint, for deployments that
+// cannot reach Last9 directly.
+//
+// Usage:
+//
+//	last9-replay [flags] <directory or file>...
+//
+// Directories are searched for the exporter's .jsonl and .jsonl.gz files,
+// which are uploaded in the order they were written. The endpoint, protocol
+// and headers are read from the same environment variables and
+// LAST9_CONFIG_FILE as the agent (OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_PROTOCOL, OTEL_EXPORTER_OTLP_HEADERS and the
+// per-signal endpoints), and can be overridden with flags:
+//
+//	-endpoint  base OTLP endpoint
+//	-protocol  grpc or http/protobuf
+//	-headers   key=value pairs separated by commas
+//	-delete    remove each file once all of it is uploaded
+//	-retries   attempts per request while the endpoint is unavailable (default 5)
+//
+// It exits with status 1 if any request could not be delivered.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/last9/go-agent/config"
+	"github.com/last9/go-agent/internal/otlpclient"
+	"github.com/last9/go-agent/internal/otlpconv"
+	"github.com/last9/go-agent/internal/otlpfile"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// minBackoff is the first delay between retries; it doubles after each
+// attempt. A variable so that tests can shorten it.
+var minBackoff = time.Second
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("last9-replay", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	endpoint := fs.String("endpoint", "", "base OTLP endpoint (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	protocol := fs.String("protocol", "", "grpc or http/protobuf (default: OTEL_EXPORTER_OTLP_PROTOCOL)")
+	headers := fs.String("headers", "", "key=value,... (default: OTEL_EXPORTER_OTLP_HEADERS)")
+	del := fs.Bool("delete", false, "remove each file once all of it is uploaded")
+	retries := fs.Int("retries", 5, "attempts per request while the endpoint is unavailable")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: last9-replay [flags] <directory or file>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg := config.Load()
+	if *endpoint != "" {
+		cfg.Endpoint = *endpoint
+		cfg.TracesEndpoint, cfg.MetricsEndpoint = "", ""
+	}
+	if *protocol != "" {
+		if *protocol != config.ProtocolGRPC && *protocol != config.ProtocolHTTPProtobuf {
+			fmt.Fprintf(stderr, "last9-replay: unsupported protocol %q (want grpc or http/protobuf)\n", *protocol)
+			return 2
+		}
+		cfg.Protocol = *protocol
+	}
+	for _, pair := range strings.Split(*headers, ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			cfg.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+
+	files, err := expand(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "last9-replay: %v\n", err)
+		return 1
+	}
+
+	r := &replayer{cfg: cfg, retries: *retries, clients: make(map[otlpclient.Signal]*otlpclient.Client)}
+	defer r.close()
+	status := 0
+	for _, path := range files {
+		sent, err := r.replayFile(ctx, path)
+		fmt.Fprintf(stderr, "%s: uploaded %d requests\n", path, sent)
+		if err != nil {
+			fmt.Fprintf(stderr, "last9-replay: %v\n", err)
+			status = 1
+			if errors.Is(err, otlpclient.ErrPermanent) {
+				continue // the endpoint is up; try the remaining files
+			}
+			break
+		}
+		if *del {
+			if err := os.Remove(path); err != nil {
+				fmt.Fprintf(stderr, "last9-replay: %v\n", err)
+				status = 1
+			}
+		}
+	}
+	return status
+}
+
+// expand replaces directories in paths with the exporter files they contain.
+func expand(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		found, err := otlpfile.Files(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+type replayer struct {
+	cfg     *config.Config
+	retries int
+	clients map[otlpclient.Signal]*otlpclient.Client
+}
+
+// replayFile uploads every request in the file at path. Malformed lines and
+// requests the endpoint rejects are skipped and reported in the returned
+// error, which wraps otlpclient.ErrPermanent; any other error stops the
+// upload.
+func (r *replayer) replayFile(ctx context.Context, path string) (int, error) {
+	sent, rejected := 0, 0
+	var lastRejection error
+	err := otlpfile.ReadLines(path, func(line []byte) error {
+		signal, payload, err := decode(line)
+		if err != nil {
+			rejected++
+			lastRejection = fmt.Errorf("%w: %v", otlpclient.ErrPermanent, err)
+			return nil
+		}
+		client, err := r.client(signal)
+		if err != nil {
+			return err
+		}
+		if err := r.send(ctx, client, payload); err != nil {
+			if errors.Is(err, otlpclient.ErrPermanent) {
+				rejected++
+				lastRejection = err
+				return nil
+			}
+			return err
+		}
+		sent++
+		return nil
+	})
+	if err == nil && rejected > 0 {
+		err = fmt.Errorf("%s: %d requests rejected, last: %w", path, rejected, lastRejection)
+	}
+	return sent, err
+}
+
+// decode converts one OTLP/JSON line into a protobuf export request.
+func decode(line []byte) (otlpclient.Signal, []byte, error) {
+	var signal otlpclient.Signal
+	var req proto.Message
+	switch {
+	case bytes.Contains(line, []byte(`"resourceSpans"`)):
+		signal, req = otlpclient.Traces, &coltracepb.ExportTraceServiceRequest{}
+	case bytes.Contains(line, []byte(`"resourceMetrics"`)):
+		signal, req = otlpclient.Metrics, &colmetricpb.ExportMetricsServiceRequest{}
+	default:
+		return "", nil, errors.New("not an OTLP/JSON trace or metric export request")
+	}
+	if err := otlpconv.UnmarshalJSON(line, req); err != nil {
+		return "", nil, err
+	}
+	payload, err := proto.Marshal(req)
+	return signal, payload, err
+}
+
+// send delivers payload, retrying with exponential backoff while the
+// endpoint is unavailable.
+func (r *replayer) send(ctx context.Context, client *otlpclient.Client, payload []byte) error {
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		err := client.Send(ctx, payload)
+		if err == nil || errors.Is(err, otlpclient.ErrPermanent) || attempt >= r.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// client returns the client for signal, creating it on first use.
+func (r *replayer) client(signal otlpclient.Signal) (*otlpclient.Client, error) {
+	if c, ok := r.clients[signal]; ok {
+		return c, nil
+	}
+	protocol, endpoint, err := destination(r.cfg, signal)
+	if err != nil {
+		return nil, err
+	}
+	c, err := otlpclient.New(signal, protocol, endpoint, r.cfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+	r.clients[signal] = c
+	return c, nil
+}
+
+func (r *replayer) close() {
+	for _, c := range r.clients {
+		_ = c.Close()
+	}
+}
+
+// destination resolves the protocol and URL for signal the way the agent's
+// exporters do: per-signal endpoints are used as-is, the base endpoint gets
+// the signal path for http/protobuf, and traces default to http/protobuf
+// while metrics default to grpc.
+func destination(cfg *config.Config, signal otlpclient.Signal) (protocol, endpoint string, err error) {
+	protocol, endpoint = cfg.Protocol, cfg.TracesEndpoint
+	insecure := cfg.TracesInsecure
+	if signal == otlpclient.Metrics {
+		endpoint, insecure = cfg.MetricsEndpoint, cfg.MetricsInsecure
+		if protocol == "" {
+			protocol = config.ProtocolGRPC
+		}
+	}
+	if protocol == "" {
+		protocol = config.ProtocolHTTPProtobuf
+	}
+	if endpoint != "" {
+		return protocol, withScheme(endpoint, insecure), nil
+	}
+	if cfg.Endpoint == "" {
+		return "", "", fmt.Errorf("no endpoint for %s: set OTEL_EXPORTER_OTLP_ENDPOINT or -endpoint", signal)
+	}
+	endpoint = withScheme(cfg.Endpoint, insecure)
+	if protocol == config.ProtocolHTTPProtobuf {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/" + string(signal)
+	}
+	return protocol, endpoint, nil
+}
+
+// withScheme prefixes endpoint with https://, or http:// when insecure
+// (OTEL_EXPORTER_OTLP_INSECURE), if it has no scheme.
+func withScheme(endpoint string, insecure bool) string {
+	if strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	if insecure {
+		return "http://" + endpoint
+	}
+	return "https://" + endpoint
+}
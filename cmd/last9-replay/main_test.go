@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/last9/go-agent/config"
+	"github.com/last9/go-agent/internal/otlpclient"
+	"github.com/last9/go-agent/internal/otlpfile"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// writeTelemetry records a span and a metric with the file exporters.
+func writeTelemetry(t *testing.T, dir string) {
+	t.Helper()
+	te, err := otlpfile.NewTraceExporter(otlpfile.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(te))
+	_, span := tp.Tracer("test").Start(context.Background(), "offline-span")
+	span.End()
+	_ = tp.Shutdown(context.Background())
+
+	me, err := otlpfile.NewMetricExporter(otlpfile.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(me)))
+	counter, _ := mp.Meter("test").Int64Counter("offline.requests")
+	counter.Add(context.Background(), 1)
+	_ = mp.Shutdown(context.Background())
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeTelemetry(t, dir)
+
+	var stderr bytes.Buffer
+	args := []string{"-endpoint", srv.URL, "-protocol", "http/protobuf", "-headers", "Authorization=Basic x", "-delete", dir}
+	if code := run(context.Background(), args, &stderr); code != 0 {
+		t.Fatalf("run() = %d, output:\n%s", code, stderr.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !bytes.Contains(received["/v1/traces"], []byte("offline-span")) {
+		t.Error("collector did not receive the span")
+	}
+	if !bytes.Contains(received["/v1/metrics"], []byte("offline.requests")) {
+		t.Error("collector did not receive the metric")
+	}
+	if files, _ := otlpfile.Files(dir); len(files) != 0 {
+		t.Errorf("files left after -delete: %v", files)
+	}
+}
+
+func TestRunKeepsFilesWhenEndpointDown(t *testing.T) {
+	old := minBackoff
+	minBackoff = time.Millisecond
+	t.Cleanup(func() { minBackoff = old })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeTelemetry(t, dir)
+
+	var stderr bytes.Buffer
+	args := []string{"-endpoint", srv.URL, "-protocol", "http/protobuf", "-retries", "2", "-delete", dir}
+	if code := run(context.Background(), args, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1; output:\n%s", code, stderr.String())
+	}
+	if files, _ := otlpfile.Files(dir); len(files) != 2 {
+		t.Errorf("files = %v, want both kept", files)
+	}
+}
+
+func TestRunSkipsMalformedLines(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-endpoint", srv.URL, path}, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "1 requests rejected") {
+		t.Errorf("output = %q, want the rejected line reported", stderr.String())
+	}
+}
+
+func TestDestination(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.Config
+		signal       otlpclient.Signal
+		wantProtocol string
+		wantEndpoint string
+	}{
+		{"traces default http", config.Config{Endpoint: "otlp.last9.io"}, otlpclient.Traces, "http/protobuf", "https://otlp.last9.io/v1/traces"},
+		{"metrics default grpc", config.Config{Endpoint: "otlp.last9.io"}, otlpclient.Metrics, "grpc", "https://otlp.last9.io"},
+		{"metrics over http", config.Config{Endpoint: "http://c:4318/", Protocol: "http/protobuf"}, otlpclient.Metrics, "http/protobuf", "http://c:4318/v1/metrics"},
+		{"per-signal endpoint", config.Config{Endpoint: "http://c:4318", TracesEndpoint: "http://t:4318/custom"}, otlpclient.Traces, "http/protobuf", "http://t:4318/custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protocol, endpoint, err := destination(&tt.cfg, tt.signal)
+			if err != nil || protocol != tt.wantProtocol || endpoint != tt.wantEndpoint {
+				t.Errorf("destination() = %q, %q, %v, want %q, %q", protocol, endpoint, err, tt.wantProtocol, tt.wantEndpoint)
+			}
+		})
+	}
+
+	if _, _, err := destination(&config.Config{}, otlpclient.Traces); err == nil {
+		t.Error("destination() without an endpoint error = nil")
+	}
+}
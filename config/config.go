@@ -1,12 +1,15 @@
 // Package config handles configuration loading from environment variables
+// and an optional YAML or JSON config file
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/otel/attribute"
 )
 
@@ -20,6 +23,96 @@ type Config struct {
 	Sampler            string
 	ResourceAttributes []attribute.KeyValue
 
+	// TracesEndpoint overrides Endpoint for traces (OTEL_EXPORTER_OTLP_TRACES_ENDPOINT).
+	// Used as-is: no /v1/traces path is appended.
+	TracesEndpoint string
+
+	// MetricsEndpoint overrides Endpoint for metrics (OTEL_EXPORTER_OTLP_METRICS_ENDPOINT).
+	// Used as-is: no /v1/metrics path is appended.
+	MetricsEndpoint string
+
+	// LogsEndpoint overrides Endpoint for logs (OTEL_EXPORTER_OTLP_LOGS_ENDPOINT).
+	// Used as-is: no /v1/logs path is appended.
+	LogsEndpoint string
+
+	// Insecure sends to endpoints without a scheme, such as "collector:4317",
+	// over plaintext instead of TLS (OTEL_EXPORTER_OTLP_INSECURE). Endpoints
+	// with an http:// or https:// scheme are unaffected. Default: false.
+	Insecure bool
+
+	// TracesInsecure, MetricsInsecure and LogsInsecure override Insecure per
+	// signal (OTEL_EXPORTER_OTLP_TRACES_INSECURE and so on). Default: Insecure.
+	TracesInsecure  bool
+	MetricsInsecure bool
+	LogsInsecure    bool
+
+	// Protocol is the OTLP transport used by all exporters (OTEL_EXPORTER_OTLP_PROTOCOL):
+	// "grpc" or "http/protobuf". Empty keeps the historical defaults of
+	// http/protobuf for traces and logs and grpc for metrics.
+	Protocol string
+
+	// Exporter selects where telemetry goes (LAST9_EXPORTER): "otlp",
+	// "console" to print spans and metrics to stderr for local development,
+	// or "file" to write them as OTLP/JSON lines under FileExporterPath.
+	// Default: "" — otlp, unless no endpoint is set in a development
+	// environment; see ResolvedExporter.
+	Exporter string
+
+	// FileExporterPath is the directory the file exporter writes to
+	// (LAST9_FILE_EXPORTER_PATH). Default: "last9-telemetry".
+	FileExporterPath string
+
+	// FileExporterMaxBytes is the size at which a file is gzipped and a new
+	// one started (LAST9_FILE_EXPORTER_MAX_BYTES). Default: 104857600 (100 MiB).
+	FileExporterMaxBytes int64
+
+	// FileExporterMaxFiles is the number of gzipped files kept per signal;
+	// the oldest are removed first (LAST9_FILE_EXPORTER_MAX_FILES). Default: 10.
+	FileExporterMaxFiles int64
+
+	// ResourceDetectors names the cloud metadata detectors that add cloud.*,
+	// host.* and platform attributes to the resource
+	// (LAST9_RESOURCE_DETECTORS): ec2, ecs, eks, gce, azure, or all.
+	// Default: none — detectors make network requests at startup.
+	ResourceDetectors []string
+
+	// Propagators are the trace context formats read from incoming requests
+	// and written to outgoing ones (OTEL_PROPAGATORS): tracecontext, baggage,
+	// b3, b3multi, jaeger, xray, ottrace, or none.
+	// Default: tracecontext, baggage.
+	Propagators []string
+
+	// Destinations are additional OTLP endpoints that receive a copy of
+	// traces and metrics, each with its own batching and optional filter.
+	// Set in the config file or with agent.WithDestination. Default: none.
+	Destinations []Destination
+
+	// MetricViews drop, rename, filter the attributes of or re-bucket
+	// matching instruments. Set in the config file or with
+	// agent.WithMetricViews. Default: none.
+	MetricViews []MetricView
+
+	// ConfigFile is the YAML or JSON file this configuration was loaded from
+	// (LAST9_CONFIG_FILE), or empty when only environment variables were used.
+	ConfigFile string
+
+	// StrictConfig makes agent.Start and agent.New fail when Validate
+	// reports an error instead of starting with defaults in place of the
+	// invalid settings (LAST9_STRICT_CONFIG). Default: false.
+	StrictConfig bool
+
+	// LogLevel is the lowest level of the agent's own messages that are
+	// logged (LAST9_LOG_LEVEL): debug, info, warn or error. debug also logs
+	// every export batch and sampling decision. Default: info.
+	LogLevel string
+
+	// Logger receives the agent's own messages. Set with agent.WithLogger.
+	// Default: nil, which writes them with the standard log package.
+	Logger *slog.Logger
+
+	// issues are the problems found while loading, reported by Validate.
+	issues []Issue
+
 	// ExcludedPaths is a list of exact URL paths to exclude from tracing (from LAST9_EXCLUDED_PATHS).
 	// Default: /health,/healthz,/metrics,/ready,/live,/ping
 	// Set LAST9_EXCLUDED_PATHS="" to disable defaults.
@@ -51,69 +144,562 @@ type Config struct {
 	// Default: false.
 	BodyCaptureOnErrorOnly bool
 
+	// ReloadOnSIGHUP reloads the config file and environment variables when the
+	// process receives SIGHUP (LAST9_RELOAD_ON_SIGHUP). Default: false.
+	ReloadOnSIGHUP bool
+
+	// ConfigWatchInterval is how often ConfigFile is checked for changes, which
+	// trigger a reload (LAST9_CONFIG_WATCH_INTERVAL, e.g. "30s").
+	// Default: 0 — the file is not watched.
+	ConfigWatchInterval time.Duration
+
+	// ShutdownTimeout bounds how long Shutdown waits for pending spans,
+	// metrics and log records to be exported (LAST9_SHUTDOWN_TIMEOUT).
+	// Default: 5s.
+	ShutdownTimeout time.Duration
+
+	// SignalHandling flushes and shuts the agent down when the process
+	// receives SIGTERM or SIGINT, then exits (LAST9_SIGNAL_HANDLING).
+	// Default: false.
+	SignalHandling bool
+
+	// TailSamplingEnabled buffers finished spans per trace and decides which
+	// traces to export once they complete (LAST9_TAIL_SAMPLING_ENABLED).
+	// Default: false.
+	TailSamplingEnabled bool
+
+	// TailSamplingRatio is the fraction of traces kept that have neither an
+	// error nor a slow root span (LAST9_TAIL_SAMPLING_RATIO). Default: 0.1.
+	TailSamplingRatio float64
+
+	// TailSamplingLatencyThreshold keeps every trace whose root span takes at
+	// least this long (LAST9_TAIL_SAMPLING_LATENCY_THRESHOLD). Default: 1s.
+	TailSamplingLatencyThreshold time.Duration
+
+	// TailSamplingDecisionWait is how long spans of a trace are buffered
+	// waiting for its root span to end (LAST9_TAIL_SAMPLING_DECISION_WAIT).
+	// Default: 10s.
+	TailSamplingDecisionWait time.Duration
+
+	// TailSamplingMaxSpans bounds the number of buffered spans; the oldest
+	// traces are decided early when it is reached (LAST9_TAIL_SAMPLING_MAX_SPANS).
+	// Default: 100000.
+	TailSamplingMaxSpans int64
+
+	// SamplingRules pick a sampling ratio per route, method, span name, span
+	// kind or RPC service for new traces; the first matching rule wins and
+	// unmatched spans use the sampler above (LAST9_SAMPLING_RULES).
+	// Default: none.
+	SamplingRules []SamplingRule
+
 	SampleRate float64
 	// SamplerRatio is the sampling ratio for traceidratio samplers (0.0-1.0).
 	// Only used when Sampler is "traceidratio" or "parentbased_traceidratio".
-	// Set via WithSamplingRate() option. Zero value means use OTEL_TRACES_SAMPLER_ARG env var.
+	// Set via OTEL_TRACES_SAMPLER_ARG or WithSamplingRate() option.
+	// Zero value means unset (default: 1.0) unless SamplerRatioSet is true.
 	SamplerRatio float64
+
+	// SamplerRatioSet marks SamplerRatio as configured, so that a ratio of 0
+	// samples nothing instead of meaning unset.
+	SamplerRatioSet bool
+
+	// SamplerRateLimit is the number of new traces per second kept by the
+	// "ratelimited" sampler (OTEL_TRACES_SAMPLER_ARG). Zero value means unset
+	// (default: 100).
+	SamplerRateLimit float64
+
+	// SamplerRateLimitPerRoute gives each HTTP route its own
+	// SamplerRateLimit budget (LAST9_SAMPLING_RATE_LIMIT_PER_ROUTE). Default: false.
+	SamplerRateLimitPerRoute bool
+
+	// BatchScheduleDelay is the longest a finished span waits in the batch
+	// processor before it is exported (OTEL_BSP_SCHEDULE_DELAY, in
+	// milliseconds). Default: 5s.
+	BatchScheduleDelay time.Duration
+
+	// BatchMaxQueueSize is the number of finished spans buffered for export;
+	// spans beyond it are dropped (OTEL_BSP_MAX_QUEUE_SIZE). Default: 2048.
+	BatchMaxQueueSize int64
+
+	// BatchMaxExportBatchSize is the largest number of spans sent in one
+	// export request (OTEL_BSP_MAX_EXPORT_BATCH_SIZE). At most
+	// BatchMaxQueueSize. Default: 512.
+	BatchMaxExportBatchSize int64
+
+	// SpanAttributeCountLimit is the maximum number of attributes kept per
+	// span (OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT). Default: 128.
+	SpanAttributeCountLimit int64
+
+	// AttributeValueLengthLimit truncates string attribute values, such as
+	// db.statement and captured bodies, to this many bytes
+	// (OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT). Default: 0 — no limit.
+	AttributeValueLengthLimit int64
+
+	// SpanEventCountLimit is the maximum number of events kept per span
+	// (OTEL_SPAN_EVENT_COUNT_LIMIT). Default: 128.
+	SpanEventCountLimit int64
+
+	// MetricExportInterval is how often metrics are collected and exported
+	// (OTEL_METRIC_EXPORT_INTERVAL, in milliseconds). Default: 60s.
+	MetricExportInterval time.Duration
+
+	// MetricTemporality is the aggregation temporality requested from every
+	// metric exporter (OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE):
+	// "cumulative", "delta" for counters and histograms, or "lowmemory" for
+	// synchronous counters and histograms only. Default: cumulative.
+	MetricTemporality string
+
+	// MetricHistogramAggregation is the aggregation used for histograms
+	// without a view (OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION):
+	// "explicit_bucket_histogram" or "base2_exponential_bucket_histogram".
+	// Default: explicit_bucket_histogram.
+	MetricHistogramAggregation string
+
+	// MetricExemplarFilter selects the measurements recorded as exemplars,
+	// which link a metric point to the trace it was recorded in
+	// (OTEL_METRICS_EXEMPLAR_FILTER): "trace_based" for measurements made
+	// with a sampled span in their context, "always_on" or "always_off".
+	// Default: trace_based.
+	MetricExemplarFilter string
+
+	// RuntimeMetricsInterval is the minimum interval between reads of Go
+	// memory statistics for runtime metrics (LAST9_RUNTIME_METRICS_INTERVAL).
+	// Default: 15s.
+	RuntimeMetricsInterval time.Duration
+
+	// PrometheusEnabled registers a Prometheus reader on the meter provider,
+	// alongside the OTLP exporter, whose handler can be mounted with
+	// agent.PrometheusHandler (LAST9_PROMETHEUS_ENABLED). Setting
+	// PrometheusAddr enables it too. Default: false.
+	PrometheusEnabled bool
+
+	// PrometheusAddr is the address the agent serves the Prometheus endpoint
+	// on, e.g. ":9464" (LAST9_PROMETHEUS_ADDR). Default: "" — not served.
+	PrometheusAddr string
+
+	// PrometheusPath is the URL path of the Prometheus endpoint, which is
+	// always excluded from tracing (LAST9_PROMETHEUS_PATH). Default: /metrics.
+	PrometheusPath string
+
+	// RedactionEnabled removes personal data and secrets from span
+	// attributes, event attributes and status descriptions before spans are
+	// exported (LAST9_REDACTION_ENABLED). Default: false.
+	RedactionEnabled bool
+
+	// RedactionDetectors names the built-in detectors to run: email,
+	// credit_card, jwt, aws_key and ip (LAST9_REDACTION_DETECTORS).
+	// Default: email,credit_card,jwt,aws_key.
+	RedactionDetectors []string
+
+	// RedactionPatterns are additional regular expressions whose matches are
+	// redacted. Set in the config file or with agent.WithRedactionPatterns.
+	// Default: none.
+	RedactionPatterns []string
+
+	// RedactionAllowKeys are attribute keys that are never redacted
+	// (LAST9_REDACTION_ALLOW_KEYS). Default: none.
+	RedactionAllowKeys []string
+
+	// RedactionDenyKeys are attribute keys whose values are always redacted
+	// in full (LAST9_REDACTION_DENY_KEYS). Default: none.
+	RedactionDenyKeys []string
+
+	// RedactionMode is what happens to sensitive data: "mask" replaces it
+	// with [REDACTED], "hash" with a truncated HMAC-SHA256 (SHA-256 without
+	// RedactionHashKey), and "drop" removes the attribute
+	// (LAST9_REDACTION_MODE). Default: mask.
+	RedactionMode string
+
+	// RedactionHashKey is the secret key of the "hash" mode's HMAC
+	// (LAST9_REDACTION_HASH_KEY). Without it hashes are unkeyed and only fit
+	// for correlation. Default: none.
+	RedactionHashKey string
+
+	// PersistentQueueDir enables the disk-backed export queue: trace and
+	// metric batches that cannot be exported are written here and replayed
+	// once the endpoint recovers (LAST9_PERSISTENT_QUEUE_DIR).
+	// Default: "" — disabled.
+	PersistentQueueDir string
+
+	// PersistentQueueMaxBytes bounds the queued data per signal; the oldest
+	// batches are dropped first (LAST9_PERSISTENT_QUEUE_MAX_BYTES).
+	// Default: 268435456 (256 MiB).
+	PersistentQueueMaxBytes int64
+
+	// PersistentQueueMaxAge is how long a queued batch is kept before it is
+	// dropped (LAST9_PERSISTENT_QUEUE_MAX_AGE). Default: 24h.
+	PersistentQueueMaxAge time.Duration
+}
+
+// DefaultPropagators is the default Config.Propagators, as a comma-separated list.
+const DefaultPropagators = "tracecontext,baggage"
+
+// Defaults for the batch span processor and span limits, matching the
+// OpenTelemetry SDK.
+const (
+	DefaultBatchScheduleDelay      = 5 * time.Second
+	DefaultBatchMaxQueueSize       = 2048
+	DefaultBatchMaxExportBatchSize = 512
+	DefaultSpanAttributeCountLimit = 128
+	DefaultSpanEventCountLimit     = 128
+)
+
+// Defaults for metric collection.
+const (
+	DefaultMetricExportInterval   = time.Minute
+	DefaultRuntimeMetricsInterval = 15 * time.Second
+)
+
+// Supported values for Config.MetricExemplarFilter.
+const (
+	ExemplarFilterTraceBased = "trace_based"
+	ExemplarFilterAlwaysOn   = "always_on"
+	ExemplarFilterAlwaysOff  = "always_off"
+)
+
+// Supported values for Config.LogLevel.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// DefaultPrometheusPath is the default Config.PrometheusPath.
+const DefaultPrometheusPath = "/metrics"
+
+// Supported values for Config.MetricTemporality.
+const (
+	MetricTemporalityCumulative = "cumulative"
+	MetricTemporalityDelta      = "delta"
+	MetricTemporalityLowMemory  = "lowmemory"
+)
+
+// Supported values for Config.MetricHistogramAggregation.
+const (
+	HistogramAggregationExplicit    = "explicit_bucket_histogram"
+	HistogramAggregationExponential = "base2_exponential_bucket_histogram"
+)
+
+// DefaultRedactionDetectors is the default Config.RedactionDetectors, as a
+// comma-separated list. IP detection is opt-in, since addresses such as
+// server.address are usually wanted.
+const DefaultRedactionDetectors = "email,credit_card,jwt,aws_key"
+
+// Supported values for Config.RedactionMode.
+const (
+	RedactionModeMask = "mask"
+	RedactionModeHash = "hash"
+	RedactionModeDrop = "drop"
+)
+
+// DefaultShutdownTimeout is the default Config.ShutdownTimeout.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// Supported values for Config.Protocol.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// Supported values for Config.Exporter.
+const (
+	ExporterOTLP    = "otlp"
+	ExporterConsole = "console"
+	ExporterFile    = "file"
+)
+
+// developmentEnvironments are the deployment.environment values in which a
+// missing endpoint selects the console exporter.
+var developmentEnvironments = map[string]bool{
+	"development": true,
+	"dev":         true,
+	"local":       true,
 }
 
-// Load reads configuration from environment variables.
+// ResolvedExporter returns the exporter the agent uses: Exporter when set,
+// otherwise console when no endpoint is configured and Environment is
+// "development", "dev" or "local", and otlp in every other case.
+func (c *Config) ResolvedExporter() string {
+	if c.Exporter != "" {
+		return c.Exporter
+	}
+	if !c.hasEndpoint() && developmentEnvironments[strings.ToLower(c.Environment)] {
+		return ExporterConsole
+	}
+	return ExporterOTLP
+}
+
+// hasEndpoint reports whether any OTLP endpoint is configured.
+func (c *Config) hasEndpoint() bool {
+	return c.Endpoint != "" || c.TracesEndpoint != "" || c.MetricsEndpoint != "" || c.LogsEndpoint != ""
+}
+
+// Load reads configuration from the file named by LAST9_CONFIG_FILE, if any,
+// and from environment variables. A config file that cannot be read or parsed
+// is logged and skipped. See LoadFile for precedence.
 //
 // Note: If OTEL_EXPORTER_OTLP_ENDPOINT is not set, the agent will start but
 // telemetry data will not be exported. This is useful for local development
 // or when using a custom exporter configuration.
 func Load() *Config {
+	cfg, err := LoadFile(os.Getenv("LAST9_CONFIG_FILE"))
+	if err != nil {
+		logging.Warnf("%v - using environment variables only", err)
+		cfg.issues = append(cfg.issues, Issue{Severity: SeverityError, Field: "LAST9_CONFIG_FILE", Message: err.Error()})
+	}
+	return cfg
+}
+
+// LoadFile reads configuration from the YAML or JSON file at path (decoded as
+// JSON when the name ends in .json) and then from environment variables.
+// Precedence is file < environment variables: any variable that is set
+// overrides the corresponding file value. Headers and resource attributes
+// are merged key by key. An empty path reads environment variables only.
+//
+// On error the returned Config is still usable and holds the environment
+// configuration without the file applied.
+func LoadFile(path string) (*Config, error) {
+	l := &loader{}
+	fc, fileErr := l.readFile(path)
+
 	cfg := &Config{
-		ServiceName:    getEnvOrDefault("OTEL_SERVICE_NAME", "unknown-service"),
-		ServiceVersion: os.Getenv("OTEL_SERVICE_VERSION"),
-		Endpoint:       os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
-		Headers:        parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
-		Sampler:        getEnvOrDefault("OTEL_TRACES_SAMPLER", "always_on"),
-		SampleRate:     parseSampleRate(os.Getenv("LAST9_TRACE_SAMPLE_RATE")),
+		ServiceName:     getEnvOrDefault("OTEL_SERVICE_NAME", stringOr(fc.ServiceName, "unknown-service")),
+		ServiceVersion:  getEnvOrDefault("OTEL_SERVICE_VERSION", stringOr(fc.ServiceVersion, "")),
+		Endpoint:        getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", stringOr(fc.Endpoint, "")),
+		TracesEndpoint:  getEnvOrDefault("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", stringOr(fc.TracesEndpoint, "")),
+		MetricsEndpoint: getEnvOrDefault("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", stringOr(fc.MetricsEndpoint, "")),
+		LogsEndpoint:    getEnvOrDefault("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", stringOr(fc.LogsEndpoint, "")),
+		Protocol:        l.parseProtocol(os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")),
+		Exporter:        l.parseExporter(os.Getenv("LAST9_EXPORTER")),
+		Headers:         fc.Headers,
+		Sampler:         getEnvOrDefault("OTEL_TRACES_SAMPLER", stringOr(fc.Sampling.Sampler, "always_on")),
+		SampleRate:      l.parseSampleRate(os.Getenv("LAST9_TRACE_SAMPLE_RATE")),
+	}
+	if fileErr == nil {
+		cfg.ConfigFile = path
+	}
+	cfg.StrictConfig = l.parseBoolEnv("LAST9_STRICT_CONFIG", false)
+	cfg.Insecure = l.parseBoolEnv("OTEL_EXPORTER_OTLP_INSECURE", boolOr(fc.Insecure, false))
+	cfg.TracesInsecure = l.parseBoolEnv("OTEL_EXPORTER_OTLP_TRACES_INSECURE", cfg.Insecure)
+	cfg.MetricsInsecure = l.parseBoolEnv("OTEL_EXPORTER_OTLP_METRICS_INSECURE", cfg.Insecure)
+	cfg.LogsInsecure = l.parseBoolEnv("OTEL_EXPORTER_OTLP_LOGS_INSECURE", cfg.Insecure)
+	cfg.LogLevel = l.parseOneOf("LAST9_LOG_LEVEL", getEnvOrDefault("LAST9_LOG_LEVEL", stringOr(fc.LogLevel, "")),
+		LogLevelInfo, LogLevelDebug, LogLevelWarn, LogLevelError)
+
+	if cfg.Protocol == "" && fc.Protocol != nil {
+		cfg.Protocol = l.parseProtocol(*fc.Protocol)
+	}
+	if cfg.Exporter == "" && fc.Exporter != nil {
+		cfg.Exporter = l.parseExporter(*fc.Exporter)
+	}
+	if cfg.SampleRate < 0 && fc.Sampling.SampleRate != nil {
+		cfg.SampleRate = *fc.Sampling.SampleRate
+	}
+	if fc.Sampling.Ratio != nil {
+		cfg.SamplerRatio = *fc.Sampling.Ratio
+		cfg.SamplerRatioSet = true
+	}
+	if fc.Sampling.RateLimit != nil {
+		cfg.SamplerRateLimit = *fc.Sampling.RateLimit
+	}
+	// OTEL_TRACES_SAMPLER_ARG is the ratio or the rate limit, depending on
+	// the sampler.
+	if arg := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); arg != "" {
+		switch cfg.Sampler {
+		case "traceidratio", "parentbased_traceidratio":
+			if ratio, ok := l.parseSamplerRatio(arg); ok {
+				cfg.SamplerRatio, cfg.SamplerRatioSet = ratio, true
+			}
+		case "ratelimited":
+			cfg.SamplerRateLimit = l.parseSamplerRateLimit(arg, cfg.SamplerRateLimit)
+		}
+	}
+	cfg.SamplerRateLimitPerRoute = l.parseBoolEnv("LAST9_SAMPLING_RATE_LIMIT_PER_ROUTE", boolOr(fc.Sampling.RateLimitPerRoute, false))
+
+	// Parse headers, letting env values override file values per key
+	if cfg.Headers == nil {
+		cfg.Headers = make(map[string]string)
+	}
+	for k, v := range l.parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")) {
+		cfg.Headers[k] = v
 	}
 
-	// Parse resource attributes
-	cfg.ResourceAttributes, cfg.Environment, cfg.ServiceVersion = parseResourceAttributes(
+	// Parse resource attributes. File attributes come first so that env
+	// attributes with the same key win when the resource is built.
+	environment := "production" // default
+	if v, ok := fc.ResourceAttributes["deployment.environment"]; ok {
+		environment = v
+	}
+	environment = stringOr(fc.Environment, environment)
+	if cfg.ServiceVersion == "" {
+		cfg.ServiceVersion = fc.ResourceAttributes["service.version"]
+	}
+	var envAttrs []attribute.KeyValue
+	envAttrs, cfg.Environment, cfg.ServiceVersion = parseResourceAttributes(
 		os.Getenv("OTEL_RESOURCE_ATTRIBUTES"),
+		environment,
 		cfg.ServiceVersion,
 	)
+	if fileAttrs := fc.resourceAttributes(); len(fileAttrs) > 0 {
+		cfg.ResourceAttributes = append(fileAttrs, envAttrs...)
+	} else {
+		cfg.ResourceAttributes = envAttrs
+	}
 
 	// Parse body capture configuration
-	cfg.BodyCaptureEnabled = parseBoolEnv("LAST9_BODY_CAPTURE_ENABLED", false)
-	cfg.BodyCaptureMaxBytes = parseInt64Env("LAST9_BODY_CAPTURE_MAX_BYTES", 8192)
-	cfg.BodyCaptureOnErrorOnly = parseBoolEnv("LAST9_BODY_CAPTURE_ON_ERROR_ONLY", false)
+	cfg.BodyCaptureEnabled = l.parseBoolEnv("LAST9_BODY_CAPTURE_ENABLED", boolOr(fc.BodyCapture.Enabled, false))
+	cfg.BodyCaptureMaxBytes = l.parseInt64Env("LAST9_BODY_CAPTURE_MAX_BYTES", int64Or(fc.BodyCapture.MaxBytes, 8192))
+	cfg.BodyCaptureOnErrorOnly = l.parseBoolEnv("LAST9_BODY_CAPTURE_ON_ERROR_ONLY", boolOr(fc.BodyCapture.OnErrorOnly, false))
 	cfg.BodyCaptureContentTypes = parseCommaSeparatedWithDefault(
 		"LAST9_BODY_CAPTURE_CONTENT_TYPES",
-		"application/json,application/xml,text/plain",
+		listOr(fc.BodyCapture.ContentTypes, "application/json,application/xml,text/plain"),
 	)
 
 	// Parse route exclusion configuration
 	cfg.ExcludedPaths = parseCommaSeparatedWithDefault(
 		"LAST9_EXCLUDED_PATHS",
-		"/health,/healthz,/metrics,/ready,/live,/ping",
+		listOr(fc.Exclusions.Paths, "/health,/healthz,/metrics,/ready,/live,/ping"),
 	)
 	cfg.ExcludedPathPrefixes = parseCommaSeparatedWithDefault(
 		"LAST9_EXCLUDED_PATH_PREFIXES",
-		"",
+		listOr(fc.Exclusions.PathPrefixes, ""),
 	)
 	cfg.ExcludedPathPatterns = parseCommaSeparatedWithDefault(
 		"LAST9_EXCLUDED_PATH_PATTERNS",
-		"/*/health,/*/healthz,/*/metrics,/*/ready,/*/live,/*/ping",
+		listOr(fc.Exclusions.PathPatterns, "/*/health,/*/healthz,/*/metrics,/*/ready,/*/live,/*/ping"),
 	)
 
+	// Parse resource detectors
+	cfg.ResourceDetectors = parseCommaSeparatedWithDefault("LAST9_RESOURCE_DETECTORS", listOr(fc.ResourceDetectors, ""))
+
+	// Parse propagators. An empty OTEL_PROPAGATORS means the default, as in
+	// the OpenTelemetry SDKs; use "none" to disable propagation.
+	cfg.Propagators = parseCommaSeparatedWithDefault("OTEL_PROPAGATORS", listOr(fc.Propagators, DefaultPropagators))
+	if len(cfg.Propagators) == 0 {
+		cfg.Propagators = strings.Split(DefaultPropagators, ",")
+	}
+
+	// Parse batch span processor and span limits
+	batch, limits := &fc.Batch, &fc.SpanLimits
+	cfg.BatchScheduleDelay = time.Duration(l.parseInt64Env("OTEL_BSP_SCHEDULE_DELAY",
+		durationOr(batch.ScheduleDelay, DefaultBatchScheduleDelay).Milliseconds())) * time.Millisecond
+	cfg.BatchMaxQueueSize = l.parseInt64Env("OTEL_BSP_MAX_QUEUE_SIZE", int64Or(batch.MaxQueueSize, DefaultBatchMaxQueueSize))
+	cfg.BatchMaxExportBatchSize = l.parseInt64Env("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", int64Or(batch.MaxExportBatchSize, DefaultBatchMaxExportBatchSize))
+	cfg.SpanAttributeCountLimit = l.parseInt64Env("OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT", int64Or(limits.AttributeCount, DefaultSpanAttributeCountLimit))
+	cfg.AttributeValueLengthLimit = l.parseInt64Env("OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT", int64Or(limits.AttributeValueLength, 0))
+	cfg.SpanEventCountLimit = l.parseInt64Env("OTEL_SPAN_EVENT_COUNT_LIMIT", int64Or(limits.EventCount, DefaultSpanEventCountLimit))
+	cfg.validateBatchAndLimits(l)
+
+	// Parse metric collection
+	metrics := &fc.Metrics
+	cfg.MetricExportInterval = time.Duration(l.parseInt64Env("OTEL_METRIC_EXPORT_INTERVAL",
+		durationOr(metrics.ExportInterval, DefaultMetricExportInterval).Milliseconds())) * time.Millisecond
+	if cfg.MetricExportInterval <= 0 {
+		l.invalid("OTEL_METRIC_EXPORT_INTERVAL", "Metric export interval must be positive, using default %s", DefaultMetricExportInterval)
+		cfg.MetricExportInterval = DefaultMetricExportInterval
+	}
+	cfg.MetricTemporality = l.parseOneOf("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE",
+		getEnvOrDefault("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", stringOr(metrics.Temporality, "")),
+		MetricTemporalityCumulative, MetricTemporalityDelta, MetricTemporalityLowMemory)
+	cfg.MetricHistogramAggregation = l.parseOneOf("OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION",
+		getEnvOrDefault("OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION", stringOr(metrics.HistogramAggregation, "")),
+		HistogramAggregationExplicit, HistogramAggregationExponential)
+	cfg.MetricExemplarFilter = l.parseOneOf("OTEL_METRICS_EXEMPLAR_FILTER",
+		getEnvOrDefault("OTEL_METRICS_EXEMPLAR_FILTER", stringOr(metrics.ExemplarFilter, "")),
+		ExemplarFilterTraceBased, ExemplarFilterAlwaysOn, ExemplarFilterAlwaysOff)
+	cfg.RuntimeMetricsInterval = l.parseDurationEnv("LAST9_RUNTIME_METRICS_INTERVAL", durationOr(metrics.RuntimeInterval, DefaultRuntimeMetricsInterval))
+	if cfg.RuntimeMetricsInterval == 0 {
+		l.invalid("LAST9_RUNTIME_METRICS_INTERVAL", "Runtime metrics interval must be positive, using default %s", DefaultRuntimeMetricsInterval)
+		cfg.RuntimeMetricsInterval = DefaultRuntimeMetricsInterval
+	}
+
+	// Parse Prometheus endpoint
+	prom := &fc.Prometheus
+	cfg.PrometheusAddr = getEnvOrDefault("LAST9_PROMETHEUS_ADDR", stringOr(prom.Addr, ""))
+	cfg.PrometheusEnabled = l.parseBoolEnv("LAST9_PROMETHEUS_ENABLED", boolOr(prom.Enabled, false)) || cfg.PrometheusAddr != ""
+	cfg.PrometheusPath = getEnvOrDefault("LAST9_PROMETHEUS_PATH", stringOr(prom.Path, DefaultPrometheusPath))
+	if !strings.HasPrefix(cfg.PrometheusPath, "/") {
+		l.invalid("LAST9_PROMETHEUS_PATH", "Prometheus path %q must start with /, using default %s", cfg.PrometheusPath, DefaultPrometheusPath)
+		cfg.PrometheusPath = DefaultPrometheusPath
+	}
+
+	// Parse reload triggers
+	cfg.ReloadOnSIGHUP = l.parseBoolEnv("LAST9_RELOAD_ON_SIGHUP", boolOr(fc.Reload.OnSIGHUP, false))
+	cfg.ConfigWatchInterval = l.parseDurationEnv("LAST9_CONFIG_WATCH_INTERVAL", durationOr(fc.Reload.WatchInterval, 0))
+
+	// Parse shutdown behavior
+	cfg.ShutdownTimeout = l.parseDurationEnv("LAST9_SHUTDOWN_TIMEOUT", durationOr(fc.Shutdown.Timeout, DefaultShutdownTimeout))
+	if cfg.ShutdownTimeout == 0 {
+		l.invalid("LAST9_SHUTDOWN_TIMEOUT", "Shutdown timeout must be positive, using default %s", DefaultShutdownTimeout)
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	cfg.SignalHandling = l.parseBoolEnv("LAST9_SIGNAL_HANDLING", boolOr(fc.Shutdown.HandleSignals, false))
+
+	// Parse sampling rules. The env var replaces the file's list as a whole.
+	if raw, ok := os.LookupEnv("LAST9_SAMPLING_RULES"); ok {
+		cfg.SamplingRules = l.parseSamplingRules(raw)
+	} else {
+		cfg.SamplingRules = fc.samplingRules(path, l)
+	}
+
+	// Parse tail sampling configuration
+	tail := &fc.Sampling.Tail
+	cfg.TailSamplingEnabled = l.parseBoolEnv("LAST9_TAIL_SAMPLING_ENABLED", boolOr(tail.Enabled, false))
+	cfg.TailSamplingRatio = l.parseRatioEnv("LAST9_TAIL_SAMPLING_RATIO", float64Or(tail.Ratio, 0.1))
+	cfg.TailSamplingLatencyThreshold = l.parseDurationEnv("LAST9_TAIL_SAMPLING_LATENCY_THRESHOLD", durationOr(tail.LatencyThreshold, time.Second))
+	cfg.TailSamplingDecisionWait = l.parseDurationEnv("LAST9_TAIL_SAMPLING_DECISION_WAIT", durationOr(tail.DecisionWait, 10*time.Second))
+	cfg.TailSamplingMaxSpans = l.parseInt64Env("LAST9_TAIL_SAMPLING_MAX_SPANS", int64Or(tail.MaxSpans, 100000))
+	if cfg.TailSamplingDecisionWait <= 0 {
+		l.invalid("LAST9_TAIL_SAMPLING_DECISION_WAIT", "Tail sampling decision wait must be positive, using 10s")
+		cfg.TailSamplingDecisionWait = 10 * time.Second
+	}
+	if cfg.TailSamplingMaxSpans <= 0 {
+		l.invalid("LAST9_TAIL_SAMPLING_MAX_SPANS", "Tail sampling max spans must be positive, using 100000")
+		cfg.TailSamplingMaxSpans = 100000
+	}
+
+	// Parse redaction configuration
+	redact := &fc.Redaction
+	cfg.RedactionEnabled = l.parseBoolEnv("LAST9_REDACTION_ENABLED", boolOr(redact.Enabled, false))
+	cfg.RedactionDetectors = l.redactionDetectors(
+		parseCommaSeparatedWithDefault("LAST9_REDACTION_DETECTORS", listOr(redact.Detectors, DefaultRedactionDetectors)),
+		"LAST9_REDACTION_DETECTORS")
+	cfg.RedactionPatterns = l.redactionPatterns(redact.Patterns, path)
+	cfg.RedactionAllowKeys = parseCommaSeparatedWithDefault("LAST9_REDACTION_ALLOW_KEYS", listOr(redact.AllowKeys, ""))
+	cfg.RedactionDenyKeys = parseCommaSeparatedWithDefault("LAST9_REDACTION_DENY_KEYS", listOr(redact.DenyKeys, ""))
+	cfg.RedactionMode = l.parseOneOf("LAST9_REDACTION_MODE", getEnvOrDefault("LAST9_REDACTION_MODE", stringOr(redact.Mode, "")),
+		RedactionModeMask, RedactionModeHash, RedactionModeDrop)
+	cfg.RedactionHashKey = getEnvOrDefault("LAST9_REDACTION_HASH_KEY", stringOr(redact.HashKey, ""))
+
+	// Parse persistent queue configuration
+	queue := &fc.PersistentQueue
+	cfg.PersistentQueueDir = getEnvOrDefault("LAST9_PERSISTENT_QUEUE_DIR", stringOr(queue.Dir, ""))
+	cfg.PersistentQueueMaxBytes = l.parseInt64Env("LAST9_PERSISTENT_QUEUE_MAX_BYTES", int64Or(queue.MaxBytes, 256<<20))
+	cfg.PersistentQueueMaxAge = l.parseDurationEnv("LAST9_PERSISTENT_QUEUE_MAX_AGE", durationOr(queue.MaxAge, 24*time.Hour))
+
+	// Parse file exporter configuration
+	fileExp := &fc.FileExporter
+	cfg.FileExporterPath = getEnvOrDefault("LAST9_FILE_EXPORTER_PATH", stringOr(fileExp.Path, "last9-telemetry"))
+	cfg.FileExporterMaxBytes = l.parseInt64Env("LAST9_FILE_EXPORTER_MAX_BYTES", int64Or(fileExp.MaxBytes, 100<<20))
+	cfg.FileExporterMaxFiles = l.parseInt64Env("LAST9_FILE_EXPORTER_MAX_FILES", int64Or(fileExp.MaxFiles, 10))
+
+	cfg.Destinations = fc.destinations(path, l)
+	cfg.MetricViews = fc.metricViews(path, l)
+
 	// Validate configuration
-	if cfg.Endpoint == "" {
-		log.Println("[Last9 Agent] Warning: OTEL_EXPORTER_OTLP_ENDPOINT not set - telemetry will not be exported")
-		log.Println("[Last9 Agent] Set this environment variable to export telemetry data")
+	if !cfg.hasEndpoint() && cfg.ResolvedExporter() == ExporterOTLP {
+		logging.Warnf("OTEL_EXPORTER_OTLP_ENDPOINT not set - telemetry will not be exported. " +
+			"Set this environment variable to export telemetry data, or LAST9_EXPORTER=console to print it locally")
 	}
 
-	return cfg
+	cfg.issues = l.issues
+	return cfg, fileErr
 }
 
 // parseHeaders parses the OTEL_EXPORTER_OTLP_HEADERS environment variable
 // Expected format: "key1=value1,key2=value2"
-func parseHeaders(headersStr string) map[string]string {
+func (l *loader) parseHeaders(headersStr string) map[string]string {
 	headers := make(map[string]string)
 	if headersStr == "" {
 		return headers
@@ -121,21 +707,27 @@ func parseHeaders(headersStr string) map[string]string {
 
 	pairs := strings.Split(headersStr, ",")
 	for _, pair := range pairs {
-		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
-		if len(kv) == 2 {
-			headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		pair = strings.TrimSpace(pair)
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			if pair != "" {
+				// The value may be a credential, so only the key is logged.
+				l.invalid("OTEL_EXPORTER_OTLP_HEADERS", "Malformed header %q in OTEL_EXPORTER_OTLP_HEADERS (want key=value), skipping", kv[0])
+			}
+			continue
 		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
 	}
 
 	return headers
 }
 
 // parseResourceAttributes parses the OTEL_RESOURCE_ATTRIBUTES environment variable
-// and extracts special attributes like deployment.environment and service.version
+// and extracts special attributes like deployment.environment and service.version.
+// environment is returned unchanged unless the variable sets deployment.environment.
 // Expected format: "key1=value1,key2=value2"
-func parseResourceAttributes(attrsStr string, serviceVersion string) ([]attribute.KeyValue, string, string) {
+func parseResourceAttributes(attrsStr, environment, serviceVersion string) ([]attribute.KeyValue, string, string) {
 	var attrs []attribute.KeyValue
-	environment := "production" // default
 
 	if attrsStr == "" {
 		return attrs, environment, serviceVersion
@@ -195,45 +787,175 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// validateBatchAndLimits replaces non-positive batch sizes, delays and
+// count limits with their defaults, and caps the export batch size at the
+// queue size, logging a warning for each.
+func (c *Config) validateBatchAndLimits(l *loader) {
+	if c.BatchScheduleDelay <= 0 {
+		l.invalid("OTEL_BSP_SCHEDULE_DELAY", "Batch schedule delay must be positive, using default %s", DefaultBatchScheduleDelay)
+		c.BatchScheduleDelay = DefaultBatchScheduleDelay
+	}
+	for _, f := range []struct {
+		name string
+		v    *int64
+		def  int64
+	}{
+		{"OTEL_BSP_MAX_QUEUE_SIZE", &c.BatchMaxQueueSize, DefaultBatchMaxQueueSize},
+		{"OTEL_BSP_MAX_EXPORT_BATCH_SIZE", &c.BatchMaxExportBatchSize, DefaultBatchMaxExportBatchSize},
+		{"OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT", &c.SpanAttributeCountLimit, DefaultSpanAttributeCountLimit},
+		{"OTEL_SPAN_EVENT_COUNT_LIMIT", &c.SpanEventCountLimit, DefaultSpanEventCountLimit},
+	} {
+		if *f.v <= 0 {
+			l.invalid(f.name, "%s must be positive, using default %d", f.name, f.def)
+			*f.v = f.def
+		}
+	}
+	if c.AttributeValueLengthLimit < 0 {
+		l.invalid("OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT", "OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT must not be negative, using no limit")
+		c.AttributeValueLengthLimit = 0
+	}
+	if c.BatchMaxExportBatchSize > c.BatchMaxQueueSize {
+		l.invalid("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", "OTEL_BSP_MAX_EXPORT_BATCH_SIZE %d exceeds OTEL_BSP_MAX_QUEUE_SIZE %d, using %d",
+			c.BatchMaxExportBatchSize, c.BatchMaxQueueSize, c.BatchMaxQueueSize)
+		c.BatchMaxExportBatchSize = c.BatchMaxQueueSize
+	}
+}
+
 // parseBoolEnv reads an env var as bool. Accepts "true"/"1" (case-insensitive).
-func parseBoolEnv(key string, defaultVal bool) bool {
+func (l *loader) parseBoolEnv(key string, defaultVal bool) bool {
 	raw := os.Getenv(key)
 	if raw == "" {
 		return defaultVal
 	}
 	v, err := strconv.ParseBool(raw)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Invalid bool for %s=%q, using default %v", key, raw, defaultVal)
+		l.invalid(key, "Invalid bool for %s=%q, using default %v", key, raw, defaultVal)
 		return defaultVal
 	}
 	return v
 }
 
 // parseInt64Env reads an env var as int64.
-func parseInt64Env(key string, defaultVal int64) int64 {
+func (l *loader) parseInt64Env(key string, defaultVal int64) int64 {
 	raw := os.Getenv(key)
 	if raw == "" {
 		return defaultVal
 	}
 	v, err := strconv.ParseInt(raw, 10, 64)
 	if err != nil || v < 0 {
-		log.Printf("[Last9 Agent] Warning: Invalid int64 for %s=%q, using default %d", key, raw, defaultVal)
+		l.invalid(key, "Invalid int64 for %s=%q, using default %d", key, raw, defaultVal)
+		return defaultVal
+	}
+	return v
+}
+
+// parseDurationEnv reads an env var as a time.Duration (e.g. "30s").
+func (l *loader) parseDurationEnv(key string, defaultVal time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultVal
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil || v < 0 {
+		l.invalid(key, "Invalid duration for %s=%q, using default %s", key, raw, defaultVal)
 		return defaultVal
 	}
 	return v
 }
 
+// parseRatioEnv reads a sampling ratio (0.0-1.0) from key, returning
+// defaultVal when it is unset or invalid.
+func (l *loader) parseRatioEnv(key string, defaultVal float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultVal
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || v > 1 {
+		l.invalid(key, "Invalid ratio for %s=%q (must be 0.0-1.0), using default %v", key, raw, defaultVal)
+		return defaultVal
+	}
+	return v
+}
+
+// parseSamplerRatio parses OTEL_TRACES_SAMPLER_ARG as a ratio (0.0-1.0),
+// reporting whether it is valid.
+func (l *loader) parseSamplerRatio(raw string) (float64, bool) {
+	ratio, err := strconv.ParseFloat(raw, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		l.invalid("OTEL_TRACES_SAMPLER_ARG", "Invalid sampler ratio %q (must be 0.0-1.0), ignoring", raw)
+		return 0, false
+	}
+	return ratio, true
+}
+
+// parseSamplerRateLimit parses OTEL_TRACES_SAMPLER_ARG as the ratelimited
+// sampler's traces per second, returning defaultVal when it is invalid.
+func (l *loader) parseSamplerRateLimit(raw string, defaultVal float64) float64 {
+	limit, err := strconv.ParseFloat(raw, 64)
+	if err != nil || limit <= 0 {
+		l.invalid("OTEL_TRACES_SAMPLER_ARG", "Invalid sampler rate limit %q (must be positive), ignoring", raw)
+		return defaultVal
+	}
+	return limit
+}
+
 // parseSampleRate parses LAST9_TRACE_SAMPLE_RATE into a float64.
 // Returns -1 when the env var is empty (unset), so callers can distinguish
 // "not configured" from "configured as 0.0" (sample nothing).
-func parseSampleRate(raw string) float64 {
+func (l *loader) parseSampleRate(raw string) float64 {
 	if raw == "" {
 		return -1 // unset
 	}
 	rate, err := strconv.ParseFloat(raw, 64)
 	if err != nil || rate < 0 || rate > 1 {
-		log.Printf("[Last9 Agent] Warning: Invalid LAST9_TRACE_SAMPLE_RATE %q (must be 0.0–1.0), ignoring", raw)
+		l.invalid("LAST9_TRACE_SAMPLE_RATE", "Invalid LAST9_TRACE_SAMPLE_RATE %q (must be 0.0–1.0), ignoring", raw)
 		return -1
 	}
 	return rate
 }
+
+// parseOneOf lowercases raw and returns it when it is one of valid, or
+// valid[0] — the default — when raw is empty or unsupported. key names the
+// setting in the warning.
+func (l *loader) parseOneOf(key, raw string, valid ...string) string {
+	v := strings.ToLower(strings.TrimSpace(raw))
+	if v == "" {
+		return valid[0]
+	}
+	for _, ok := range valid {
+		if v == ok {
+			return v
+		}
+	}
+	l.invalid(key, "Unsupported %s %q (want %s), using default %s", key, raw, strings.Join(valid, ", "), valid[0])
+	return valid[0]
+}
+
+// parseExporter validates LAST9_EXPORTER.
+// Returns "" when unset or unsupported so the exporter is chosen automatically.
+func (l *loader) parseExporter(raw string) string {
+	switch e := strings.ToLower(strings.TrimSpace(raw)); e {
+	case "":
+		return ""
+	case ExporterOTLP, ExporterConsole, ExporterFile:
+		return e
+	default:
+		l.invalid("LAST9_EXPORTER", "Unsupported LAST9_EXPORTER %q (want otlp, console or file), ignoring", raw)
+		return ""
+	}
+}
+
+// parseProtocol validates OTEL_EXPORTER_OTLP_PROTOCOL.
+// Returns "" when unset or unsupported so exporters keep their defaults.
+func (l *loader) parseProtocol(raw string) string {
+	switch p := strings.ToLower(strings.TrimSpace(raw)); p {
+	case "":
+		return ""
+	case ProtocolGRPC, ProtocolHTTPProtobuf:
+		return p
+	default:
+		l.invalid("OTEL_EXPORTER_OTLP_PROTOCOL", "Unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q (want grpc or http/protobuf), ignoring", raw)
+		return ""
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseSampleRate(t *testing.T) {
@@ -24,7 +25,7 @@ func TestParseSampleRate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseSampleRate(tt.raw)
+			got := new(loader).parseSampleRate(tt.raw)
 			if got != tt.want {
 				t.Errorf("parseSampleRate(%q) = %v, want %v", tt.raw, got, tt.want)
 			}
@@ -225,7 +226,7 @@ func TestParseBoolEnv(t *testing.T) {
 				os.Setenv(key, *tt.val)
 				defer os.Unsetenv(key)
 			}
-			got := parseBoolEnv(key, tt.defaultVal)
+			got := new(loader).parseBoolEnv(key, tt.defaultVal)
 			if got != tt.want {
 				t.Errorf("parseBoolEnv(%q)=%v (val=%v), want %v", key, got, tt.val, tt.want)
 			}
@@ -254,7 +255,7 @@ func TestParseInt64Env(t *testing.T) {
 				os.Setenv(key, *tt.val)
 				defer os.Unsetenv(key)
 			}
-			got := parseInt64Env(key, tt.defaultVal)
+			got := new(loader).parseInt64Env(key, tt.defaultVal)
 			if got != tt.want {
 				t.Errorf("parseInt64Env(%q)=%v (val=%v), want %v", key, got, tt.val, tt.want)
 			}
@@ -262,6 +263,35 @@ func TestParseInt64Env(t *testing.T) {
 	}
 }
 
+func TestParseDurationEnv(t *testing.T) {
+	const key = "TEST_PARSE_DURATION_ENV"
+	tests := []struct {
+		val        *string
+		name       string
+		want       time.Duration
+		defaultVal time.Duration
+	}{
+		{nil, "not set uses default", 0, 0},
+		{strPtr("30s"), "valid value", 30 * time.Second, 0},
+		{strPtr("0s"), "zero", 0, time.Minute},
+		{strPtr("-5s"), "negative falls back to default", time.Minute, time.Minute},
+		{strPtr("30"), "missing unit falls back to default", time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv(key)
+			if tt.val != nil {
+				os.Setenv(key, *tt.val)
+				defer os.Unsetenv(key)
+			}
+			got := new(loader).parseDurationEnv(key, tt.defaultVal)
+			if got != tt.want {
+				t.Errorf("parseDurationEnv(%q)=%v (val=%v), want %v", key, got, tt.val, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoad_BodyCapture_Defaults(t *testing.T) {
 	os.Unsetenv("LAST9_BODY_CAPTURE_ENABLED")
 	os.Unsetenv("LAST9_BODY_CAPTURE_MAX_BYTES")
@@ -314,4 +344,421 @@ func TestLoad_BodyCapture_EnvVars(t *testing.T) {
 	}
 }
 
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", ""},
+		{"grpc", ProtocolGRPC},
+		{"http/protobuf", ProtocolHTTPProtobuf},
+		{" GRPC ", ProtocolGRPC},
+		{"http/json", ""},
+		{"thrift", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := new(loader).parseProtocol(tt.raw); got != tt.want {
+				t.Errorf("parseProtocol(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExporter(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", ""},
+		{"otlp", ExporterOTLP},
+		{" Console ", ExporterConsole},
+		{"file", ExporterFile},
+		{"stdout", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := new(loader).parseExporter(tt.raw); got != tt.want {
+				t.Errorf("parseExporter(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvedExporter(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"explicit console", Config{Exporter: ExporterConsole, Endpoint: "https://otlp.last9.io"}, ExporterConsole},
+		{"explicit otlp in development", Config{Exporter: ExporterOTLP, Environment: "development"}, ExporterOTLP},
+		{"no endpoint in development", Config{Environment: "development"}, ExporterConsole},
+		{"no endpoint in local", Config{Environment: "Local"}, ExporterConsole},
+		{"no endpoint in production", Config{Environment: "production"}, ExporterOTLP},
+		{"endpoint in development", Config{Environment: "dev", TracesEndpoint: "http://localhost:4318/v1/traces"}, ExporterOTLP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ResolvedExporter(); got != tt.want {
+				t.Errorf("ResolvedExporter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_ExporterEnvVars(t *testing.T) {
+	os.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+	os.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://traces:4317")
+	os.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://metrics:4317")
+	os.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "http://logs:4317")
+	defer func() {
+		os.Unsetenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT")
+	}()
+
+	cfg := Load()
+
+	if cfg.Protocol != ProtocolGRPC {
+		t.Errorf("Protocol = %q, want %q", cfg.Protocol, ProtocolGRPC)
+	}
+	if cfg.TracesEndpoint != "http://traces:4317" {
+		t.Errorf("TracesEndpoint = %q, want http://traces:4317", cfg.TracesEndpoint)
+	}
+	if cfg.MetricsEndpoint != "http://metrics:4317" {
+		t.Errorf("MetricsEndpoint = %q, want http://metrics:4317", cfg.MetricsEndpoint)
+	}
+	if cfg.LogsEndpoint != "http://logs:4317" {
+		t.Errorf("LogsEndpoint = %q, want http://logs:4317", cfg.LogsEndpoint)
+	}
+}
+
+func TestLoad_Insecure(t *testing.T) {
+	os.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	os.Setenv("OTEL_EXPORTER_OTLP_METRICS_INSECURE", "false")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_INSECURE")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_METRICS_INSECURE")
+
+	cfg := Load()
+	if !cfg.Insecure || !cfg.TracesInsecure || !cfg.LogsInsecure {
+		t.Errorf("Insecure/TracesInsecure/LogsInsecure = %t/%t/%t, want true from OTEL_EXPORTER_OTLP_INSECURE",
+			cfg.Insecure, cfg.TracesInsecure, cfg.LogsInsecure)
+	}
+	if cfg.MetricsInsecure {
+		t.Error("MetricsInsecure = true, want false from OTEL_EXPORTER_OTLP_METRICS_INSECURE")
+	}
+
+	os.Unsetenv("OTEL_EXPORTER_OTLP_INSECURE")
+	os.Unsetenv("OTEL_EXPORTER_OTLP_METRICS_INSECURE")
+	if cfg := Load(); cfg.Insecure || cfg.TracesInsecure {
+		t.Error("Insecure = true by default, want false")
+	}
+}
+
+func TestLoad_SamplerArg(t *testing.T) {
+	defer os.Unsetenv("OTEL_TRACES_SAMPLER")
+	defer os.Unsetenv("OTEL_TRACES_SAMPLER_ARG")
+
+	tests := []struct {
+		sampler   string
+		arg       string
+		wantRatio float64
+		wantSet   bool
+		wantLimit float64
+		wantIssue bool
+	}{
+		{"traceidratio", "", 0, false, 0, false},
+		{"traceidratio", "0.5", 0.5, true, 0, false},
+		{"parentbased_traceidratio", "0", 0, true, 0, false},
+		{"traceidratio", "1.5", 0, false, 0, true},
+		{"traceidratio", "half", 0, false, 0, true},
+		{"ratelimited", "50", 0, false, 50, false},
+		{"ratelimited", "0", 0, false, 0, true},
+		{"ratelimited", "invalid", 0, false, 0, true},
+		{"always_on", "10", 0, false, 0, false}, // not used by this sampler
+	}
+	for _, tt := range tests {
+		t.Run(tt.sampler+"/"+tt.arg, func(t *testing.T) {
+			os.Setenv("OTEL_TRACES_SAMPLER", tt.sampler)
+			os.Setenv("OTEL_TRACES_SAMPLER_ARG", tt.arg)
+
+			cfg := Load()
+			if cfg.SamplerRatio != tt.wantRatio || cfg.SamplerRatioSet != tt.wantSet || cfg.SamplerRateLimit != tt.wantLimit {
+				t.Errorf("SamplerRatio/SamplerRatioSet/SamplerRateLimit = %v/%t/%v, want %v/%t/%v",
+					cfg.SamplerRatio, cfg.SamplerRatioSet, cfg.SamplerRateLimit, tt.wantRatio, tt.wantSet, tt.wantLimit)
+			}
+			if got := len(cfg.issues) > 0; got != tt.wantIssue {
+				t.Errorf("issues = %v, want issue: %t", cfg.issues, tt.wantIssue)
+			}
+		})
+	}
+}
+
 func strPtr(s string) *string { return &s }
+
+func TestLoad_Propagators(t *testing.T) {
+	defer os.Unsetenv("OTEL_PROPAGATORS")
+
+	tests := []struct {
+		name string
+		env  *string
+		want []string
+	}{
+		{"unset", nil, []string{"tracecontext", "baggage"}},
+		{"empty means default", strPtr(""), []string{"tracecontext", "baggage"}},
+		{"custom", strPtr("b3, tracecontext"), []string{"b3", "tracecontext"}},
+		{"none", strPtr("none"), []string{"none"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv("OTEL_PROPAGATORS")
+			if tt.env != nil {
+				os.Setenv("OTEL_PROPAGATORS", *tt.env)
+			}
+			if got := Load().Propagators; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Propagators = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_BatchAndSpanLimits(t *testing.T) {
+	keys := []string{
+		"OTEL_BSP_SCHEDULE_DELAY", "OTEL_BSP_MAX_QUEUE_SIZE", "OTEL_BSP_MAX_EXPORT_BATCH_SIZE",
+		"OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT", "OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT", "OTEL_SPAN_EVENT_COUNT_LIMIT",
+	}
+	unset := func() {
+		for _, k := range keys {
+			os.Unsetenv(k)
+		}
+	}
+	defer unset()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want [6]int64 // delay in ms, queue, batch, attribute count, value length, event count
+	}{
+		{"defaults", nil, [6]int64{5000, 2048, 512, 128, 0, 128}},
+		{"custom", map[string]string{
+			"OTEL_BSP_SCHEDULE_DELAY": "1000", "OTEL_BSP_MAX_QUEUE_SIZE": "8192", "OTEL_BSP_MAX_EXPORT_BATCH_SIZE": "1024",
+			"OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT": "64", "OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT": "4096", "OTEL_SPAN_EVENT_COUNT_LIMIT": "32",
+		}, [6]int64{1000, 8192, 1024, 64, 4096, 32}},
+		{"invalid falls back to defaults", map[string]string{
+			"OTEL_BSP_SCHEDULE_DELAY": "0", "OTEL_BSP_MAX_QUEUE_SIZE": "-1", "OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT": "many",
+			"OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT": "-1",
+		}, [6]int64{5000, 2048, 512, 128, 0, 128}},
+		{"batch capped at queue", map[string]string{
+			"OTEL_BSP_MAX_QUEUE_SIZE": "100", "OTEL_BSP_MAX_EXPORT_BATCH_SIZE": "512",
+		}, [6]int64{5000, 100, 100, 128, 0, 128}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unset()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			cfg := Load()
+			got := [6]int64{cfg.BatchScheduleDelay.Milliseconds(), cfg.BatchMaxQueueSize, cfg.BatchMaxExportBatchSize,
+				cfg.SpanAttributeCountLimit, cfg.AttributeValueLengthLimit, cfg.SpanEventCountLimit}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Metrics(t *testing.T) {
+	keys := []string{
+		"OTEL_METRIC_EXPORT_INTERVAL", "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE",
+		"OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION", "LAST9_RUNTIME_METRICS_INTERVAL",
+	}
+	unset := func() {
+		for _, k := range keys {
+			os.Unsetenv(k)
+		}
+	}
+	defer unset()
+
+	tests := []struct {
+		name            string
+		env             map[string]string
+		wantInterval    time.Duration
+		wantTemporality string
+		wantHistogram   string
+		wantRuntime     time.Duration
+	}{
+		{"defaults", nil, time.Minute, MetricTemporalityCumulative, HistogramAggregationExplicit, 15 * time.Second},
+		{"custom", map[string]string{
+			"OTEL_METRIC_EXPORT_INTERVAL":                              "10000",
+			"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE":        "Delta",
+			"OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION": "base2_exponential_bucket_histogram",
+			"LAST9_RUNTIME_METRICS_INTERVAL":                           "5s",
+		}, 10 * time.Second, MetricTemporalityDelta, HistogramAggregationExponential, 5 * time.Second},
+		{"lowmemory", map[string]string{
+			"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "lowmemory",
+		}, time.Minute, MetricTemporalityLowMemory, HistogramAggregationExplicit, 15 * time.Second},
+		{"invalid falls back to defaults", map[string]string{
+			"OTEL_METRIC_EXPORT_INTERVAL":                              "0",
+			"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE":        "sometimes",
+			"OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION": "summary",
+			"LAST9_RUNTIME_METRICS_INTERVAL":                           "0s",
+		}, time.Minute, MetricTemporalityCumulative, HistogramAggregationExplicit, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unset()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			cfg := Load()
+			if cfg.MetricExportInterval != tt.wantInterval {
+				t.Errorf("MetricExportInterval = %v, want %v", cfg.MetricExportInterval, tt.wantInterval)
+			}
+			if cfg.MetricTemporality != tt.wantTemporality {
+				t.Errorf("MetricTemporality = %q, want %q", cfg.MetricTemporality, tt.wantTemporality)
+			}
+			if cfg.MetricHistogramAggregation != tt.wantHistogram {
+				t.Errorf("MetricHistogramAggregation = %q, want %q", cfg.MetricHistogramAggregation, tt.wantHistogram)
+			}
+			if cfg.RuntimeMetricsInterval != tt.wantRuntime {
+				t.Errorf("RuntimeMetricsInterval = %v, want %v", cfg.RuntimeMetricsInterval, tt.wantRuntime)
+			}
+		})
+	}
+}
+
+func TestLoad_ExemplarFilter(t *testing.T) {
+	defer os.Unsetenv("OTEL_METRICS_EXEMPLAR_FILTER")
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", ExemplarFilterTraceBased},
+		{"always_on", ExemplarFilterAlwaysOn},
+		{"ALWAYS_OFF", ExemplarFilterAlwaysOff},
+		{"sometimes", ExemplarFilterTraceBased},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			os.Setenv("OTEL_METRICS_EXEMPLAR_FILTER", tt.raw)
+			if got := Load().MetricExemplarFilter; got != tt.want {
+				t.Errorf("MetricExemplarFilter = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_LogLevel(t *testing.T) {
+	defer os.Unsetenv("LAST9_LOG_LEVEL")
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", LogLevelInfo},
+		{"debug", LogLevelDebug},
+		{"WARN", LogLevelWarn},
+		{"error", LogLevelError},
+		{"verbose", LogLevelInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			os.Setenv("LAST9_LOG_LEVEL", tt.raw)
+			if got := Load().LogLevel; got != tt.want {
+				t.Errorf("LogLevel = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Prometheus(t *testing.T) {
+	keys := []string{"LAST9_PROMETHEUS_ENABLED", "LAST9_PROMETHEUS_ADDR", "LAST9_PROMETHEUS_PATH"}
+	unset := func() {
+		for _, k := range keys {
+			os.Unsetenv(k)
+		}
+	}
+	defer unset()
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantEnabled bool
+		wantAddr    string
+		wantPath    string
+	}{
+		{"defaults", nil, false, "", "/metrics"},
+		{"handler only", map[string]string{"LAST9_PROMETHEUS_ENABLED": "true"}, true, "", "/metrics"},
+		{"addr enables", map[string]string{"LAST9_PROMETHEUS_ADDR": ":9464", "LAST9_PROMETHEUS_PATH": "/prom"}, true, ":9464", "/prom"},
+		{"relative path", map[string]string{"LAST9_PROMETHEUS_ADDR": ":9464", "LAST9_PROMETHEUS_PATH": "prom"}, true, ":9464", "/metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unset()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			cfg := Load()
+			if cfg.PrometheusEnabled != tt.wantEnabled || cfg.PrometheusAddr != tt.wantAddr || cfg.PrometheusPath != tt.wantPath {
+				t.Errorf("prometheus = %t/%q/%q, want %t/%q/%q", cfg.PrometheusEnabled, cfg.PrometheusAddr, cfg.PrometheusPath,
+					tt.wantEnabled, tt.wantAddr, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestLoad_Redaction(t *testing.T) {
+	keys := []string{"LAST9_REDACTION_ENABLED", "LAST9_REDACTION_DETECTORS", "LAST9_REDACTION_ALLOW_KEYS",
+		"LAST9_REDACTION_DENY_KEYS", "LAST9_REDACTION_MODE"}
+	unset := func() {
+		for _, k := range keys {
+			os.Unsetenv(k)
+		}
+	}
+	defer unset()
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		wantEnabled   bool
+		wantDetectors []string
+		wantDeny      []string
+		wantMode      string
+	}{
+		{"defaults", nil, false, []string{"email", "credit_card", "jwt", "aws_key"}, nil, "mask"},
+		{
+			"configured",
+			map[string]string{
+				"LAST9_REDACTION_ENABLED":   "true",
+				"LAST9_REDACTION_DETECTORS": "Email, ip",
+				"LAST9_REDACTION_DENY_KEYS": "enduser.id,http.request.header.authorization",
+				"LAST9_REDACTION_MODE":      "HASH",
+			},
+			true, []string{"email", "ip"}, []string{"enduser.id", "http.request.header.authorization"}, "hash",
+		},
+		{"unsupported values", map[string]string{"LAST9_REDACTION_DETECTORS": "ssn,jwt", "LAST9_REDACTION_MODE": "shred"}, false, []string{"jwt"}, nil, "mask"},
+		{"no detectors", map[string]string{"LAST9_REDACTION_DETECTORS": ""}, false, nil, nil, "mask"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unset()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			cfg := Load()
+			if cfg.RedactionEnabled != tt.wantEnabled || !reflect.DeepEqual(cfg.RedactionDetectors, tt.wantDetectors) ||
+				!reflect.DeepEqual(cfg.RedactionDenyKeys, tt.wantDeny) || cfg.RedactionMode != tt.wantMode {
+				t.Errorf("redaction = %t/%q/%q/%q, want %t/%q/%q/%q",
+					cfg.RedactionEnabled, cfg.RedactionDetectors, cfg.RedactionDenyKeys, cfg.RedactionMode,
+					tt.wantEnabled, tt.wantDetectors, tt.wantDeny, tt.wantMode)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Destination is an additional OTLP endpoint that receives a copy of the
+// agent's traces and metrics, optionally filtered. The primary exporter
+// configured by OTEL_EXPORTER_OTLP_* is unaffected, and logs are only sent
+// to the primary exporter.
+type Destination struct {
+	// Name identifies the destination in log messages. Required and unique.
+	Name string
+	// Endpoint is the base OTLP endpoint. With http/protobuf the signal path
+	// (/v1/traces, /v1/metrics) is appended. Required.
+	Endpoint string
+	// Headers are sent with every export request.
+	Headers map[string]string
+	// Protocol is "grpc" or "http/protobuf" for both signals.
+	// Default: http/protobuf.
+	Protocol string
+	// Signals limits the destination to "traces" or "metrics". Default: both.
+	Signals []string
+	// Filter selects the spans and metrics sent. Default: everything.
+	Filter DestinationFilter
+}
+
+// DestinationFilter selects what a Destination receives. Each non-empty
+// field must match, and matches when any of its values does.
+type DestinationFilter struct {
+	// SpanStatus matches the span status code: error, ok or unset.
+	SpanStatus []string
+	// SpanKinds matches the span kind: server, client, producer, consumer or internal.
+	SpanKinds []string
+	// Scopes matches instrumentation scope names by prefix, for spans and
+	// metrics, e.g. "go.opentelemetry.io/contrib/instrumentation/net/http".
+	Scopes []string
+	// MetricPrefixes matches metric names by prefix, e.g. "http.server.".
+	MetricPrefixes []string
+}
+
+// Signals accepted in Destination.Signals.
+const (
+	SignalTraces  = "traces"
+	SignalMetrics = "metrics"
+)
+
+// Values accepted for Destination.Signals and DestinationFilter.SpanStatus.
+var (
+	signals      = map[string]bool{SignalTraces: true, SignalMetrics: true}
+	spanStatuses = map[string]bool{"error": true, "ok": true, "unset": true}
+)
+
+// HasSignal reports whether d receives signal.
+func (d *Destination) HasSignal(signal string) bool {
+	return len(d.Signals) == 0 || contains(d.Signals, signal)
+}
+
+// Validate normalizes d and reports the first invalid field.
+func (d *Destination) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if d.Endpoint == "" {
+		return fmt.Errorf("missing endpoint")
+	}
+	d.Protocol = strings.ToLower(strings.TrimSpace(d.Protocol))
+	if d.Protocol != "" && d.Protocol != ProtocolGRPC && d.Protocol != ProtocolHTTPProtobuf {
+		return fmt.Errorf("unsupported protocol %q (want grpc or http/protobuf)", d.Protocol)
+	}
+	var err error
+	if d.Signals, err = normalize(d.Signals, signals, "signal"); err != nil {
+		return err
+	}
+	if d.Filter.SpanStatus, err = normalize(d.Filter.SpanStatus, spanStatuses, "span_status"); err != nil {
+		return err
+	}
+	d.Filter.SpanKinds, err = normalize(d.Filter.SpanKinds, spanKinds, "span_kind")
+	return err
+}
+
+// normalize returns values lowercased in a new slice, or an error for the
+// first value that is not in valid.
+func normalize(values []string, valid map[string]bool, field string) ([]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(strings.TrimSpace(v))
+		if !valid[out[i]] {
+			return nil, fmt.Errorf("unknown %s %q", field, v)
+		}
+	}
+	return out, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validDestinations returns the valid destinations of ds, logging and
+// skipping the rest. source names where they came from in the warning.
+func (l *loader) validDestinations(ds []Destination, source string) []Destination {
+	var valid []Destination
+	seen := make(map[string]bool)
+	for i, d := range ds {
+		if err := d.Validate(); err != nil {
+			l.invalid(fmt.Sprintf("destinations[%d]", i), "Invalid destinations[%d] in %s: %v, skipping", i, source, err)
+			continue
+		}
+		if seen[d.Name] {
+			l.invalid(fmt.Sprintf("destinations[%d]", i), "Duplicate destination name %q in %s, skipping", d.Name, source)
+			continue
+		}
+		seen[d.Name] = true
+		valid = append(valid, d)
+	}
+	return valid
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDestinationValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       Destination
+		wantErr bool
+	}{
+		{"minimal", Destination{Name: "a", Endpoint: "collector:4318"}, false},
+		{"full", Destination{
+			Name: "a", Endpoint: "collector:4317", Protocol: "GRPC", Signals: []string{"Traces"},
+			Filter: DestinationFilter{SpanStatus: []string{"Error"}, SpanKinds: []string{"Server"}},
+		}, false},
+		{"missing name", Destination{Endpoint: "collector:4318"}, true},
+		{"missing endpoint", Destination{Name: "a"}, true},
+		{"bad protocol", Destination{Name: "a", Endpoint: "x", Protocol: "http/json"}, true},
+		{"bad signal", Destination{Name: "a", Endpoint: "x", Signals: []string{"logs"}}, true},
+		{"bad status", Destination{Name: "a", Endpoint: "x", Filter: DestinationFilter{SpanStatus: []string{"failed"}}}, true},
+		{"bad kind", Destination{Name: "a", Endpoint: "x", Filter: DestinationFilter{SpanKinds: []string{"rpc"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDestinationValidateNormalizes(t *testing.T) {
+	signals := []string{"Traces"}
+	d := Destination{Name: "a", Endpoint: "x", Protocol: " GRPC ", Signals: signals,
+		Filter: DestinationFilter{SpanStatus: []string{"ERROR"}, SpanKinds: []string{"Client"}}}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if d.Protocol != ProtocolGRPC || !reflect.DeepEqual(d.Signals, []string{"traces"}) ||
+		!reflect.DeepEqual(d.Filter.SpanStatus, []string{"error"}) || !reflect.DeepEqual(d.Filter.SpanKinds, []string{"client"}) {
+		t.Errorf("Validate() left %+v", d)
+	}
+	if signals[0] != "Traces" {
+		t.Error("Validate() modified the caller's slice")
+	}
+	if !d.HasSignal(SignalTraces) || d.HasSignal(SignalMetrics) {
+		t.Error("HasSignal() does not follow Signals")
+	}
+	if all := (Destination{}); !all.HasSignal(SignalMetrics) {
+		t.Error("HasSignal() = false with no Signals, want every signal")
+	}
+}
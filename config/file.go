@@ -0,0 +1,464 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the on-disk schema read from LAST9_CONFIG_FILE.
+// Pointer fields distinguish "not set" from an explicit zero value, so that
+// e.g. `paths: []` disables the default exclusions just like
+// LAST9_EXCLUDED_PATHS="" does.
+//
+// Example (YAML):
+//
+//	service_name: checkout
+//	environment: staging
+//	endpoint: https://otlp.last9.io
+//	headers:
+//	  Authorization: Basic <token>
+//	resource_attributes:
+//	  team: payments
+//	resource_detectors: [ec2, ecs]
+//	propagators: [tracecontext, baggage, b3multi]
+//	log_level: warn
+//	sampling:
+//	  sample_rate: 0.25
+//	  rules:
+//	    - route: /api/v1/poll
+//	      ratio: 0.01
+//	  tail:
+//	    enabled: true
+//	    latency_threshold: 500ms
+//	exclusions:
+//	  paths: [/health, /metrics]
+//	  path_prefixes: [/internal/]
+//	body_capture:
+//	  enabled: true
+//	  on_error_only: true
+//	reload:
+//	  watch_interval: 30s
+//	batch:
+//	  schedule_delay: 1s
+//	  max_queue_size: 8192
+//	span_limits:
+//	  attribute_value_length: 4096
+//	metrics:
+//	  export_interval: 30s
+//	  temporality: delta
+//	  histogram_aggregation: base2_exponential_bucket_histogram
+//	prometheus:
+//	  addr: :9464
+//	redaction:
+//	  enabled: true
+//	  patterns: ['order-secret-[0-9a-f]{32}']
+//	  deny_keys: [enduser.id]
+//	shutdown:
+//	  timeout: 10s
+//	  handle_signals: true
+//	persistent_queue:
+//	  dir: /var/lib/last9/queue
+//	  max_bytes: 104857600
+//	file_exporter:
+//	  path: /var/lib/last9/export
+//	metric_views:
+//	  - instrument: http.server.request.duration
+//	    attribute_keys: [http.route, http.response.status_code]
+//	    buckets: [0.01, 0.05, 0.1, 0.5, 1, 5]
+//	destinations:
+//	  - name: in-house
+//	    endpoint: https://collector.internal:4318
+//	    filter:
+//	      span_status: [error]
+type fileConfig struct {
+	ServiceName        *string           `yaml:"service_name" json:"service_name"`
+	ServiceVersion     *string           `yaml:"service_version" json:"service_version"`
+	Environment        *string           `yaml:"environment" json:"environment"`
+	Endpoint           *string           `yaml:"endpoint" json:"endpoint"`
+	TracesEndpoint     *string           `yaml:"traces_endpoint" json:"traces_endpoint"`
+	MetricsEndpoint    *string           `yaml:"metrics_endpoint" json:"metrics_endpoint"`
+	LogsEndpoint       *string           `yaml:"logs_endpoint" json:"logs_endpoint"`
+	Protocol           *string           `yaml:"protocol" json:"protocol"`
+	Insecure           *bool             `yaml:"insecure" json:"insecure"`
+	Exporter           *string           `yaml:"exporter" json:"exporter"`
+	Headers            map[string]string `yaml:"headers" json:"headers"`
+	ResourceAttributes map[string]string `yaml:"resource_attributes" json:"resource_attributes"`
+	ResourceDetectors  *[]string         `yaml:"resource_detectors" json:"resource_detectors"`
+	Propagators        *[]string         `yaml:"propagators" json:"propagators"`
+	LogLevel           *string           `yaml:"log_level" json:"log_level"`
+
+	Sampling struct {
+		Sampler    *string  `yaml:"sampler" json:"sampler"`
+		Ratio      *float64 `yaml:"ratio" json:"ratio"`
+		SampleRate *float64 `yaml:"sample_rate" json:"sample_rate"`
+
+		RateLimit         *float64 `yaml:"rate_limit" json:"rate_limit"`
+		RateLimitPerRoute *bool    `yaml:"rate_limit_per_route" json:"rate_limit_per_route"`
+
+		Rules []fileSamplingRule `yaml:"rules" json:"rules"`
+
+		Tail struct {
+			Enabled          *bool         `yaml:"enabled" json:"enabled"`
+			Ratio            *float64      `yaml:"ratio" json:"ratio"`
+			LatencyThreshold *fileDuration `yaml:"latency_threshold" json:"latency_threshold"`
+			DecisionWait     *fileDuration `yaml:"decision_wait" json:"decision_wait"`
+			MaxSpans         *int64        `yaml:"max_spans" json:"max_spans"`
+		} `yaml:"tail" json:"tail"`
+	} `yaml:"sampling" json:"sampling"`
+
+	Exclusions struct {
+		Paths        *[]string `yaml:"paths" json:"paths"`
+		PathPrefixes *[]string `yaml:"path_prefixes" json:"path_prefixes"`
+		PathPatterns *[]string `yaml:"path_patterns" json:"path_patterns"`
+	} `yaml:"exclusions" json:"exclusions"`
+
+	BodyCapture struct {
+		Enabled      *bool     `yaml:"enabled" json:"enabled"`
+		MaxBytes     *int64    `yaml:"max_bytes" json:"max_bytes"`
+		OnErrorOnly  *bool     `yaml:"on_error_only" json:"on_error_only"`
+		ContentTypes *[]string `yaml:"content_types" json:"content_types"`
+	} `yaml:"body_capture" json:"body_capture"`
+
+	Reload struct {
+		OnSIGHUP      *bool         `yaml:"on_sighup" json:"on_sighup"`
+		WatchInterval *fileDuration `yaml:"watch_interval" json:"watch_interval"`
+	} `yaml:"reload" json:"reload"`
+
+	Batch struct {
+		ScheduleDelay      *fileDuration `yaml:"schedule_delay" json:"schedule_delay"`
+		MaxQueueSize       *int64        `yaml:"max_queue_size" json:"max_queue_size"`
+		MaxExportBatchSize *int64        `yaml:"max_export_batch_size" json:"max_export_batch_size"`
+	} `yaml:"batch" json:"batch"`
+
+	SpanLimits struct {
+		AttributeCount       *int64 `yaml:"attribute_count" json:"attribute_count"`
+		AttributeValueLength *int64 `yaml:"attribute_value_length" json:"attribute_value_length"`
+		EventCount           *int64 `yaml:"event_count" json:"event_count"`
+	} `yaml:"span_limits" json:"span_limits"`
+
+	Metrics struct {
+		ExportInterval       *fileDuration `yaml:"export_interval" json:"export_interval"`
+		Temporality          *string       `yaml:"temporality" json:"temporality"`
+		HistogramAggregation *string       `yaml:"histogram_aggregation" json:"histogram_aggregation"`
+		ExemplarFilter       *string       `yaml:"exemplar_filter" json:"exemplar_filter"`
+		RuntimeInterval      *fileDuration `yaml:"runtime_interval" json:"runtime_interval"`
+	} `yaml:"metrics" json:"metrics"`
+
+	Prometheus struct {
+		Enabled *bool   `yaml:"enabled" json:"enabled"`
+		Addr    *string `yaml:"addr" json:"addr"`
+		Path    *string `yaml:"path" json:"path"`
+	} `yaml:"prometheus" json:"prometheus"`
+
+	Redaction struct {
+		Enabled   *bool     `yaml:"enabled" json:"enabled"`
+		Detectors *[]string `yaml:"detectors" json:"detectors"`
+		Patterns  []string  `yaml:"patterns" json:"patterns"`
+		AllowKeys *[]string `yaml:"allow_keys" json:"allow_keys"`
+		DenyKeys  *[]string `yaml:"deny_keys" json:"deny_keys"`
+		Mode      *string   `yaml:"mode" json:"mode"`
+		HashKey   *string   `yaml:"hash_key" json:"hash_key"`
+	} `yaml:"redaction" json:"redaction"`
+
+	Shutdown struct {
+		Timeout       *fileDuration `yaml:"timeout" json:"timeout"`
+		HandleSignals *bool         `yaml:"handle_signals" json:"handle_signals"`
+	} `yaml:"shutdown" json:"shutdown"`
+
+	PersistentQueue struct {
+		Dir      *string       `yaml:"dir" json:"dir"`
+		MaxBytes *int64        `yaml:"max_bytes" json:"max_bytes"`
+		MaxAge   *fileDuration `yaml:"max_age" json:"max_age"`
+	} `yaml:"persistent_queue" json:"persistent_queue"`
+
+	FileExporter struct {
+		Path     *string `yaml:"path" json:"path"`
+		MaxBytes *int64  `yaml:"max_bytes" json:"max_bytes"`
+		MaxFiles *int64  `yaml:"max_files" json:"max_files"`
+	} `yaml:"file_exporter" json:"file_exporter"`
+
+	MetricViews  []fileMetricView  `yaml:"metric_views" json:"metric_views"`
+	Destinations []fileDestination `yaml:"destinations" json:"destinations"`
+}
+
+// fileMetricView is one entry of metric_views.
+type fileMetricView struct {
+	Instrument    string    `yaml:"instrument" json:"instrument"`
+	Scope         string    `yaml:"scope" json:"scope"`
+	Drop          bool      `yaml:"drop" json:"drop"`
+	Name          string    `yaml:"name" json:"name"`
+	AttributeKeys []string  `yaml:"attribute_keys" json:"attribute_keys"`
+	Buckets       []float64 `yaml:"buckets" json:"buckets"`
+}
+
+// fileDestination is one entry of destinations.
+type fileDestination struct {
+	Name     string            `yaml:"name" json:"name"`
+	Endpoint string            `yaml:"endpoint" json:"endpoint"`
+	Headers  map[string]string `yaml:"headers" json:"headers"`
+	Protocol string            `yaml:"protocol" json:"protocol"`
+	Signals  []string          `yaml:"signals" json:"signals"`
+	Filter   struct {
+		SpanStatus     []string `yaml:"span_status" json:"span_status"`
+		SpanKinds      []string `yaml:"span_kinds" json:"span_kinds"`
+		Scopes         []string `yaml:"scopes" json:"scopes"`
+		MetricPrefixes []string `yaml:"metric_prefixes" json:"metric_prefixes"`
+	} `yaml:"filter" json:"filter"`
+}
+
+// fileSamplingRule is one entry of sampling.rules. Ratio is required.
+type fileSamplingRule struct {
+	Route      string   `yaml:"route" json:"route"`
+	Method     string   `yaml:"method" json:"method"`
+	SpanName   string   `yaml:"span_name" json:"span_name"`
+	SpanKind   string   `yaml:"span_kind" json:"span_kind"`
+	RPCService string   `yaml:"rpc_service" json:"rpc_service"`
+	Ratio      *float64 `yaml:"ratio" json:"ratio"`
+}
+
+// fileDuration is a time.Duration written as a Go duration string ("30s")
+// in the config file.
+type fileDuration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *fileDuration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *fileDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *fileDuration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = fileDuration(v)
+	return nil
+}
+
+// readFile parses the config file at path. Files ending in .json are decoded
+// as JSON; anything else as YAML. Unknown keys are rejected so that typos
+// surface instead of being silently ignored. An empty path yields an empty
+// fileConfig.
+func (l *loader) readFile(path string) (*fileConfig, error) {
+	fc := &fileConfig{}
+	if path == "" {
+		return fc, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fc, fmt.Errorf("read config file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(fc)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(fc)
+		if errors.Is(err, io.EOF) {
+			err = nil // empty file
+		}
+	}
+	if err != nil {
+		return &fileConfig{}, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	if r := fc.Sampling.SampleRate; r != nil && (*r < 0 || *r > 1) {
+		l.invalid("sampling.sample_rate", "Invalid sampling.sample_rate %v in %s (must be 0.0-1.0), ignoring", *r, path)
+		fc.Sampling.SampleRate = nil
+	}
+	if r := fc.Sampling.Ratio; r != nil && (*r < 0 || *r > 1) {
+		l.invalid("sampling.ratio", "Invalid sampling.ratio %v in %s (must be 0.0-1.0), ignoring", *r, path)
+		fc.Sampling.Ratio = nil
+	}
+	if n := fc.BodyCapture.MaxBytes; n != nil && *n < 0 {
+		l.invalid("body_capture.max_bytes", "Invalid body_capture.max_bytes %d in %s, ignoring", *n, path)
+		fc.BodyCapture.MaxBytes = nil
+	}
+	if r := fc.Sampling.RateLimit; r != nil && *r <= 0 {
+		l.invalid("sampling.rate_limit", "Invalid sampling.rate_limit %v in %s (must be positive), ignoring", *r, path)
+		fc.Sampling.RateLimit = nil
+	}
+	if n := fc.PersistentQueue.MaxBytes; n != nil && *n < 0 {
+		l.invalid("persistent_queue.max_bytes", "Invalid persistent_queue.max_bytes %d in %s, ignoring", *n, path)
+		fc.PersistentQueue.MaxBytes = nil
+	}
+	if n := fc.FileExporter.MaxBytes; n != nil && *n < 0 {
+		l.invalid("file_exporter.max_bytes", "Invalid file_exporter.max_bytes %d in %s, ignoring", *n, path)
+		fc.FileExporter.MaxBytes = nil
+	}
+	if n := fc.FileExporter.MaxFiles; n != nil && *n < 0 {
+		l.invalid("file_exporter.max_files", "Invalid file_exporter.max_files %d in %s, ignoring", *n, path)
+		fc.FileExporter.MaxFiles = nil
+	}
+	tail := &fc.Sampling.Tail
+	if r := tail.Ratio; r != nil && (*r < 0 || *r > 1) {
+		l.invalid("sampling.tail.ratio", "Invalid sampling.tail.ratio %v in %s (must be 0.0-1.0), ignoring", *r, path)
+		tail.Ratio = nil
+	}
+	if n := tail.MaxSpans; n != nil && *n <= 0 {
+		l.invalid("sampling.tail.max_spans", "Invalid sampling.tail.max_spans %d in %s, ignoring", *n, path)
+		tail.MaxSpans = nil
+	}
+	for name, d := range map[string]**fileDuration{
+		"sampling.tail.latency_threshold": &tail.LatencyThreshold,
+		"sampling.tail.decision_wait":     &tail.DecisionWait,
+		"reload.watch_interval":           &fc.Reload.WatchInterval,
+		"shutdown.timeout":                &fc.Shutdown.Timeout,
+		"metrics.export_interval":         &fc.Metrics.ExportInterval,
+		"metrics.runtime_interval":        &fc.Metrics.RuntimeInterval,
+		"persistent_queue.max_age":        &fc.PersistentQueue.MaxAge,
+	} {
+		if *d != nil && **d < 0 {
+			l.invalid(name, "Invalid %s %s in %s, ignoring", name, time.Duration(**d), path)
+			*d = nil
+		}
+	}
+	return fc, nil
+}
+
+// resourceAttributes returns the file's resource attributes sorted by key,
+// so that the resulting resource is deterministic.
+func (fc *fileConfig) resourceAttributes() []attribute.KeyValue {
+	keys := make([]string, 0, len(fc.ResourceAttributes))
+	for k := range fc.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, fc.ResourceAttributes[k]))
+	}
+	return attrs
+}
+
+// samplingRules converts sampling.rules, skipping invalid rules with a warning.
+func (fc *fileConfig) samplingRules(path string, l *loader) []SamplingRule {
+	var rules []SamplingRule
+	for i, fr := range fc.Sampling.Rules {
+		if fr.Ratio == nil {
+			l.invalid(fmt.Sprintf("sampling.rules[%d]", i), "sampling.rules[%d] in %s has no ratio, skipping", i, path)
+			continue
+		}
+		rule := SamplingRule{
+			Route:      fr.Route,
+			Method:     fr.Method,
+			SpanName:   fr.SpanName,
+			SpanKind:   fr.SpanKind,
+			RPCService: fr.RPCService,
+			Ratio:      *fr.Ratio,
+		}
+		if err := rule.validate(); err != nil {
+			l.invalid(fmt.Sprintf("sampling.rules[%d]", i), "Invalid sampling.rules[%d] in %s: %v, skipping", i, path, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// destinations converts destinations, skipping invalid entries with a warning.
+func (fc *fileConfig) destinations(path string, l *loader) []Destination {
+	ds := make([]Destination, 0, len(fc.Destinations))
+	for _, fd := range fc.Destinations {
+		ds = append(ds, Destination{
+			Name:     fd.Name,
+			Endpoint: fd.Endpoint,
+			Headers:  fd.Headers,
+			Protocol: fd.Protocol,
+			Signals:  fd.Signals,
+			Filter: DestinationFilter{
+				SpanStatus:     fd.Filter.SpanStatus,
+				SpanKinds:      fd.Filter.SpanKinds,
+				Scopes:         fd.Filter.Scopes,
+				MetricPrefixes: fd.Filter.MetricPrefixes,
+			},
+		})
+	}
+	return l.validDestinations(ds, path)
+}
+
+func (fc *fileConfig) metricViews(path string, l *loader) []MetricView {
+	vs := make([]MetricView, 0, len(fc.MetricViews))
+	for _, fv := range fc.MetricViews {
+		vs = append(vs, MetricView(fv))
+	}
+	return l.validMetricViews(vs, path)
+}
+
+// stringOr returns *p, or def when p is nil.
+func stringOr(p *string, def string) string {
+	if p != nil {
+		return *p
+	}
+	return def
+}
+
+// boolOr returns *p, or def when p is nil.
+func boolOr(p *bool, def bool) bool {
+	if p != nil {
+		return *p
+	}
+	return def
+}
+
+// float64Or returns *p, or def when p is nil.
+func float64Or(p *float64, def float64) float64 {
+	if p != nil {
+		return *p
+	}
+	return def
+}
+
+// durationOr returns *p, or def when p is nil.
+func durationOr(p *fileDuration, def time.Duration) time.Duration {
+	if p != nil {
+		return time.Duration(*p)
+	}
+	return def
+}
+
+// int64Or returns *p, or def when p is nil.
+func int64Or(p *int64, def int64) int64 {
+	if p != nil {
+		return *p
+	}
+	return def
+}
+
+// listOr returns *p joined with commas, or def when p is nil. The result is
+// used as the default for parseCommaSeparatedWithDefault, so an explicit
+// empty list in the file opts out of the defaults.
+func listOr(p *[]string, def string) string {
+	if p != nil {
+		return strings.Join(*p, ",")
+	}
+	return def
+}
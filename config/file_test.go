@@ -0,0 +1,617 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// writeConfigFile writes content to a file named name in a temp dir and
+// returns its path.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+const testYAMLConfig = `
+service_name: checkout
+service_version: 1.2.3
+environment: staging
+endpoint: https://otlp.example.com
+protocol: grpc
+exporter: otlp
+headers:
+  Authorization: Basic file-token
+  X-Team: payments
+resource_attributes:
+  team: payments
+sampling:
+  sampler: parentbased_traceidratio
+  ratio: 0.25
+  sample_rate: 0.1
+exclusions:
+  paths: []
+  path_prefixes: [/internal/]
+  path_patterns: ["/*/status"]
+body_capture:
+  enabled: true
+  max_bytes: 4096
+  on_error_only: true
+  content_types: [application/json]
+`
+
+func TestLoadFile_YAML(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", testYAMLConfig)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if cfg.ConfigFile != path {
+		t.Errorf("ConfigFile = %q, want %q", cfg.ConfigFile, path)
+	}
+	if cfg.ServiceName != "checkout" || cfg.ServiceVersion != "1.2.3" || cfg.Environment != "staging" {
+		t.Errorf("service = %q/%q/%q, want checkout/1.2.3/staging", cfg.ServiceName, cfg.ServiceVersion, cfg.Environment)
+	}
+	if cfg.Endpoint != "https://otlp.example.com" || cfg.Protocol != ProtocolGRPC || cfg.Exporter != ExporterOTLP {
+		t.Errorf("exporter = %q/%q/%q, want https://otlp.example.com/grpc/otlp", cfg.Endpoint, cfg.Protocol, cfg.Exporter)
+	}
+	wantHeaders := map[string]string{"Authorization": "Basic file-token", "X-Team": "payments"}
+	if !reflect.DeepEqual(cfg.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", cfg.Headers, wantHeaders)
+	}
+	if !reflect.DeepEqual(cfg.ResourceAttributes, []attribute.KeyValue{attribute.String("team", "payments")}) {
+		t.Errorf("ResourceAttributes = %v, want [team=payments]", cfg.ResourceAttributes)
+	}
+	if cfg.Sampler != "parentbased_traceidratio" || cfg.SamplerRatio != 0.25 || cfg.SampleRate != 0.1 {
+		t.Errorf("sampling = %q/%v/%v, want parentbased_traceidratio/0.25/0.1", cfg.Sampler, cfg.SamplerRatio, cfg.SampleRate)
+	}
+	if cfg.ExcludedPaths != nil {
+		t.Errorf("ExcludedPaths = %v, want nil (empty list opts out of defaults)", cfg.ExcludedPaths)
+	}
+	if !reflect.DeepEqual(cfg.ExcludedPathPrefixes, []string{"/internal/"}) {
+		t.Errorf("ExcludedPathPrefixes = %v, want [/internal/]", cfg.ExcludedPathPrefixes)
+	}
+	if !reflect.DeepEqual(cfg.ExcludedPathPatterns, []string{"/*/status"}) {
+		t.Errorf("ExcludedPathPatterns = %v, want [/*/status]", cfg.ExcludedPathPatterns)
+	}
+	if !cfg.BodyCaptureEnabled || !cfg.BodyCaptureOnErrorOnly || cfg.BodyCaptureMaxBytes != 4096 {
+		t.Errorf("body capture = %v/%v/%d, want true/true/4096", cfg.BodyCaptureEnabled, cfg.BodyCaptureOnErrorOnly, cfg.BodyCaptureMaxBytes)
+	}
+	if !reflect.DeepEqual(cfg.BodyCaptureContentTypes, []string{"application/json"}) {
+		t.Errorf("BodyCaptureContentTypes = %v, want [application/json]", cfg.BodyCaptureContentTypes)
+	}
+}
+
+func TestLoadFile_JSON(t *testing.T) {
+	path := writeConfigFile(t, "agent.json", `{
+		"service_name": "checkout",
+		"exclusions": {"path_prefixes": ["/internal/"]},
+		"body_capture": {"enabled": true}
+	}`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.ServiceName != "checkout" {
+		t.Errorf("ServiceName = %q, want checkout", cfg.ServiceName)
+	}
+	if !reflect.DeepEqual(cfg.ExcludedPathPrefixes, []string{"/internal/"}) {
+		t.Errorf("ExcludedPathPrefixes = %v, want [/internal/]", cfg.ExcludedPathPrefixes)
+	}
+	if !cfg.BodyCaptureEnabled {
+		t.Error("BodyCaptureEnabled = false, want true")
+	}
+	// Unset sections keep their defaults.
+	if len(cfg.ExcludedPaths) == 0 {
+		t.Error("ExcludedPaths should keep defaults when not set in the file")
+	}
+	if cfg.BodyCaptureMaxBytes != 8192 {
+		t.Errorf("BodyCaptureMaxBytes = %d, want default 8192", cfg.BodyCaptureMaxBytes)
+	}
+}
+
+func TestLoadFile_EnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", testYAMLConfig)
+
+	os.Setenv("OTEL_SERVICE_NAME", "from-env")
+	os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Basic env-token")
+	os.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod,team=platform")
+	os.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.5")
+	os.Setenv("LAST9_TRACE_SAMPLE_RATE", "0.9")
+	os.Setenv("LAST9_EXCLUDED_PATHS", "/healthz")
+	os.Setenv("LAST9_BODY_CAPTURE_ENABLED", "false")
+	defer func() {
+		os.Unsetenv("OTEL_SERVICE_NAME")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_HEADERS")
+		os.Unsetenv("OTEL_RESOURCE_ATTRIBUTES")
+		os.Unsetenv("OTEL_TRACES_SAMPLER_ARG")
+		os.Unsetenv("LAST9_TRACE_SAMPLE_RATE")
+		os.Unsetenv("LAST9_EXCLUDED_PATHS")
+		os.Unsetenv("LAST9_BODY_CAPTURE_ENABLED")
+	}()
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if cfg.ServiceName != "from-env" {
+		t.Errorf("ServiceName = %q, want from-env", cfg.ServiceName)
+	}
+	wantHeaders := map[string]string{"Authorization": "Basic env-token", "X-Team": "payments"}
+	if !reflect.DeepEqual(cfg.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v (merged per key)", cfg.Headers, wantHeaders)
+	}
+	if cfg.Environment != "prod" {
+		t.Errorf("Environment = %q, want prod", cfg.Environment)
+	}
+	// File attributes come first so that env attributes win on conflict.
+	last := cfg.ResourceAttributes[len(cfg.ResourceAttributes)-1]
+	if last != attribute.String("team", "platform") {
+		t.Errorf("last resource attribute = %v, want team=platform", last)
+	}
+	if cfg.SamplerRatio != 0.5 {
+		t.Errorf("SamplerRatio = %v, want 0.5 from OTEL_TRACES_SAMPLER_ARG", cfg.SamplerRatio)
+	}
+	if cfg.SampleRate != 0.9 {
+		t.Errorf("SampleRate = %v, want 0.9", cfg.SampleRate)
+	}
+	if !reflect.DeepEqual(cfg.ExcludedPaths, []string{"/healthz"}) {
+		t.Errorf("ExcludedPaths = %v, want [/healthz]", cfg.ExcludedPaths)
+	}
+	if cfg.BodyCaptureEnabled {
+		t.Error("BodyCaptureEnabled = true, want false from env")
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"unknown yaml key", "agent.yaml", "service_nme: typo\n", "field service_nme not found"},
+		{"unknown json key", "agent.json", `{"service_nme": "typo"}`, "unknown field"},
+		{"malformed yaml", "agent.yml", "headers: [unclosed\n", "parse config file"},
+		{"invalid yaml duration", "agent.yaml", "reload:\n  watch_interval: 30\n", "missing unit in duration"},
+		{"invalid json duration", "agent.json", `{"sampling": {"tail": {"decision_wait": "soon"}}}`, "invalid duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.file, tt.content)
+			cfg, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadFile() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if cfg == nil || cfg.ServiceName != "unknown-service" || cfg.ConfigFile != "" {
+				t.Errorf("expected env-only config on error, got %+v", cfg)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		if err == nil {
+			t.Fatal("expected error for missing file")
+		}
+	})
+}
+
+func TestLoadFile_EmptyFileAndPath(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", "")
+	if _, err := LoadFile(path); err != nil {
+		t.Errorf("LoadFile(empty file) error = %v", err)
+	}
+	cfg, err := LoadFile("")
+	if err != nil || cfg.ConfigFile != "" {
+		t.Errorf("LoadFile(\"\") = %q, %v; want env-only config", cfg.ConfigFile, err)
+	}
+}
+
+func TestLoad_ConfigFileEnv(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", "service_name: from-file\n")
+	os.Setenv("LAST9_CONFIG_FILE", path)
+	defer os.Unsetenv("LAST9_CONFIG_FILE")
+
+	cfg := Load()
+	if cfg.ServiceName != "from-file" {
+		t.Errorf("ServiceName = %q, want from-file", cfg.ServiceName)
+	}
+
+	os.Setenv("LAST9_CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	cfg = Load()
+	if cfg.ServiceName != "unknown-service" {
+		t.Errorf("ServiceName = %q, want unknown-service when the file is unreadable", cfg.ServiceName)
+	}
+}
+
+func TestLoadFile_Reload(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", "reload:\n  on_sighup: true\n  watch_interval: 30s\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !cfg.ReloadOnSIGHUP || cfg.ConfigWatchInterval != 30*time.Second {
+		t.Errorf("reload = %v/%v, want true/30s", cfg.ReloadOnSIGHUP, cfg.ConfigWatchInterval)
+	}
+
+	os.Setenv("LAST9_RELOAD_ON_SIGHUP", "false")
+	os.Setenv("LAST9_CONFIG_WATCH_INTERVAL", "5s")
+	defer os.Unsetenv("LAST9_RELOAD_ON_SIGHUP")
+	defer os.Unsetenv("LAST9_CONFIG_WATCH_INTERVAL")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.ReloadOnSIGHUP || cfg.ConfigWatchInterval != 5*time.Second {
+		t.Errorf("reload = %v/%v, want false/5s (env overrides file)", cfg.ReloadOnSIGHUP, cfg.ConfigWatchInterval)
+	}
+}
+
+func TestLoadFile_Shutdown(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", "shutdown:\n  timeout: 20s\n  handle_signals: true\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !cfg.SignalHandling || cfg.ShutdownTimeout != 20*time.Second {
+		t.Errorf("shutdown = %v/%v, want true/20s", cfg.SignalHandling, cfg.ShutdownTimeout)
+	}
+
+	os.Setenv("LAST9_SIGNAL_HANDLING", "false")
+	os.Setenv("LAST9_SHUTDOWN_TIMEOUT", "0s")
+	defer os.Unsetenv("LAST9_SIGNAL_HANDLING")
+	defer os.Unsetenv("LAST9_SHUTDOWN_TIMEOUT")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.SignalHandling || cfg.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Errorf("shutdown = %v/%v, want false/%v (env overrides file, zero timeout rejected)", cfg.SignalHandling, cfg.ShutdownTimeout, DefaultShutdownTimeout)
+	}
+}
+
+func TestLoadFile_BatchAndSpanLimits(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `
+batch:
+  schedule_delay: 2s
+  max_queue_size: 4096
+  max_export_batch_size: 256
+span_limits:
+  attribute_count: 64
+  attribute_value_length: 1024
+  event_count: 16
+`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.BatchScheduleDelay != 2*time.Second || cfg.BatchMaxQueueSize != 4096 || cfg.BatchMaxExportBatchSize != 256 {
+		t.Errorf("batch = %v/%d/%d, want 2s/4096/256", cfg.BatchScheduleDelay, cfg.BatchMaxQueueSize, cfg.BatchMaxExportBatchSize)
+	}
+	if cfg.SpanAttributeCountLimit != 64 || cfg.AttributeValueLengthLimit != 1024 || cfg.SpanEventCountLimit != 16 {
+		t.Errorf("span limits = %d/%d/%d, want 64/1024/16", cfg.SpanAttributeCountLimit, cfg.AttributeValueLengthLimit, cfg.SpanEventCountLimit)
+	}
+
+	os.Setenv("OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT", "2048")
+	defer os.Unsetenv("OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT")
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.AttributeValueLengthLimit != 2048 {
+		t.Errorf("AttributeValueLengthLimit = %d, want 2048 (env overrides file)", cfg.AttributeValueLengthLimit)
+	}
+}
+
+func TestLoadFile_Metrics(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `
+metrics:
+  export_interval: 30s
+  temporality: delta
+  histogram_aggregation: base2_exponential_bucket_histogram
+  runtime_interval: 1m
+  exemplar_filter: always_off
+`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.MetricExemplarFilter != ExemplarFilterAlwaysOff {
+		t.Errorf("MetricExemplarFilter = %q, want always_off", cfg.MetricExemplarFilter)
+	}
+	if cfg.MetricExportInterval != 30*time.Second || cfg.RuntimeMetricsInterval != time.Minute {
+		t.Errorf("intervals = %v/%v, want 30s/1m", cfg.MetricExportInterval, cfg.RuntimeMetricsInterval)
+	}
+	if cfg.MetricTemporality != MetricTemporalityDelta || cfg.MetricHistogramAggregation != HistogramAggregationExponential {
+		t.Errorf("temporality/histogram = %q/%q, want delta/base2_exponential_bucket_histogram", cfg.MetricTemporality, cfg.MetricHistogramAggregation)
+	}
+
+	os.Setenv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", "cumulative")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE")
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.MetricTemporality != MetricTemporalityCumulative {
+		t.Errorf("MetricTemporality = %q, want cumulative (env overrides file)", cfg.MetricTemporality)
+	}
+}
+
+func TestLoadFile_TailSampling(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `
+sampling:
+  tail:
+    enabled: true
+    ratio: 0.05
+    latency_threshold: 500ms
+    decision_wait: 5s
+    max_spans: 1000
+`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !cfg.TailSamplingEnabled || cfg.TailSamplingRatio != 0.05 || cfg.TailSamplingLatencyThreshold != 500*time.Millisecond ||
+		cfg.TailSamplingDecisionWait != 5*time.Second || cfg.TailSamplingMaxSpans != 1000 {
+		t.Errorf("tail sampling = %v/%v/%v/%v/%v, want true/0.05/500ms/5s/1000", cfg.TailSamplingEnabled, cfg.TailSamplingRatio,
+			cfg.TailSamplingLatencyThreshold, cfg.TailSamplingDecisionWait, cfg.TailSamplingMaxSpans)
+	}
+
+	os.Setenv("LAST9_TAIL_SAMPLING_RATIO", "0.5")
+	os.Setenv("LAST9_TAIL_SAMPLING_LATENCY_THRESHOLD", "2s")
+	defer os.Unsetenv("LAST9_TAIL_SAMPLING_RATIO")
+	defer os.Unsetenv("LAST9_TAIL_SAMPLING_LATENCY_THRESHOLD")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.TailSamplingRatio != 0.5 || cfg.TailSamplingLatencyThreshold != 2*time.Second {
+		t.Errorf("tail sampling = %v/%v, want 0.5/2s (env overrides file)", cfg.TailSamplingRatio, cfg.TailSamplingLatencyThreshold)
+	}
+}
+
+func TestLoadFile_ZeroRatio(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `
+sampling:
+  sampler: traceidratio
+  ratio: 0
+`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.SamplerRatio != 0 || !cfg.SamplerRatioSet {
+		t.Errorf("SamplerRatio = %v, SamplerRatioSet = %t, want 0, true (sample nothing)", cfg.SamplerRatio, cfg.SamplerRatioSet)
+	}
+
+	path = writeConfigFile(t, "agent.yaml", "sampling:\n  sampler: traceidratio\n")
+	if cfg, _ = LoadFile(path); cfg.SamplerRatioSet {
+		t.Error("SamplerRatioSet = true without a ratio, want unset")
+	}
+}
+
+func TestLoadFile_RateLimit(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", "sampling:\n  sampler: ratelimited\n  rate_limit: 50\n  rate_limit_per_route: true\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.Sampler != "ratelimited" || cfg.SamplerRateLimit != 50 || !cfg.SamplerRateLimitPerRoute {
+		t.Errorf("rate limit = %q/%v/%v, want ratelimited/50/true", cfg.Sampler, cfg.SamplerRateLimit, cfg.SamplerRateLimitPerRoute)
+	}
+
+	// OTEL_TRACES_SAMPLER_ARG overrides the file value.
+	os.Setenv("OTEL_TRACES_SAMPLER_ARG", "10")
+	defer os.Unsetenv("OTEL_TRACES_SAMPLER_ARG")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.SamplerRateLimit != 10 {
+		t.Errorf("SamplerRateLimit = %v, want 10 from OTEL_TRACES_SAMPLER_ARG", cfg.SamplerRateLimit)
+	}
+}
+
+func TestLoadFile_PersistentQueue(t *testing.T) {
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.PersistentQueueDir != "" || cfg.PersistentQueueMaxBytes != 256<<20 || cfg.PersistentQueueMaxAge != 24*time.Hour {
+		t.Errorf("defaults = %q/%d/%v, want disabled/256MiB/24h", cfg.PersistentQueueDir, cfg.PersistentQueueMaxBytes, cfg.PersistentQueueMaxAge)
+	}
+
+	path := writeConfigFile(t, "agent.yaml", "persistent_queue:\n  dir: /var/lib/last9\n  max_bytes: 1048576\n  max_age: 1h\n")
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.PersistentQueueDir != "/var/lib/last9" || cfg.PersistentQueueMaxBytes != 1<<20 || cfg.PersistentQueueMaxAge != time.Hour {
+		t.Errorf("persistent queue = %q/%d/%v, want /var/lib/last9/1048576/1h", cfg.PersistentQueueDir, cfg.PersistentQueueMaxBytes, cfg.PersistentQueueMaxAge)
+	}
+
+	os.Setenv("LAST9_PERSISTENT_QUEUE_DIR", "/tmp/queue")
+	os.Setenv("LAST9_PERSISTENT_QUEUE_MAX_AGE", "10m")
+	defer os.Unsetenv("LAST9_PERSISTENT_QUEUE_DIR")
+	defer os.Unsetenv("LAST9_PERSISTENT_QUEUE_MAX_AGE")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.PersistentQueueDir != "/tmp/queue" || cfg.PersistentQueueMaxAge != 10*time.Minute {
+		t.Errorf("persistent queue = %q/%v, want /tmp/queue/10m (env overrides file)", cfg.PersistentQueueDir, cfg.PersistentQueueMaxAge)
+	}
+}
+
+func TestLoadFile_FileExporter(t *testing.T) {
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.FileExporterPath != "last9-telemetry" || cfg.FileExporterMaxBytes != 100<<20 || cfg.FileExporterMaxFiles != 10 {
+		t.Errorf("defaults = %q/%d/%d, want last9-telemetry/100MiB/10", cfg.FileExporterPath, cfg.FileExporterMaxBytes, cfg.FileExporterMaxFiles)
+	}
+
+	path := writeConfigFile(t, "agent.yaml", "exporter: file\nfile_exporter:\n  path: /data/otlp\n  max_bytes: 1048576\n  max_files: -1\n")
+	os.Setenv("LAST9_FILE_EXPORTER_MAX_FILES", "3")
+	defer os.Unsetenv("LAST9_FILE_EXPORTER_MAX_FILES")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.ResolvedExporter() != ExporterFile {
+		t.Errorf("ResolvedExporter() = %q, want file", cfg.ResolvedExporter())
+	}
+	if cfg.FileExporterPath != "/data/otlp" || cfg.FileExporterMaxBytes != 1<<20 || cfg.FileExporterMaxFiles != 3 {
+		t.Errorf("file exporter = %q/%d/%d, want /data/otlp/1048576/3", cfg.FileExporterPath, cfg.FileExporterMaxBytes, cfg.FileExporterMaxFiles)
+	}
+}
+
+func TestLoadFile_ResourceDetectors(t *testing.T) {
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.ResourceDetectors != nil {
+		t.Errorf("ResourceDetectors = %v, want none by default", cfg.ResourceDetectors)
+	}
+
+	path := writeConfigFile(t, "agent.yaml", "resource_detectors: [ec2, ecs]\n")
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.ResourceDetectors, []string{"ec2", "ecs"}) {
+		t.Errorf("ResourceDetectors = %v, want [ec2 ecs]", cfg.ResourceDetectors)
+	}
+
+	os.Setenv("LAST9_RESOURCE_DETECTORS", "gce, azure")
+	defer os.Unsetenv("LAST9_RESOURCE_DETECTORS")
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.ResourceDetectors, []string{"gce", "azure"}) {
+		t.Errorf("ResourceDetectors = %v, want [gce azure] from env", cfg.ResourceDetectors)
+	}
+}
+
+func TestLoadFile_Destinations(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `destinations:
+  - name: in-house
+    endpoint: https://collector.internal:4318
+    headers:
+      X-Token: abc
+    signals: [traces]
+    filter:
+      span_status: [error]
+      span_kinds: [server]
+      scopes: [go.opentelemetry.io/contrib/]
+      metric_prefixes: [http.]
+  - name: in-house
+    endpoint: https://duplicate:4318
+  - name: broken
+    endpoint: https://collector:4318
+    protocol: http/json
+`)
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := []Destination{{
+		Name:     "in-house",
+		Endpoint: "https://collector.internal:4318",
+		Headers:  map[string]string{"X-Token": "abc"},
+		Signals:  []string{"traces"},
+		Filter: DestinationFilter{
+			SpanStatus:     []string{"error"},
+			SpanKinds:      []string{"server"},
+			Scopes:         []string{"go.opentelemetry.io/contrib/"},
+			MetricPrefixes: []string{"http."},
+		},
+	}}
+	if !reflect.DeepEqual(cfg.Destinations, want) {
+		t.Errorf("Destinations = %+v, want %+v", cfg.Destinations, want)
+	}
+}
+
+func TestLoadFile_MetricViews(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `metric_views:
+  - instrument: http.server.request.duration
+    attribute_keys: [http.route]
+    buckets: [0.1, 1]
+  - scope: go.nhat.io/otelsql
+    drop: true
+  - instrument: "http.*"
+    name: renamed
+`)
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := []MetricView{
+		{Instrument: "http.server.request.duration", AttributeKeys: []string{"http.route"}, Buckets: []float64{0.1, 1}},
+		{Scope: "go.nhat.io/otelsql", Drop: true},
+	}
+	if !reflect.DeepEqual(cfg.MetricViews, want) {
+		t.Errorf("MetricViews = %+v, want %+v", cfg.MetricViews, want)
+	}
+}
+
+func TestLoadFile_Redaction(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `redaction:
+  enabled: true
+  detectors: [jwt]
+  patterns: ['order-[0-9]+', '(unclosed']
+  allow_keys: [enduser.email]
+  mode: drop
+  hash_key: file-key
+`)
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !cfg.RedactionEnabled || cfg.RedactionMode != RedactionModeDrop {
+		t.Errorf("RedactionEnabled = %t, RedactionMode = %q, want true, drop", cfg.RedactionEnabled, cfg.RedactionMode)
+	}
+	if !reflect.DeepEqual(cfg.RedactionDetectors, []string{"jwt"}) {
+		t.Errorf("RedactionDetectors = %q, want [jwt]", cfg.RedactionDetectors)
+	}
+	if !reflect.DeepEqual(cfg.RedactionPatterns, []string{"order-[0-9]+"}) {
+		t.Errorf("RedactionPatterns = %q, want only the valid pattern", cfg.RedactionPatterns)
+	}
+	if !reflect.DeepEqual(cfg.RedactionAllowKeys, []string{"enduser.email"}) {
+		t.Errorf("RedactionAllowKeys = %q, want [enduser.email]", cfg.RedactionAllowKeys)
+	}
+	if cfg.RedactionHashKey != "file-key" {
+		t.Errorf("RedactionHashKey = %q, want file-key", cfg.RedactionHashKey)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactionDetectors are the supported Config.RedactionDetectors.
+var redactionDetectors = []string{"email", "credit_card", "jwt", "aws_key", "ip"}
+
+// ValidRedactionDetectors returns the supported detector names in names,
+// lowercased, logging and skipping the rest. source names where they came
+// from in the warning.
+func ValidRedactionDetectors(names []string, source string) []string {
+	return (*loader)(nil).redactionDetectors(names, source)
+}
+
+// ValidRedactionPatterns returns the patterns that compile as regular
+// expressions, logging and skipping the rest. source names where they came
+// from in the warning.
+func ValidRedactionPatterns(patterns []string, source string) []string {
+	return (*loader)(nil).redactionPatterns(patterns, source)
+}
+
+func (l *loader) redactionDetectors(names []string, source string) []string {
+	var valid []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !contains(redactionDetectors, name) {
+			l.invalid("LAST9_REDACTION_DETECTORS", "Unsupported redaction detector %q in %s (want %s), skipping",
+				name, source, strings.Join(redactionDetectors, ", "))
+			continue
+		}
+		valid = append(valid, name)
+	}
+	return valid
+}
+
+func (l *loader) redactionPatterns(patterns []string, source string) []string {
+	var valid []string
+	for _, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			l.invalid("redaction.patterns", "Invalid redaction pattern %q in %s: %v, skipping", p, source, err)
+			continue
+		}
+		valid = append(valid, p)
+	}
+	return valid
+}
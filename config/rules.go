@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SamplingRule sets the sampling ratio for new traces whose root span matches
+// every non-empty field. A rule with no match fields matches all spans.
+type SamplingRule struct {
+	// Route matches http.route, falling back to url.path. "/api/**" matches
+	// a prefix, patterns with *, ? or [ are globs (path.Match), anything else
+	// must match exactly — the same forms as the LAST9_EXCLUDED_PATH* lists.
+	Route string
+	// Method matches the HTTP request method, case-insensitively.
+	Method string
+	// SpanName matches the span name, exactly or as a glob (path.Match).
+	SpanName string
+	// SpanKind matches the span kind: server, client, producer, consumer or internal.
+	SpanKind string
+	// RPCService matches rpc.service, exactly or as a glob (path.Match).
+	RPCService string
+	// Ratio is the fraction of matching traces to keep (0.0-1.0).
+	Ratio float64
+}
+
+// spanKinds are the values accepted for SamplingRule.SpanKind.
+var spanKinds = map[string]bool{
+	"server": true, "client": true, "producer": true, "consumer": true, "internal": true,
+}
+
+// validate normalizes r and reports the first invalid field.
+func (r *SamplingRule) validate() error {
+	r.Method = strings.ToUpper(r.Method)
+	r.SpanKind = strings.ToLower(r.SpanKind)
+	if r.SpanKind != "" && !spanKinds[r.SpanKind] {
+		return fmt.Errorf("unknown span_kind %q", r.SpanKind)
+	}
+	if r.Ratio < 0 || r.Ratio > 1 {
+		return fmt.Errorf("ratio %v must be 0.0-1.0", r.Ratio)
+	}
+	return nil
+}
+
+// parseSamplingRules parses LAST9_SAMPLING_RULES: rules separated by ";",
+// each a comma-separated list of key=value pairs with a required ratio.
+// Invalid rules are skipped with a warning.
+//
+//	route=/api/v1/poll,ratio=0.01;route=/checkout/**,ratio=1;span_kind=client,ratio=0.1
+func (l *loader) parseSamplingRules(raw string) []SamplingRule {
+	var rules []SamplingRule
+	for _, item := range strings.Split(raw, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rule, err := parseSamplingRule(item)
+		if err != nil {
+			l.invalid("LAST9_SAMPLING_RULES", "Invalid sampling rule %q in LAST9_SAMPLING_RULES: %v, skipping", item, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func parseSamplingRule(item string) (SamplingRule, error) {
+	var rule SamplingRule
+	hasRatio := false
+	for _, pair := range strings.Split(item, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return rule, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "route":
+			rule.Route = value
+		case "method":
+			rule.Method = value
+		case "span_name":
+			rule.SpanName = value
+		case "span_kind":
+			rule.SpanKind = value
+		case "rpc_service":
+			rule.RPCService = value
+		case "ratio":
+			ratio, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return rule, fmt.Errorf("invalid ratio %q", value)
+			}
+			rule.Ratio = ratio
+			hasRatio = true
+		default:
+			return rule, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	if !hasRatio {
+		return rule, fmt.Errorf("missing ratio")
+	}
+	return rule, rule.validate()
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseSamplingRules(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []SamplingRule
+	}{
+		{"empty", "", nil},
+		{
+			name: "multiple rules",
+			raw:  "route=/api/v1/poll,ratio=0.01; route=/checkout/**, method=post, ratio=1",
+			want: []SamplingRule{
+				{Route: "/api/v1/poll", Ratio: 0.01},
+				{Route: "/checkout/**", Method: "POST", Ratio: 1},
+			},
+		},
+		{
+			name: "all keys",
+			raw:  "span_name=Kafka *,span_kind=Consumer,rpc_service=orders.*,ratio=0",
+			want: []SamplingRule{{SpanName: "Kafka *", SpanKind: "consumer", RPCService: "orders.*", Ratio: 0}},
+		},
+		{
+			name: "invalid rules are skipped",
+			raw:  "route=/a;route=/b,ratio=2;route=/c,ratio=x;span_kind=sever,ratio=1;colour=red,ratio=1;route,ratio=1;route=/ok,ratio=0.5",
+			want: []SamplingRule{{Route: "/ok", Ratio: 0.5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := new(loader).parseSamplingRules(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSamplingRules(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_SamplingRules(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `
+sampling:
+  rules:
+    - route: /api/v1/poll
+      ratio: 0.01
+    - span_kind: bogus
+      ratio: 1
+    - route: /checkout/**
+    - method: get
+      ratio: 0.5
+`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := []SamplingRule{{Route: "/api/v1/poll", Ratio: 0.01}, {Method: "GET", Ratio: 0.5}}
+	if !reflect.DeepEqual(cfg.SamplingRules, want) {
+		t.Errorf("SamplingRules = %+v, want %+v", cfg.SamplingRules, want)
+	}
+
+	os.Setenv("LAST9_SAMPLING_RULES", "")
+	defer os.Unsetenv("LAST9_SAMPLING_RULES")
+
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.SamplingRules != nil {
+		t.Errorf("SamplingRules = %+v, want nil (empty env var clears file rules)", cfg.SamplingRules)
+	}
+}
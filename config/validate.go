@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/last9/go-agent/internal/logging"
+)
+
+// Severity is how serious an Issue is.
+type Severity string
+
+// Supported severities.
+const (
+	// SeverityError marks a setting that is invalid and was ignored or
+	// replaced by its default.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a valid configuration that is likely a mistake,
+	// such as a missing endpoint.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a configuration problem found by Validate.
+type Issue struct {
+	Severity Severity
+	// Field is the environment variable or config file key at fault, e.g.
+	// "OTEL_TRACES_SAMPLER" or "destinations[1]".
+	Field   string
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+// ValidationError lists the errors that made a strict agent refuse to
+// start (LAST9_STRICT_CONFIG).
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.Field + ": " + issue.Message
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+// Validate returns the problems with cfg: settings that Load or LoadFile
+// found invalid and replaced by their defaults, and combinations that are
+// valid but unlikely to be intended. It returns nil when there are none.
+//
+// Example (fail a CI check on misconfiguration):
+//
+//	for _, issue := range config.Validate(config.Load()) {
+//	    if issue.Severity == config.SeverityError {
+//	        log.Fatal(issue)
+//	    }
+//	}
+func Validate(cfg *Config) []Issue {
+	issues := append([]Issue(nil), cfg.issues...)
+	add := func(severity Severity, field, format string, args ...any) {
+		issues = append(issues, Issue{Severity: severity, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.ServiceName == "" || cfg.ServiceName == "unknown-service" {
+		add(SeverityWarning, "OTEL_SERVICE_NAME", "Service name not set, telemetry is reported as %q", cfg.ServiceName)
+	}
+	if !cfg.hasEndpoint() && cfg.ResolvedExporter() == ExporterOTLP {
+		add(SeverityWarning, "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT not set - telemetry will not be exported")
+	}
+
+	if cfg.Sampler != "" && !contains(samplers, cfg.Sampler) {
+		add(SeverityError, "OTEL_TRACES_SAMPLER", "Unknown sampler %q (want %s), using always_on", cfg.Sampler, strings.Join(samplers, ", "))
+	}
+
+	for _, name := range cfg.Propagators {
+		if name = strings.ToLower(strings.TrimSpace(name)); !contains(propagators, name) {
+			add(SeverityError, "OTEL_PROPAGATORS", "Unknown propagator %q (want %s), ignoring", name, strings.Join(propagators, ", "))
+		}
+	}
+	for _, name := range cfg.ResourceDetectors {
+		if name = strings.ToLower(strings.TrimSpace(name)); !contains(resourceDetectors, name) {
+			add(SeverityError, "LAST9_RESOURCE_DETECTORS", "Unknown resource detector %q (want %s), ignoring", name, strings.Join(resourceDetectors, ", "))
+		}
+	}
+	return issues
+}
+
+// Supported values of the settings that Validate checks but Load passes
+// through unchanged.
+var (
+	samplers = []string{"always_on", "always_off", "traceidratio", "parentbased_always_on",
+		"parentbased_always_off", "parentbased_traceidratio", "ratelimited"}
+	propagators       = []string{"tracecontext", "baggage", "b3", "b3multi", "jaeger", "xray", "ottrace", "none"}
+	resourceDetectors = []string{"ec2", "ecs", "eks", "gce", "azure", "all"}
+)
+
+// loader records the problems found while loading a Config. A nil loader
+// only logs them.
+type loader struct {
+	issues []Issue
+}
+
+// invalid logs a setting that is ignored or replaced by its default, and
+// records it as an error.
+func (l *loader) invalid(field, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	logging.Warnf("%s", msg)
+	if l != nil {
+		l.issues = append(l.issues, Issue{Severity: SeverityError, Field: field, Message: msg})
+	}
+}
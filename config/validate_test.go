@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	keys := []string{
+		"OTEL_SERVICE_NAME", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_TRACES_SAMPLER",
+		"OTEL_TRACES_SAMPLER_ARG", "LAST9_TRACE_SAMPLE_RATE", "OTEL_PROPAGATORS", "LAST9_TAIL_SAMPLING_ENABLED",
+	}
+	unset := func() {
+		for _, k := range keys {
+			os.Unsetenv(k)
+		}
+	}
+	defer unset()
+
+	// with returns a valid environment plus extra.
+	with := func(extra map[string]string) map[string]string {
+		env := map[string]string{"OTEL_SERVICE_NAME": "checkout", "OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp.last9.io"}
+		for k, v := range extra {
+			env[k] = v
+		}
+		return env
+	}
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string // severity: field of each issue
+	}{
+		{"valid", with(nil), nil},
+		{"defaults", nil, []string{"warning: OTEL_SERVICE_NAME", "warning: OTEL_EXPORTER_OTLP_ENDPOINT"}},
+		{"invalid sample rate", with(map[string]string{"LAST9_TRACE_SAMPLE_RATE": "2"}), []string{"error: LAST9_TRACE_SAMPLE_RATE"}},
+		{"unknown sampler", with(map[string]string{"OTEL_TRACES_SAMPLER": "sometimes"}), []string{"error: OTEL_TRACES_SAMPLER"}},
+		{"invalid sampler arg", with(map[string]string{"OTEL_TRACES_SAMPLER": "traceidratio", "OTEL_TRACES_SAMPLER_ARG": "half"}), []string{"error: OTEL_TRACES_SAMPLER_ARG"}},
+		{"malformed header", with(map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "Authorization=Basic x,broken"}), []string{"error: OTEL_EXPORTER_OTLP_HEADERS"}},
+		{"unknown propagator", with(map[string]string{"OTEL_PROPAGATORS": "tracecontext,w3c"}), []string{"error: OTEL_PROPAGATORS"}},
+		{"invalid bool", with(map[string]string{"LAST9_TAIL_SAMPLING_ENABLED": "yes please"}), []string{"error: LAST9_TAIL_SAMPLING_ENABLED"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unset()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			var got []string
+			for _, issue := range Validate(Load()) {
+				got = append(got, string(issue.Severity)+": "+issue.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_FileIssues(t *testing.T) {
+	path := writeConfigFile(t, "agent.yaml", `service_name: checkout
+endpoint: https://otlp.last9.io
+sampling:
+  sample_rate: 1.5
+metric_views:
+  - instrument: orders
+`)
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	var fields []string
+	for _, issue := range Validate(cfg) {
+		fields = append(fields, issue.Field)
+	}
+	if want := []string{"sampling.sample_rate", "metric_views[0]"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Validate() fields = %q, want %q", fields, want)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Issues: []Issue{
+		{Severity: SeverityError, Field: "OTEL_TRACES_SAMPLER", Message: "Unknown sampler"},
+		{Severity: SeverityError, Field: "LAST9_EXPORTER", Message: "Unsupported exporter"},
+	}}
+	want := "invalid configuration: OTEL_TRACES_SAMPLER: Unknown sampler; LAST9_EXPORTER: Unsupported exporter"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MetricView changes how the instruments it matches are exported, for
+// shaping metrics emitted by dependencies such as otelhttp, otelsql, Kafka
+// and MongoDB. An instrument matched by several views is exported once per
+// view.
+type MetricView struct {
+	// Instrument matches instrument names; * matches any run of characters
+	// and ? a single one, e.g. "http.server.*". Default: any instrument.
+	Instrument string
+	// Scope matches the instrumentation scope name exactly, e.g.
+	// "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp".
+	// Default: any scope. Instrument or Scope is required.
+	Scope string
+	// Drop discards the matching instruments. It cannot be combined with the
+	// fields below.
+	Drop bool
+	// Name renames the instrument. Only valid when Instrument matches a
+	// single name, without wildcards.
+	Name string
+	// AttributeKeys keeps only these attributes, dropping the rest to reduce
+	// cardinality. Default: all attributes.
+	AttributeKeys []string
+	// Buckets sets the explicit bucket boundaries of histograms, in
+	// increasing order; other instruments the view matches are left as
+	// they are. Default: the instrument's own boundaries.
+	Buckets []float64
+}
+
+// Validate reports the first invalid field of v.
+func (v *MetricView) Validate() error {
+	if v.Instrument == "" && v.Scope == "" {
+		return fmt.Errorf("missing instrument or scope")
+	}
+	changes := v.Name != "" || len(v.AttributeKeys) > 0 || len(v.Buckets) > 0
+	if v.Drop && changes {
+		return fmt.Errorf("drop cannot be combined with name, attribute_keys or buckets")
+	}
+	if !v.Drop && !changes {
+		return fmt.Errorf("missing drop, name, attribute_keys or buckets")
+	}
+	if v.Name != "" && (v.Instrument == "" || strings.ContainsAny(v.Instrument, "*?")) {
+		return fmt.Errorf("name requires an instrument without wildcards")
+	}
+	if !sort.Float64sAreSorted(v.Buckets) {
+		return fmt.Errorf("buckets must be in increasing order")
+	}
+	for i := 1; i < len(v.Buckets); i++ {
+		if v.Buckets[i] == v.Buckets[i-1] {
+			return fmt.Errorf("duplicate bucket boundary %v", v.Buckets[i])
+		}
+	}
+	return nil
+}
+
+// validMetricViews returns the valid views of vs, logging and skipping the
+// rest. source names where they came from in the warning.
+func (l *loader) validMetricViews(vs []MetricView, source string) []MetricView {
+	var valid []MetricView
+	for i, v := range vs {
+		if err := v.Validate(); err != nil {
+			l.invalid(fmt.Sprintf("metric_views[%d]", i), "Invalid metric_views[%d] in %s: %v, skipping", i, source, err)
+			continue
+		}
+		valid = append(valid, v)
+	}
+	return valid
+}
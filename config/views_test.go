@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestMetricViewValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       MetricView
+		wantErr bool
+	}{
+		{"drop", MetricView{Instrument: "db.sql.*", Drop: true}, false},
+		{"scope only", MetricView{Scope: "otelhttp", AttributeKeys: []string{"http.route"}}, false},
+		{"rename", MetricView{Instrument: "orders", Name: "shop.orders"}, false},
+		{"buckets", MetricView{Instrument: "http.server.request.duration", Buckets: []float64{0.1, 0.5, 1}}, false},
+		{"no match", MetricView{Drop: true}, true},
+		{"no change", MetricView{Instrument: "orders"}, true},
+		{"drop and rename", MetricView{Instrument: "orders", Drop: true, Name: "x"}, true},
+		{"rename wildcard", MetricView{Instrument: "http.*", Name: "x"}, true},
+		{"rename scope", MetricView{Scope: "otelhttp", Name: "x"}, true},
+		{"unsorted buckets", MetricView{Instrument: "latency", Buckets: []float64{1, 0.5}}, true},
+		{"duplicate buckets", MetricView{Instrument: "latency", Buckets: []float64{0.5, 0.5}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.v.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/last9/go-agent/config"
+	"github.com/last9/go-agent/internal/console"
+	"github.com/last9/go-agent/internal/diskqueue"
+	"github.com/last9/go-agent/internal/otlpclient"
+	"github.com/last9/go-agent/internal/otlpfile"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// OTLP/HTTP signal paths appended to a base OTEL_EXPORTER_OTLP_ENDPOINT.
+const (
+	tracesURLPath  = "/v1/traces"
+	metricsURLPath = "/v1/metrics"
+	logsURLPath    = "/v1/logs"
+)
+
+// newTraceExporter creates the span exporter described by cfg: the console
+// printer, the OTLP/JSON file writer, or OTLP. Traces default to
+// http/protobuf when no protocol is configured.
+func newTraceExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	switch cfg.ResolvedExporter() {
+	case config.ExporterConsole:
+		return console.NewTraceExporter(os.Stderr), nil
+	case config.ExporterFile:
+		return otlpfile.NewTraceExporter(fileOptions(cfg))
+	}
+
+	protocol := cfg.Protocol
+	if protocol == "" {
+		protocol = config.ProtocolHTTPProtobuf
+	}
+	endpoint := signalEndpoint(cfg.Endpoint, cfg.TracesEndpoint, tracesURLPath, protocol, cfg.TracesInsecure)
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	if protocol == config.ProtocolGRPC {
+		var opts []otlptracegrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(endpoint))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.PersistentQueueDir != "" {
+			opts = append(opts, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	} else {
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.PersistentQueueDir != "" {
+			opts = append(opts, otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	}
+	if err != nil || cfg.PersistentQueueDir == "" {
+		return exporter, err
+	}
+
+	sender, err := otlpclient.New(otlpclient.Traces, protocol, queueEndpoint(endpoint, tracesURLPath, protocol, cfg.TracesInsecure), cfg.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("persistent queue: %w", err)
+	}
+	return diskqueue.NewTraceExporter(exporter, sender, queueOptions(cfg))
+}
+
+// newMetricExporter creates the metric exporter described by cfg: the
+// console printer, the OTLP/JSON file writer, or OTLP. Metrics default to
+// grpc when no protocol is configured.
+func newMetricExporter(ctx context.Context, cfg *config.Config) (metric.Exporter, error) {
+	switch cfg.ResolvedExporter() {
+	case config.ExporterConsole:
+		return console.NewMetricExporter(os.Stderr), nil
+	case config.ExporterFile:
+		return otlpfile.NewMetricExporter(fileOptions(cfg))
+	}
+
+	protocol := cfg.Protocol
+	if protocol == "" {
+		protocol = config.ProtocolGRPC
+	}
+	endpoint := signalEndpoint(cfg.Endpoint, cfg.MetricsEndpoint, metricsURLPath, protocol, cfg.MetricsInsecure)
+
+	var exporter metric.Exporter
+	var err error
+	if protocol == config.ProtocolHTTPProtobuf {
+		var opts []otlpmetrichttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(endpoint))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.PersistentQueueDir != "" {
+			opts = append(opts, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{Enabled: false}))
+		}
+		exporter, err = otlpmetrichttp.New(ctx, opts...)
+	} else {
+		var opts []otlpmetricgrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(endpoint))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.PersistentQueueDir != "" {
+			opts = append(opts, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{Enabled: false}))
+		}
+		exporter, err = otlpmetricgrpc.New(ctx, opts...)
+	}
+	if err != nil || cfg.PersistentQueueDir == "" {
+		return exporter, err
+	}
+
+	sender, err := otlpclient.New(otlpclient.Metrics, protocol, queueEndpoint(endpoint, metricsURLPath, protocol, cfg.MetricsInsecure), cfg.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("persistent queue: %w", err)
+	}
+	return diskqueue.NewMetricExporter(exporter, sender, queueOptions(cfg))
+}
+
+// newLogExporter creates the OTLP log exporter described by cfg.
+// Logs default to http/protobuf when no protocol is configured. The console
+// and file exporters discard log records, which the slog and zap handlers
+// already write locally.
+func newLogExporter(ctx context.Context, cfg *config.Config) (sdklog.Exporter, error) {
+	if cfg.ResolvedExporter() != config.ExporterOTLP {
+		return discardLogExporter{}, nil
+	}
+
+	protocol := cfg.Protocol
+	if protocol == "" {
+		protocol = config.ProtocolHTTPProtobuf
+	}
+	endpoint := signalEndpoint(cfg.Endpoint, cfg.LogsEndpoint, logsURLPath, protocol, cfg.LogsInsecure)
+
+	if protocol == config.ProtocolGRPC {
+		var opts []otlploggrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlploggrpc.WithEndpointURL(endpoint))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlploggrpc.WithHeaders(cfg.Headers))
+		}
+		return otlploggrpc.New(ctx, opts...)
+	}
+
+	var opts []otlploghttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlploghttp.WithEndpointURL(endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlploghttp.WithHeaders(cfg.Headers))
+	}
+	return otlploghttp.New(ctx, opts...)
+}
+
+// discardLogExporter drops log records.
+type discardLogExporter struct{}
+
+func (discardLogExporter) Export(context.Context, []sdklog.Record) error { return nil }
+func (discardLogExporter) Shutdown(context.Context) error                { return nil }
+func (discardLogExporter) ForceFlush(context.Context) error              { return nil }
+
+// queueEndpoint returns the URL the persistent queue replays to: the
+// exporter's endpoint, or the OTLP default for protocol when none is set.
+func queueEndpoint(endpoint, urlPath, protocol string, insecure bool) string {
+	if endpoint != "" {
+		return endpoint
+	}
+	if protocol == config.ProtocolGRPC {
+		return withScheme("localhost:4317", insecure)
+	}
+	return withScheme("localhost:4318", insecure) + urlPath
+}
+
+// queueOptions returns the persistent queue settings from cfg.
+func queueOptions(cfg *config.Config) diskqueue.Options {
+	return diskqueue.Options{
+		Dir:      cfg.PersistentQueueDir,
+		MaxBytes: cfg.PersistentQueueMaxBytes,
+		MaxAge:   cfg.PersistentQueueMaxAge,
+	}
+}
+
+// fileOptions returns the file exporter settings from cfg.
+func fileOptions(cfg *config.Config) otlpfile.Options {
+	return otlpfile.Options{
+		Dir:      cfg.FileExporterPath,
+		MaxBytes: cfg.FileExporterMaxBytes,
+		MaxFiles: int(cfg.FileExporterMaxFiles),
+	}
+}
+
+// destinationConfig returns a copy of cfg exporting over OTLP to d, so that
+// destinations share the primary exporters' construction. Destinations
+// default to http/protobuf for both signals and have no persistent queue.
+// They use TLS unless their endpoint has an http:// scheme.
+func destinationConfig(cfg *config.Config, d config.Destination) *config.Config {
+	dc := *cfg
+	dc.Exporter = config.ExporterOTLP
+	dc.Endpoint = d.Endpoint
+	dc.TracesEndpoint, dc.MetricsEndpoint, dc.LogsEndpoint = "", "", ""
+	dc.Headers = d.Headers
+	dc.Insecure, dc.TracesInsecure, dc.MetricsInsecure, dc.LogsInsecure = false, false, false, false
+	dc.Protocol = d.Protocol
+	if dc.Protocol == "" {
+		dc.Protocol = config.ProtocolHTTPProtobuf
+	}
+	dc.PersistentQueueDir = ""
+	return &dc
+}
+
+// signalEndpoint resolves the exporter URL for one signal. A per-signal
+// endpoint is used as-is; otherwise the base endpoint is used, with the
+// signal path appended for http/protobuf as the OTLP spec requires.
+// Endpoints without a scheme are treated as https, or http when insecure.
+// Returns "" when neither is set, leaving the exporter on its own defaults.
+func signalEndpoint(base, signal, urlPath, protocol string, insecure bool) string {
+	if signal != "" {
+		return withScheme(signal, insecure)
+	}
+	if base == "" {
+		return ""
+	}
+	base = withScheme(base, insecure)
+	if protocol == config.ProtocolHTTPProtobuf {
+		return strings.TrimSuffix(base, "/") + urlPath
+	}
+	return base
+}
+
+// withScheme prefixes endpoint with https://, or http:// when insecure, if
+// it has no scheme, so that "collector:4317" parses as a host rather than a
+// URL scheme. The exporters derive TLS from the scheme of the URL.
+func withScheme(endpoint string, insecure bool) string {
+	if strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	if insecure {
+		return "http://" + endpoint
+	}
+	return "https://" + endpoint
+}
+
+// selectorExporter overrides the temporality and aggregation an exporter
+// asks its reader for, so the configured preference applies to the console,
+// file and OTLP exporters alike.
+type selectorExporter struct {
+	metric.Exporter
+	temporality metric.TemporalitySelector
+	aggregation metric.AggregationSelector
+}
+
+func (e *selectorExporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return e.temporality(k)
+}
+
+func (e *selectorExporter) Aggregation(k metric.InstrumentKind) metric.Aggregation {
+	return e.aggregation(k)
+}
+
+// withMetricSelectors wraps exporter to apply cfg.MetricTemporality and
+// cfg.MetricHistogramAggregation. It returns exporter unchanged for the
+// defaults, cumulative temporality and explicit bucket histograms.
+func withMetricSelectors(exporter metric.Exporter, cfg *config.Config) metric.Exporter {
+	temporality := temporalitySelector(cfg.MetricTemporality)
+	exponential := cfg.MetricHistogramAggregation == config.HistogramAggregationExponential
+	if temporality == nil && !exponential {
+		return exporter
+	}
+	e := &selectorExporter{Exporter: exporter, temporality: exporter.Temporality, aggregation: exporter.Aggregation}
+	if temporality != nil {
+		e.temporality = temporality
+	}
+	if exponential {
+		e.aggregation = func(k metric.InstrumentKind) metric.Aggregation {
+			if k == metric.InstrumentKindHistogram {
+				// Defaults from the OpenTelemetry specification.
+				return metric.AggregationBase2ExponentialHistogram{MaxSize: 160, MaxScale: 20}
+			}
+			return exporter.Aggregation(k)
+		}
+	}
+	return e
+}
+
+// temporalitySelector implements the temporality preferences of
+// OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE. It returns nil for
+// cumulative, leaving the exporter's own selector in place. Up-down counters
+// stay cumulative under every preference.
+func temporalitySelector(preference string) metric.TemporalitySelector {
+	switch preference {
+	case config.MetricTemporalityDelta:
+		return func(k metric.InstrumentKind) metricdata.Temporality {
+			switch k {
+			case metric.InstrumentKindCounter, metric.InstrumentKindObservableCounter, metric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			}
+			return metricdata.CumulativeTemporality
+		}
+	case config.MetricTemporalityLowMemory:
+		return func(k metric.InstrumentKind) metricdata.Temporality {
+			switch k {
+			case metric.InstrumentKindCounter, metric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			}
+			return metricdata.CumulativeTemporality
+		}
+	}
+	return nil
+}
@@ -2,13 +2,12 @@
 package beego
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/beego/beego/v2/server/web"
 	"github.com/beego/beego/v2/server/web/context"
 	agent "github.com/last9/go-agent"
-	"go.opentelemetry.io/otel"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
@@ -52,8 +51,20 @@ func New() *web.HttpServer {
 //	app := web.NewHttpSever()
 //	app.InsertFilterChain("/*", beego.Middleware())
 func Middleware() func(next web.FilterFunc) web.FilterFunc {
-	tracer := otel.GetTracerProvider().Tracer(tracerName)
-	propagator := otel.GetTextMapPropagator()
+	return MiddlewareFor(nil)
+}
+
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider. A nil a uses the global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	app := web.NewHttpSever()
+//	app.InsertFilterChain("/*", beego.MiddlewareFor(orders))
+func MiddlewareFor(a *agent.Agent) func(next web.FilterFunc) web.FilterFunc {
+	tracer := a.TracerProvider().Tracer(tracerName)
+	propagator := a.Propagator()
 	serverKind := trace.WithSpanKind(trace.SpanKindServer)
 
 	return func(next web.FilterFunc) web.FilterFunc {
@@ -90,7 +101,7 @@ func Middleware() func(next web.FilterFunc) web.FilterFunc {
 func setupInstrumentation(app *web.HttpServer) {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for Beego middleware: %v (instrumentation will not be active)", err)
+			logging.Warnf("Failed to auto-start agent for Beego middleware: %v (instrumentation will not be active)", err)
 			return
 		}
 	}
@@ -66,7 +66,14 @@ func NewRouter() *chi.Mux {
 // such as adding it to a router BEFORE defining routes (though this won't
 // capture route patterns properly).
 func Middleware(router *chi.Mux) func(next http.Handler) http.Handler {
-	cfg := agent.GetConfig()
+	return MiddlewareFor(nil, router)
+}
+
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider and a's route exclusions apply. A nil a uses the
+// global agent without starting it.
+func MiddlewareFor(a *agent.Agent, router *chi.Mux) func(next http.Handler) http.Handler {
+	cfg := a.Config()
 	serviceName := "chi-service"
 	if cfg != nil {
 		serviceName = cfg.ServiceName
@@ -74,21 +81,21 @@ func Middleware(router *chi.Mux) func(next http.Handler) http.Handler {
 
 	return otelchi.Middleware(
 		serviceName,
-		buildOptions(router)...,
+		buildOptions(a, router)...,
 	)
 }
 
-// buildOptions returns otelchi options with route info and optional filter.
-func buildOptions(router *chi.Mux) []otelchi.Option {
+// buildOptions returns otelchi options with a's providers, route info and
+// optional filter.
+func buildOptions(a *agent.Agent, router *chi.Mux) []otelchi.Option {
 	opts := []otelchi.Option{
 		otelchi.WithChiRoutes(router),
+		otelchi.WithTracerProvider(a.TracerProvider()),
+		otelchi.WithPropagators(a.Propagator()),
 	}
-	rm := agent.GetRouteMatcher()
-	if !rm.IsEmpty() {
-		opts = append(opts, otelchi.WithFilter(func(r *http.Request) bool {
-			return !rm.ShouldExclude(r.URL.Path)
-		}))
-	}
+	opts = append(opts, otelchi.WithFilter(func(r *http.Request) bool {
+		return !a.RouteMatcher().ShouldExclude(r.URL.Path)
+	}))
 	return opts
 }
 
@@ -115,16 +122,16 @@ func ensureAgentStarted() {
 //	http.ListenAndServe(":8080", handler)
 func Use(router *chi.Mux) http.Handler {
 	ensureAgentStarted()
+	return UseFor(nil, router)
+}
 
-	cfg := agent.GetConfig()
-	serviceName := "chi-service"
-	if cfg != nil {
-		serviceName = cfg.ServiceName
-	}
-
-	middleware := otelchi.Middleware(
-		serviceName,
-		buildOptions(router)...,
-	)
-	return middleware(router)
+// UseFor is Use for an agent created with agent.New. A nil a uses the global
+// agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	handler := chiagent.UseFor(orders, r)
+func UseFor(a *agent.Agent, router *chi.Mux) http.Handler {
+	return MiddlewareFor(a, router)(router)
 }
@@ -2,10 +2,9 @@
 package echo
 
 import (
-	"log"
-
 	"github.com/labstack/echo/v4"
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
@@ -44,21 +43,32 @@ func New() *echo.Echo {
 //	e := echo.New()
 //	e.Use(echo.Middleware())
 func Middleware() echo.MiddlewareFunc {
-	cfg := agent.GetConfig()
+	return MiddlewareFor(nil)
+}
+
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider and a's route exclusions apply. A nil a uses the
+// global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	e := echo.New()
+//	e.Use(echo.MiddlewareFor(orders))
+func MiddlewareFor(a *agent.Agent) echo.MiddlewareFunc {
+	cfg := a.Config()
 	serviceName := "echo-service"
 	if cfg != nil {
 		serviceName = cfg.ServiceName
 	}
 
-	var opts []otelecho.Option
-	rm := agent.GetRouteMatcher()
-	if !rm.IsEmpty() {
-		opts = append(opts, otelecho.WithSkipper(func(c echo.Context) bool {
-			return rm.ShouldExclude(c.Request().URL.Path)
-		}))
-	}
-
-	return otelecho.Middleware(serviceName, opts...)
+	return otelecho.Middleware(serviceName,
+		otelecho.WithTracerProvider(a.TracerProvider()),
+		otelecho.WithPropagators(a.Propagator()),
+		otelecho.WithSkipper(func(c echo.Context) bool {
+			return a.RouteMatcher().ShouldExclude(c.Request().URL.Path)
+		}),
+	)
 }
 
 // setupInstrumentation adds Last9 telemetry to an Echo instance
@@ -66,7 +76,7 @@ func setupInstrumentation(e *echo.Echo) {
 	if !agent.IsInitialized() {
 		// Agent not initialized, try to start it
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for Echo middleware: %v (instrumentation will not be active)", err)
+			logging.Warnf("Failed to auto-start agent for Echo middleware: %v (instrumentation will not be active)", err)
 			return
 		}
 	}
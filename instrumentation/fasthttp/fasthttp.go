@@ -5,12 +5,11 @@ package fasthttp
 
 import (
 	"context"
-	"log"
 	"net/http"
 
 	agent "github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"github.com/valyala/fasthttp"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
 	"go.opentelemetry.io/otel/trace"
@@ -68,18 +67,28 @@ func (c headerCarrier) Keys() []string {
 func Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (instrumentation disabled)", err)
+			logging.Warnf("Failed to auto-start agent: %v (instrumentation disabled)", err)
 			return next
 		}
 	}
+	return MiddlewareFor(nil, next)
+}
 
-	tracer := otel.GetTracerProvider().Tracer(tracerName)
-	propagator := otel.GetTextMapPropagator()
-	rm := agent.GetRouteMatcher()
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider and a's route exclusions apply. A nil a uses the
+// global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	fasthttp.ListenAndServe(":8080", fasthttpagent.MiddlewareFor(orders, handler))
+func MiddlewareFor(a *agent.Agent, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	tracer := a.TracerProvider().Tracer(tracerName)
+	propagator := a.Propagator()
 
 	return func(ctx *fasthttp.RequestCtx) {
 		path := string(ctx.Path())
-		if !rm.IsEmpty() && rm.ShouldExclude(path) {
+		if a.RouteMatcher().ShouldExclude(path) {
 			next(ctx)
 			return
 		}
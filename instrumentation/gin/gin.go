@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
@@ -77,21 +78,32 @@ func Default() *gin.Engine {
 //	r := gin.New()
 //	r.Use(gin.Middleware())
 func Middleware() gin.HandlerFunc {
-	cfg := agent.GetConfig()
+	return MiddlewareFor(nil)
+}
+
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider and a's route exclusions apply. A nil a uses the
+// global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	r := gin.New()
+//	r.Use(ginagent.MiddlewareFor(orders))
+func MiddlewareFor(a *agent.Agent) gin.HandlerFunc {
+	cfg := a.Config()
 	serviceName := "gin-service"
 	if cfg != nil {
 		serviceName = cfg.ServiceName
 	}
 
-	var opts []otelgin.Option
-	rm := agent.GetRouteMatcher()
-	if !rm.IsEmpty() {
-		opts = append(opts, otelgin.WithFilter(func(r *http.Request) bool {
-			return !rm.ShouldExclude(r.URL.Path)
-		}))
-	}
-
-	return otelgin.Middleware(serviceName, opts...)
+	return otelgin.Middleware(serviceName,
+		otelgin.WithTracerProvider(a.TracerProvider()),
+		otelgin.WithPropagators(a.Propagator()),
+		otelgin.WithFilter(func(r *http.Request) bool {
+			return !a.RouteMatcher().ShouldExclude(r.URL.Path)
+		}),
+	)
 }
 
 // setupInstrumentation adds Last9 telemetry to a Gin engine
@@ -99,12 +111,7 @@ func setupInstrumentation(r *gin.Engine) {
 	if !agent.IsInitialized() {
 		// Agent not initialized, try to start it
 		if err := agent.Start(); err != nil {
-			// Log error but continue - instrumentation will be disabled
-			msg := "[Last9 Agent] Failed to auto-start: " + err.Error() + "\n"
-			if _, writeErr := gin.DefaultWriter.Write([]byte(msg)); writeErr != nil {
-				// Can't log the error anywhere, silent failure
-				return
-			}
+			logging.Warnf("Failed to auto-start agent for Gin middleware: %v (instrumentation will not be active)", err)
 			return
 		}
 	}
@@ -2,11 +2,11 @@
 package gorilla
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
@@ -45,21 +45,32 @@ func NewRouter() *mux.Router {
 //	r := mux.NewRouter()
 //	r.Use(gorilla.Middleware())
 func Middleware() mux.MiddlewareFunc {
-	cfg := agent.GetConfig()
+	return MiddlewareFor(nil)
+}
+
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider and a's route exclusions apply. A nil a uses the
+// global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	r := mux.NewRouter()
+//	r.Use(gorilla.MiddlewareFor(orders))
+func MiddlewareFor(a *agent.Agent) mux.MiddlewareFunc {
+	cfg := a.Config()
 	serviceName := "gorilla-service"
 	if cfg != nil {
 		serviceName = cfg.ServiceName
 	}
 
-	var opts []otelmux.Option
-	rm := agent.GetRouteMatcher()
-	if !rm.IsEmpty() {
-		opts = append(opts, otelmux.WithFilter(func(r *http.Request) bool {
-			return !rm.ShouldExclude(r.URL.Path)
-		}))
-	}
-
-	return otelmux.Middleware(serviceName, opts...)
+	return otelmux.Middleware(serviceName,
+		otelmux.WithTracerProvider(a.TracerProvider()),
+		otelmux.WithPropagators(a.Propagator()),
+		otelmux.WithFilter(func(r *http.Request) bool {
+			return !a.RouteMatcher().ShouldExclude(r.URL.Path)
+		}),
+	)
 }
 
 // setupInstrumentation adds Last9 telemetry to a Gorilla Mux router
@@ -67,7 +78,7 @@ func setupInstrumentation(r *mux.Router) {
 	if !agent.IsInitialized() {
 		// Agent not initialized, try to start it
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for Gorilla middleware: %v (instrumentation will not be active)", err)
+			logging.Warnf("Failed to auto-start agent for Gorilla middleware: %v (instrumentation will not be active)", err)
 			return
 		}
 	}
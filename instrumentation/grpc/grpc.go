@@ -4,11 +4,9 @@
 package grpc
 
 import (
-	"log"
-
 	agent "github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
 	googlegrpc "google.golang.org/grpc"
 )
 
@@ -32,15 +30,24 @@ import (
 func NewServer(opts ...googlegrpc.ServerOption) *googlegrpc.Server {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (returning non-instrumented server)", err)
+			logging.Warnf("Failed to auto-start agent: %v (returning non-instrumented server)", err)
 			return googlegrpc.NewServer(opts...)
 		}
 	}
+	return NewServerFor(nil, opts...)
+}
 
+// NewServerFor is NewServer for an agent created with agent.New: spans and
+// metrics go to a's providers. A nil a uses the global agent without
+// starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	s := grpcagent.NewServerFor(orders)
+func NewServerFor(a *agent.Agent, opts ...googlegrpc.ServerOption) *googlegrpc.Server {
 	serverOpts := []googlegrpc.ServerOption{
-		googlegrpc.StatsHandler(otelgrpc.NewServerHandler(
-			otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
-		)),
+		googlegrpc.StatsHandler(otelgrpc.NewServerHandler(handlerOptions(a)...)),
 	}
 	serverOpts = append(serverOpts, opts...)
 	return googlegrpc.NewServer(serverOpts...)
@@ -61,11 +68,23 @@ func NewServer(opts ...googlegrpc.ServerOption) *googlegrpc.Server {
 func NewClientDialOption() googlegrpc.DialOption {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (client tracing disabled)", err)
+			logging.Warnf("Failed to auto-start agent: %v (client tracing disabled)", err)
 		}
 	}
+	return NewClientDialOptionFor(nil)
+}
+
+// NewClientDialOptionFor is NewClientDialOption for an agent created with
+// agent.New. A nil a uses the global agent without starting it.
+func NewClientDialOptionFor(a *agent.Agent) googlegrpc.DialOption {
+	return googlegrpc.WithStatsHandler(otelgrpc.NewClientHandler(handlerOptions(a)...))
+}
 
-	return googlegrpc.WithStatsHandler(otelgrpc.NewClientHandler(
-		otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
-	))
+// handlerOptions returns the otelgrpc options using a's providers and propagator.
+func handlerOptions(a *agent.Agent) []otelgrpc.Option {
+	return []otelgrpc.Option{
+		otelgrpc.WithTracerProvider(a.TracerProvider()),
+		otelgrpc.WithMeterProvider(a.MeterProvider()),
+		otelgrpc.WithPropagators(a.Propagator()),
+	}
 }
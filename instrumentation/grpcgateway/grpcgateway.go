@@ -3,15 +3,14 @@
 package grpcgateway
 
 import (
-	"log"
 	"net/http"
 	"strings"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
-	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
 )
 
@@ -46,24 +45,34 @@ func isDefaultExcluded(path string) bool {
 	return false
 }
 
-// buildHTTPFilterOptions returns otelhttp options with a combined filter that
-// excludes default infra paths and any user-configured exclusions.
-func buildHTTPFilterOptions() []otelhttp.Option {
-	rm := agent.GetRouteMatcher()
+// buildHTTPFilterOptions returns otelhttp options with a's providers and a
+// combined filter that excludes default infra paths and any user-configured
+// exclusions.
+func buildHTTPFilterOptions(a *agent.Agent) []otelhttp.Option {
 	return []otelhttp.Option{
+		otelhttp.WithTracerProvider(a.TracerProvider()),
+		otelhttp.WithMeterProvider(a.MeterProvider()),
+		otelhttp.WithPropagators(a.Propagator()),
 		otelhttp.WithFilter(func(r *http.Request) bool {
 			path := r.URL.Path
 			if isDefaultExcluded(path) {
 				return false
 			}
-			if !rm.IsEmpty() {
-				return !rm.ShouldExclude(path)
-			}
-			return true
+			// Looked up per request so agent.Reload takes effect.
+			return !a.RouteMatcher().ShouldExclude(path)
 		}),
 	}
 }
 
+// grpcOptions returns the otelgrpc options using a's providers and propagator.
+func grpcOptions(a *agent.Agent) []otelgrpc.Option {
+	return []otelgrpc.Option{
+		otelgrpc.WithTracerProvider(a.TracerProvider()),
+		otelgrpc.WithMeterProvider(a.MeterProvider()),
+		otelgrpc.WithPropagators(a.Propagator()),
+	}
+}
+
 // NewGatewayMux creates a new grpc-gateway ServeMux.
 // This ServeMux handles gRPC-to-JSON transcoding, converting HTTP/JSON requests into gRPC calls.
 //
@@ -76,7 +85,7 @@ func buildHTTPFilterOptions() []otelhttp.Option {
 func NewGatewayMux(opts ...runtime.ServeMuxOption) *runtime.ServeMux {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (instrumentation disabled)", err)
+			logging.Warnf("Failed to auto-start agent: %v (instrumentation disabled)", err)
 		}
 	}
 
@@ -102,17 +111,20 @@ func NewGatewayMux(opts ...runtime.ServeMuxOption) *runtime.ServeMux {
 func NewGrpcServer(opts ...grpc.ServerOption) *grpc.Server {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (returning non-instrumented server)", err)
+			logging.Warnf("Failed to auto-start agent: %v (returning non-instrumented server)", err)
 			return grpc.NewServer(opts...)
 		}
 	}
+	return NewGrpcServerFor(nil, opts...)
+}
 
+// NewGrpcServerFor is NewGrpcServer for an agent created with agent.New.
+// A nil a uses the global agent without starting it.
+func NewGrpcServerFor(a *agent.Agent, opts ...grpc.ServerOption) *grpc.Server {
 	// Add OpenTelemetry stats handler for tracing
-	// Explicitly pass the global propagator to ensure context propagation works
+	// Explicitly pass the agent's propagator to ensure context propagation works
 	interceptorOpts := []grpc.ServerOption{
-		grpc.StatsHandler(otelgrpc.NewServerHandler(
-			otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
-		)),
+		grpc.StatsHandler(otelgrpc.NewServerHandler(grpcOptions(a)...)),
 	}
 
 	// Combine with user-provided options
@@ -143,12 +155,17 @@ func NewGrpcServer(opts ...grpc.ServerOption) *grpc.Server {
 func WrapHTTPMux(mux *http.ServeMux, serviceName string) http.Handler {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (returning unwrapped handler)", err)
+			logging.Warnf("Failed to auto-start agent: %v (returning unwrapped handler)", err)
 			return mux
 		}
 	}
+	return WrapHTTPMuxFor(nil, mux, serviceName)
+}
 
-	opts := buildHTTPFilterOptions()
+// WrapHTTPMuxFor is WrapHTTPMux for an agent created with agent.New.
+// A nil a uses the global agent without starting it.
+func WrapHTTPMuxFor(a *agent.Agent, mux *http.ServeMux, serviceName string) http.Handler {
+	opts := buildHTTPFilterOptions(a)
 	return otelhttp.NewHandler(mux, serviceName, opts...)
 }
 
@@ -166,8 +183,12 @@ func WrapHTTPMux(mux *http.ServeMux, serviceName string) http.Handler {
 //	    grpcgateway.NewDialOption(),
 //	)
 func NewDialOption() grpc.DialOption {
+	return NewDialOptionFor(nil)
+}
+
+// NewDialOptionFor is NewDialOption for an agent created with agent.New.
+// A nil a uses the global agent.
+func NewDialOptionFor(a *agent.Agent) grpc.DialOption {
 	// Use StatsHandler with explicit propagator for context propagation
-	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(
-		otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
-	))
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(grpcOptions(a)...))
 }
@@ -51,60 +51,78 @@ import (
 // Middleware returns an http.Handler middleware that captures request/response
 // bodies onto the active OTel span as http.request.body and http.response.body.
 //
-// Config is read once at construction time from agent.GetConfig().
+// Config is read from agent.GetConfig() on every request, so settings changed
+// by agent.Reload take effect immediately.
 // No-ops when LAST9_BODY_CAPTURE_ENABLED is false (default) or no span is recording.
 func Middleware(next http.Handler) http.Handler {
-	return newMiddleware(next, agent.GetConfig())
+	return MiddlewareFor(nil, next)
 }
 
-// newMiddleware is the testable core; Middleware delegates here.
+// MiddlewareFor is Middleware for an agent created with agent.New: body
+// capture follows a's config. A nil a uses the global agent.
+func MiddlewareFor(a *agent.Agent, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveWithCapture(w, r, next, a.Config())
+	})
+}
+
+// newMiddleware is the testable core with a fixed config.
 func newMiddleware(next http.Handler, cfg *config.Config) http.Handler {
 	if cfg == nil || !cfg.BodyCaptureEnabled {
 		return next
 	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveWithCapture(w, r, next, cfg)
+	})
+}
+
+// serveWithCapture serves r with next, capturing bodies as configured by cfg.
+func serveWithCapture(w http.ResponseWriter, r *http.Request, next http.Handler, cfg *config.Config) {
+	if cfg == nil || !cfg.BodyCaptureEnabled {
+		next.ServeHTTP(w, r)
+		return
+	}
 
 	maxBytes := cfg.BodyCaptureMaxBytes
 	onErrorOnly := cfg.BodyCaptureOnErrorOnly
 	contentTypes := cfg.BodyCaptureContentTypes
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Capture request body via TeeReader — handler still reads the original stream.
-		var reqBodyBuf *limitedBuffer
-		if r.Body != nil && isAllowedContentType(r.Header.Get("Content-Type"), contentTypes) {
-			reqBodyBuf = newLimitedBuffer(maxBytes)
-			r.Body = io.NopCloser(io.TeeReader(r.Body, reqBodyBuf))
-		}
+	// Capture request body via TeeReader — handler still reads the original stream.
+	var reqBodyBuf *limitedBuffer
+	if r.Body != nil && isAllowedContentType(r.Header.Get("Content-Type"), contentTypes) {
+		reqBodyBuf = newLimitedBuffer(maxBytes)
+		r.Body = io.NopCloser(io.TeeReader(r.Body, reqBodyBuf))
+	}
 
-		// Wrap response writer. When onErrorOnly=true, buf is nil until WriteHeader
-		// receives a status >= 400 — no allocation on the happy path.
-		rw := &captureResponseWriter{
-			ResponseWriter: w,
-			maxBytes:       maxBytes,
-			contentTypes:   contentTypes,
-			onErrorOnly:    onErrorOnly,
-			status:         http.StatusOK,
-		}
+	// Wrap response writer. When onErrorOnly=true, buf is nil until WriteHeader
+	// receives a status >= 400 — no allocation on the happy path.
+	rw := &captureResponseWriter{
+		ResponseWriter: w,
+		maxBytes:       maxBytes,
+		contentTypes:   contentTypes,
+		onErrorOnly:    onErrorOnly,
+		status:         http.StatusOK,
+	}
 
-		next.ServeHTTP(rw, r)
+	next.ServeHTTP(rw, r)
 
-		if onErrorOnly && rw.status < 400 {
-			return
-		}
+	if onErrorOnly && rw.status < 400 {
+		return
+	}
 
-		span := trace.SpanFromContext(r.Context())
-		if !span.IsRecording() {
-			return
-		}
+	span := trace.SpanFromContext(r.Context())
+	if !span.IsRecording() {
+		return
+	}
 
-		if reqBodyBuf != nil && reqBodyBuf.Len() > 0 {
-			span.SetAttributes(attribute.String("http.request.body", reqBodyBuf.String()))
-		}
+	if reqBodyBuf != nil && reqBodyBuf.Len() > 0 {
+		span.SetAttributes(attribute.String("http.request.body", reqBodyBuf.String()))
+	}
 
-		// Use Content-Type snapshotted at WriteHeader time, not post-ServeHTTP header map.
-		if isAllowedContentType(rw.respContentType, contentTypes) && rw.buf != nil && rw.buf.Len() > 0 {
-			span.SetAttributes(attribute.String("http.response.body", rw.buf.String()))
-		}
-	})
+	// Use Content-Type snapshotted at WriteHeader time, not post-ServeHTTP header map.
+	if isAllowedContentType(rw.respContentType, contentTypes) && rw.buf != nil && rw.buf.Len() > 0 {
+		span.SetAttributes(attribute.String("http.response.body", rw.buf.String()))
+	}
 }
 
 // captureResponseWriter wraps http.ResponseWriter to record status code and body.
@@ -1,12 +1,14 @@
 package httpcapture
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/last9/go-agent"
 	"github.com/last9/go-agent/config"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -457,3 +459,27 @@ func TestMiddleware_SpanAttributes(t *testing.T) {
 		t.Errorf("http.response.body = %q, want %q", v.AsString(), `{"result":42}`)
 	}
 }
+
+func TestMiddlewareFor(t *testing.T) {
+	rec := setupTracer(t)
+
+	a, err := agent.New(agent.WithServiceName("capture-test"), agent.WithExporter(config.ExporterConsole), agent.WithBodyCapture(true))
+	if err != nil {
+		t.Fatalf("agent.New() failed: %v", err)
+	}
+	defer a.Shutdown(context.Background()) //nolint:errcheck
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)    //nolint:errcheck
+		w.Write([]byte("ok")) //nolint:errcheck
+	})
+
+	mw := handlerWithSpan(MiddlewareFor(a, inner))
+	req := httptest.NewRequest("POST", "/", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	mw.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := spanAttrs(rec)["http.request.body"]; got != "hello" {
+		t.Errorf("http.request.body = %q, want %q (capture enabled on the agent, not globally)", got, "hello")
+	}
+}
@@ -2,12 +2,11 @@
 package iris
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/kataras/iris/v12"
 	agent "github.com/last9/go-agent"
-	"go.opentelemetry.io/otel"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
@@ -47,15 +46,27 @@ func New() *iris.Application {
 //	app := iris.New()
 //	app.Use(irisagent.Middleware())
 func Middleware() iris.Handler {
-	tracer := otel.GetTracerProvider().Tracer(tracerName)
-	propagator := otel.GetTextMapPropagator()
-	rm := agent.GetRouteMatcher()
+	return MiddlewareFor(nil)
+}
+
+// MiddlewareFor is Middleware for an agent created with agent.New: spans go
+// to a's tracer provider and a's route exclusions apply. A nil a uses the
+// global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	app := iris.New()
+//	app.Use(irisagent.MiddlewareFor(orders))
+func MiddlewareFor(a *agent.Agent) iris.Handler {
+	tracer := a.TracerProvider().Tracer(tracerName)
+	propagator := a.Propagator()
 
 	return func(ctx iris.Context) {
 		r := ctx.Request()
 		path := r.URL.Path
 
-		if !rm.IsEmpty() && rm.ShouldExclude(path) {
+		if a.RouteMatcher().ShouldExclude(path) {
 			ctx.Next()
 			return
 		}
@@ -95,7 +106,7 @@ func Middleware() iris.Handler {
 func setupInstrumentation(app *iris.Application) {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for Iris middleware: %v (instrumentation will not be active)", err)
+			logging.Warnf("Failed to auto-start agent for Iris middleware: %v (instrumentation will not be active)", err)
 			return
 		}
 	}
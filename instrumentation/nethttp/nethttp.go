@@ -22,14 +22,17 @@
 //	mux := http.NewServeMux()
 //	mux.HandleFunc("/users", usersHandler)
 //	http.ListenAndServe(":8080", nethttp.WrapHandler(mux))
+//
+// Each of these reports to the global agent. HandlerFor and WrapHandlerFor
+// report to an agent created with agent.New instead.
 package nethttp
 
 import (
 	"context"
-	"log"
 	"net/http"
 
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -47,12 +50,23 @@ import (
 //	http.Handle("/users", userHandler)
 func Handler(h http.Handler, operation string) http.Handler {
 	ensureAgentStarted()
+	return HandlerFor(nil, h, operation)
+}
 
+// HandlerFor is Handler for an agent created with agent.New: spans and
+// metrics go to a's providers and a's route exclusions apply. A nil a uses
+// the global agent without starting it.
+//
+// Example:
+//
+//	orders, _ := agent.New(agent.WithServiceName("orders"))
+//	http.Handle("/orders", nethttp.HandlerFor(orders, ordersHandler, "/orders"))
+func HandlerFor(a *agent.Agent, h http.Handler, operation string) http.Handler {
 	if operation == "" {
 		operation = "HTTP"
 	}
 
-	return otelhttp.NewHandler(h, operation, buildOTelOptions()...)
+	return otelhttp.NewHandler(h, operation, buildOTelOptions(a)...)
 }
 
 // HandlerFunc wraps an http.HandlerFunc with OpenTelemetry instrumentation.
@@ -84,7 +98,13 @@ func HandlerFunc(f http.HandlerFunc, operation string) http.Handler {
 //	http.ListenAndServe(":8080", nethttp.WrapHandler(mux))
 func WrapHandler(h http.Handler) http.Handler {
 	ensureAgentStarted()
-	return otelhttp.NewHandler(h, "", buildOTelOptions()...)
+	return WrapHandlerFor(nil, h)
+}
+
+// WrapHandlerFor is WrapHandler for an agent created with agent.New.
+// A nil a uses the global agent without starting it.
+func WrapHandlerFor(a *agent.Agent, h http.Handler) http.Handler {
+	return otelhttp.NewHandler(h, "", buildOTelOptions(a)...)
 }
 
 // ServeMux is an instrumented version of http.ServeMux.
@@ -241,23 +261,23 @@ func InjectContext(ctx context.Context, r *http.Request) {
 	propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
 }
 
-// buildOTelOptions returns common otelhttp options: propagator, server name, and route filter.
-func buildOTelOptions() []otelhttp.Option {
+// buildOTelOptions returns common otelhttp options for a: providers,
+// propagator, server name, and route filter.
+func buildOTelOptions(a *agent.Agent) []otelhttp.Option {
 	opts := []otelhttp.Option{
-		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
+		otelhttp.WithTracerProvider(a.TracerProvider()),
+		otelhttp.WithMeterProvider(a.MeterProvider()),
+		otelhttp.WithPropagators(a.Propagator()),
 	}
 
-	cfg := agent.GetConfig()
+	cfg := a.Config()
 	if cfg != nil {
 		opts = append(opts, otelhttp.WithServerName(cfg.ServiceName))
 	}
 
-	rm := agent.GetRouteMatcher()
-	if !rm.IsEmpty() {
-		opts = append(opts, otelhttp.WithFilter(func(r *http.Request) bool {
-			return !rm.ShouldExclude(r.URL.Path)
-		}))
-	}
+	opts = append(opts, otelhttp.WithFilter(func(r *http.Request) bool {
+		return !a.RouteMatcher().ShouldExclude(r.URL.Path)
+	}))
 
 	return opts
 }
@@ -266,7 +286,7 @@ func buildOTelOptions() []otelhttp.Option {
 func ensureAgentStarted() {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for net/http: %v", err)
+			logging.Warnf("Failed to auto-start agent for net/http: %v", err)
 		}
 	}
 }
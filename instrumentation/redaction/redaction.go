@@ -0,0 +1,356 @@
+// Package redaction provides a SpanProcessor that removes personal data and
+// secrets from span attributes, event attributes and status descriptions
+// before spans reach the exporters.
+//
+// Attribute values are scanned by detectors — built-in ones for email
+// addresses, credit card numbers (Luhn-checked), JWTs, AWS keys and IP
+// addresses, plus user-supplied regular expressions — and every match is
+// masked, hashed, or causes the attribute to be dropped. Attributes listed
+// in DenyKeys are redacted in full regardless of content; attributes listed
+// in AllowKeys are never scanned.
+//
+// Spans are redacted when they end, so values set at any point of a span's
+// life, such as captured HTTP bodies or db.statement, are covered. The
+// processor wraps the processor that exports spans:
+//
+//	p := redaction.New(sdktrace.NewBatchSpanProcessor(exporter), redaction.Options{
+//	    Detectors: []string{redaction.Email, redaction.CreditCard},
+//	    DenyKeys:  []string{"enduser.id"},
+//	})
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p))
+package redaction
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Names of the built-in detectors.
+const (
+	Email      = "email"
+	CreditCard = "credit_card"
+	JWT        = "jwt"
+	AWSKey     = "aws_key"
+	IP         = "ip"
+)
+
+// Mode selects what happens to sensitive data.
+type Mode string
+
+// Supported modes.
+const (
+	// Mask replaces each match, or a denied attribute's whole value, with
+	// "[REDACTED]".
+	Mask Mode = "mask"
+	// Hash replaces each match with a truncated HMAC-SHA256 of it under
+	// Options.HashKey, so equal values can still be correlated across spans.
+	// Without a key it is a plain SHA-256, which supports correlation only:
+	// values from a small space, such as card numbers, can be recovered by
+	// hashing every candidate.
+	Hash Mode = "hash"
+	// Drop removes attributes that contain a match or are denied.
+	Drop Mode = "drop"
+)
+
+// masked replaces redacted values, except in Hash mode.
+const masked = "[REDACTED]"
+
+// Options configures a Processor.
+type Options struct {
+	// Detectors names the built-in detectors to run: Email, CreditCard, JWT,
+	// AWSKey and IP. Unknown names are ignored. Default: none.
+	Detectors []string
+
+	// Patterns are additional expressions whose matches are redacted.
+	Patterns []*regexp.Regexp
+
+	// DenyKeys are attribute keys whose values are always redacted in full.
+	DenyKeys []string
+
+	// AllowKeys are attribute keys that are never scanned, such as
+	// server.address when IP detection is enabled.
+	AllowKeys []string
+
+	// Mode is Mask, Hash or Drop. Default: Mask.
+	Mode Mode
+
+	// HashKey is the secret key of the HMAC in Hash mode. Keep it out of
+	// the telemetry backend. Default: none (unkeyed SHA-256).
+	HashKey []byte
+}
+
+// detector finds one kind of sensitive data.
+type detector struct {
+	re *regexp.Regexp
+	// hint, when set, must occur in a value for re to match, to skip the
+	// expression on most values.
+	hint string
+	// valid, when set, confirms a match of re.
+	valid func(string) bool
+}
+
+// builtin are the built-in detectors by name.
+var builtin = map[string]detector{
+	Email: {
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		hint: "@",
+	},
+	CreditCard: {
+		re:    regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		valid: luhn,
+	},
+	JWT: {
+		re:   regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+		hint: "eyJ",
+	},
+	AWSKey: {
+		// Access key IDs, and secret keys when labelled as such.
+		re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b|(?i:aws_secret_access_key)["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+	},
+	IP: {
+		re:    regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|\b[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{0,4}){2,7}`),
+		valid: isIP,
+	},
+}
+
+// Processor is a SpanProcessor that redacts spans before passing them to
+// the next processor.
+type Processor struct {
+	next      sdktrace.SpanProcessor
+	detectors []detector
+	deny      map[attribute.Key]bool
+	allow     map[attribute.Key]bool
+	mode      Mode
+	hashKey   []byte
+}
+
+var _ sdktrace.SpanProcessor = (*Processor)(nil)
+
+// New returns a Processor that passes redacted spans to next.
+func New(next sdktrace.SpanProcessor, opts Options) *Processor {
+	p := &Processor{
+		next:    next,
+		deny:    keySet(opts.DenyKeys),
+		allow:   keySet(opts.AllowKeys),
+		mode:    opts.Mode,
+		hashKey: opts.HashKey,
+	}
+	if p.mode != Hash && p.mode != Drop {
+		p.mode = Mask
+	}
+	for _, name := range opts.Detectors {
+		if d, ok := builtin[name]; ok {
+			p.detectors = append(p.detectors, d)
+		}
+	}
+	for _, re := range opts.Patterns {
+		p.detectors = append(p.detectors, detector{re: re})
+	}
+	return p
+}
+
+func keySet(keys []string) map[attribute.Key]bool {
+	set := make(map[attribute.Key]bool, len(keys))
+	for _, k := range keys {
+		set[attribute.Key(k)] = true
+	}
+	return set
+}
+
+// OnStart implements sdktrace.SpanProcessor.
+func (p *Processor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd passes s to the next processor with its sensitive data redacted.
+func (p *Processor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.next.OnEnd(p.redactSpan(s))
+}
+
+// Shutdown implements sdktrace.SpanProcessor.
+func (p *Processor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush implements sdktrace.SpanProcessor.
+func (p *Processor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// redactedSpan overrides the parts of a span that were redacted.
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	attrs  []attribute.KeyValue
+	events []sdktrace.Event
+	status sdktrace.Status
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue { return s.attrs }
+func (s *redactedSpan) Events() []sdktrace.Event         { return s.events }
+func (s *redactedSpan) Status() sdktrace.Status          { return s.status }
+
+// redactSpan returns s unchanged when nothing in it needs redacting, and a
+// wrapper holding redacted copies otherwise.
+func (p *Processor) redactSpan(s sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	attrs, changed := p.redactAttributes(s.Attributes())
+
+	events := s.Events()
+	var eventsCopied bool
+	for i, e := range events {
+		eventAttrs, eventChanged := p.redactAttributes(e.Attributes)
+		if !eventChanged {
+			continue
+		}
+		if !eventsCopied {
+			events = append([]sdktrace.Event(nil), events...)
+			eventsCopied = true
+		}
+		events[i].Attributes = eventAttrs
+	}
+
+	// A status description cannot be dropped on its own, so in Drop mode
+	// its matches are masked.
+	status := s.Status()
+	if description, ok := p.redactString(status.Description); ok {
+		status.Description = description
+	} else if !changed && !eventsCopied {
+		return s
+	}
+	return &redactedSpan{ReadOnlySpan: s, attrs: attrs, events: events, status: status}
+}
+
+// redactAttributes returns attrs with sensitive values redacted, and
+// whether anything changed. attrs itself is never modified.
+func (p *Processor) redactAttributes(attrs []attribute.KeyValue) ([]attribute.KeyValue, bool) {
+	var out []attribute.KeyValue
+	for i, kv := range attrs {
+		redacted, keep, changed := p.redactAttribute(kv)
+		if !changed {
+			if out != nil {
+				out = append(out, kv)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]attribute.KeyValue, 0, len(attrs)), attrs[:i]...)
+		}
+		if keep {
+			out = append(out, redacted)
+		}
+	}
+	if out == nil {
+		return attrs, false
+	}
+	return out, true
+}
+
+// redactAttribute returns kv redacted, whether to keep it, and whether it
+// changed.
+func (p *Processor) redactAttribute(kv attribute.KeyValue) (attribute.KeyValue, bool, bool) {
+	if p.allow[kv.Key] {
+		return kv, true, false
+	}
+	if p.deny[kv.Key] {
+		switch p.mode {
+		case Drop:
+			return kv, false, true
+		case Hash:
+			return kv.Key.String(hash(p.hashKey, kv.Value.Emit())), true, true
+		default:
+			return kv.Key.String(masked), true, true
+		}
+	}
+
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		v, changed := p.redactString(kv.Value.AsString())
+		if !changed {
+			return kv, true, false
+		}
+		return kv.Key.String(v), p.mode != Drop, true
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		var changed bool
+		for i, v := range values {
+			if r, ok := p.redactString(v); ok {
+				values[i] = r
+				changed = true
+			}
+		}
+		if !changed {
+			return kv, true, false
+		}
+		return kv.Key.StringSlice(values), p.mode != Drop, true
+	}
+	return kv, true, false
+}
+
+// redactString returns v with every detector match replaced, and whether
+// anything matched.
+func (p *Processor) redactString(v string) (string, bool) {
+	var changed bool
+	for _, d := range p.detectors {
+		if d.hint != "" && !strings.Contains(v, d.hint) {
+			continue
+		}
+		v = d.re.ReplaceAllStringFunc(v, func(match string) string {
+			if d.valid != nil && !d.valid(match) {
+				return match
+			}
+			changed = true
+			if p.mode == Hash {
+				return hash(p.hashKey, match)
+			}
+			return masked
+		})
+	}
+	return v, changed
+}
+
+// hash returns a short, stable digest of v: an HMAC-SHA256 under key, or a
+// SHA-256 when key is empty.
+func hash(key []byte, v string) string {
+	if len(key) == 0 {
+		sum := sha256.Sum256([]byte(v))
+		return "[sha256:" + hex.EncodeToString(sum[:8]) + "]"
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(v))
+	return "[hmac:" + hex.EncodeToString(mac.Sum(nil)[:8]) + "]"
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum used by
+// payment card numbers.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// isIP reports whether s is an IPv4 or IPv6 address, ruling out version
+// numbers and times that the IP expression also matches.
+func isIP(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
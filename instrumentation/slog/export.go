@@ -0,0 +1,212 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// scopeName is the instrumentation scope of log records shipped by this package.
+const scopeName = "github.com/last9/go-agent/instrumentation/slog"
+
+// ExportHandler is an slog.Handler that ships every record to Last9 through
+// the OpenTelemetry LoggerProvider started by agent.Start.
+//
+// Records are emitted with the log call's context, so trace and span IDs are
+// attached natively as OTLP log record fields rather than as attributes.
+// Attributes inside groups are flattened into dotted keys ("req.method").
+//
+// ExportHandler produces no local output. To keep writing to stdout as well,
+// set Options.Export on a Handler instead.
+type ExportHandler struct {
+	logger    otellog.Logger
+	level     slog.Leveler
+	addSource bool
+	replace   func(groups []string, a slog.Attr) slog.Attr
+	groups    []string
+	attrs     []otellog.KeyValue
+}
+
+// Compile-time assertion that ExportHandler implements slog.Handler.
+var _ slog.Handler = (*ExportHandler)(nil)
+
+// NewExportHandler creates an ExportHandler. handlerOpts controls the minimum
+// level, source location and attribute rewriting as it does for the standard
+// library handlers; opts selects the LoggerProvider. Both may be nil.
+//
+// Example:
+//
+//	logger := slog.New(slogagent.NewExportHandler(nil, nil))
+//	logger.InfoContext(ctx, "order placed", "order_id", id)
+func NewExportHandler(handlerOpts *slog.HandlerOptions, opts *Options) *ExportHandler {
+	h := &ExportHandler{logger: opts.logger()}
+	if handlerOpts != nil {
+		h.level = handlerOpts.Level
+		h.addSource = handlerOpts.AddSource
+		h.replace = handlerOpts.ReplaceAttr
+	}
+	return h
+}
+
+// logger returns the OTel logger for the configured provider, falling back to
+// the global provider installed by agent.Start.
+func (o *Options) logger() otellog.Logger {
+	if o != nil && o.LoggerProvider != nil {
+		return o.LoggerProvider.Logger(scopeName)
+	}
+	return global.Logger(scopeName)
+}
+
+// Enabled implements slog.Handler. It reports whether level meets the
+// configured minimum and the LoggerProvider would emit at that severity.
+func (h *ExportHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.level != nil {
+		minLevel = h.level.Level()
+	}
+	if level < minLevel {
+		return false
+	}
+	return h.logger.Enabled(ctx, otellog.EnabledParameters{Severity: severity(level)})
+}
+
+// Handle implements slog.Handler. It converts r to an OTel log record and
+// emits it with ctx.
+func (h *ExportHandler) Handle(ctx context.Context, r slog.Record) error {
+	var rec otellog.Record
+	rec.SetTimestamp(r.Time)
+	rec.SetObservedTimestamp(time.Now())
+	rec.SetBody(otellog.StringValue(r.Message))
+	rec.SetSeverity(severity(r.Level))
+	rec.SetSeverityText(r.Level.String())
+
+	kvs := make([]otellog.KeyValue, 0, len(h.attrs)+r.NumAttrs()+3)
+	kvs = append(kvs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = h.appendAttr(kvs, h.groups, a)
+		return true
+	})
+	if h.addSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		kvs = append(kvs,
+			otellog.String(string(semconv.CodeFunctionKey), frame.Function),
+			otellog.String(string(semconv.CodeFilepathKey), frame.File),
+			otellog.Int(string(semconv.CodeLineNumberKey), frame.Line),
+		)
+	}
+	rec.AddAttributes(kvs...)
+
+	h.logger.Emit(ctx, rec)
+	return nil
+}
+
+// WithAttrs implements slog.Handler. The attributes are converted once and
+// attached to every subsequent record.
+func (h *ExportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = make([]otellog.KeyValue, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(h2.attrs, h.attrs)
+	for _, a := range attrs {
+		h2.attrs = h2.appendAttr(h2.attrs, h.groups, a)
+	}
+	return &h2
+}
+
+// WithGroup implements slog.Handler. Subsequent attributes are prefixed with
+// the group name.
+func (h *ExportHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+// appendAttr flattens a into kvs under the given groups, applying ReplaceAttr
+// and dropping empty attributes and groups as the slog.Handler contract requires.
+func (h *ExportHandler) appendAttr(kvs []otellog.KeyValue, groups []string, a slog.Attr) []otellog.KeyValue {
+	a.Value = a.Value.Resolve()
+	if h.replace != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.replace(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			kvs = h.appendAttr(kvs, groups, ga)
+		}
+		return kvs
+	}
+
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
+	return append(kvs, otellog.KeyValue{Key: key, Value: convertValue(a.Value)})
+}
+
+// convertValue maps a resolved slog.Value to its OTel log equivalent.
+// Durations are recorded in nanoseconds and times as Unix nanoseconds.
+func convertValue(v slog.Value) otellog.Value {
+	switch v.Kind() {
+	case slog.KindBool:
+		return otellog.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return otellog.Int64Value(int64(v.Duration()))
+	case slog.KindFloat64:
+		return otellog.Float64Value(v.Float64())
+	case slog.KindInt64:
+		return otellog.Int64Value(v.Int64())
+	case slog.KindString:
+		return otellog.StringValue(v.String())
+	case slog.KindTime:
+		return otellog.Int64Value(v.Time().UnixNano())
+	case slog.KindUint64:
+		u := v.Uint64()
+		if u > math.MaxInt64 {
+			return otellog.StringValue(strconv.FormatUint(u, 10))
+		}
+		return otellog.Int64Value(int64(u))
+	default:
+		switch x := v.Any().(type) {
+		case error:
+			return otellog.StringValue(x.Error())
+		case []byte:
+			return otellog.BytesValue(x)
+		default:
+			return otellog.StringValue(fmt.Sprintf("%+v", x))
+		}
+	}
+}
+
+// severity maps an slog.Level onto the OTel severity range, so that
+// Debug, Info, Warn and Error land on DEBUG, INFO, WARN and ERROR.
+func severity(level slog.Level) otellog.Severity {
+	s := otellog.Severity(level + 9)
+	switch {
+	case s < otellog.SeverityTrace1:
+		return otellog.SeverityTrace1
+	case s > otellog.SeverityFatal4:
+		return otellog.SeverityFatal4
+	}
+	return s
+}
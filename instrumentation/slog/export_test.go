@@ -0,0 +1,251 @@
+package slog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	slogagent "github.com/last9/go-agent/instrumentation/slog"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// memoryExporter records exported log records for inspection.
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) all() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]sdklog.Record(nil), e.records...)
+}
+
+// newExportOptions returns Options that export synchronously to a memoryExporter.
+func newExportOptions(t *testing.T) (*slogagent.Options, *memoryExporter) {
+	t.Helper()
+	exp := &memoryExporter{}
+	lp := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+	t.Cleanup(func() { _ = lp.Shutdown(context.Background()) })
+	return &slogagent.Options{LoggerProvider: lp}, exp
+}
+
+func attrMap(r sdklog.Record) map[string]otellog.Value {
+	m := make(map[string]otellog.Value)
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		m[kv.Key] = kv.Value
+		return true
+	})
+	return m
+}
+
+func onlyRecord(t *testing.T, exp *memoryExporter) sdklog.Record {
+	t.Helper()
+	records := exp.all()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 exported record, got %d", len(records))
+	}
+	return records[0]
+}
+
+func TestExportHandler_ActiveSpan_AttachesTraceContext(t *testing.T) {
+	ctx, span, cleanup := startSpan(t)
+	defer cleanup()
+	opts, exp := newExportOptions(t)
+
+	logger := slog.New(slogagent.NewExportHandler(nil, opts))
+	logger.InfoContext(ctx, "user created", "user_id", 42)
+
+	r := onlyRecord(t, exp)
+	sc := span.SpanContext()
+	if r.TraceID() != sc.TraceID() {
+		t.Errorf("TraceID = %s, want %s", r.TraceID(), sc.TraceID())
+	}
+	if r.SpanID() != sc.SpanID() {
+		t.Errorf("SpanID = %s, want %s", r.SpanID(), sc.SpanID())
+	}
+	if got := r.Body().AsString(); got != "user created" {
+		t.Errorf("Body = %q, want %q", got, "user created")
+	}
+	if r.Severity() != otellog.SeverityInfo {
+		t.Errorf("Severity = %v, want %v", r.Severity(), otellog.SeverityInfo)
+	}
+	if r.SeverityText() != "INFO" {
+		t.Errorf("SeverityText = %q, want INFO", r.SeverityText())
+	}
+	attrs := attrMap(r)
+	if got := attrs["user_id"].AsInt64(); got != 42 {
+		t.Errorf("user_id = %d, want 42", got)
+	}
+	if _, ok := attrs["trace_id"]; ok {
+		t.Error("trace_id must travel in the record, not as an attribute")
+	}
+}
+
+func TestExportHandler_LevelFiltering(t *testing.T) {
+	opts, exp := newExportOptions(t)
+
+	logger := slog.New(slogagent.NewExportHandler(&slog.HandlerOptions{Level: slog.LevelWarn}, opts))
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	r := onlyRecord(t, exp)
+	if got := r.Body().AsString(); got != "kept" {
+		t.Errorf("Body = %q, want %q", got, "kept")
+	}
+	if r.Severity() != otellog.SeverityWarn {
+		t.Errorf("Severity = %v, want %v", r.Severity(), otellog.SeverityWarn)
+	}
+}
+
+func TestExportHandler_GroupsAreFlattened(t *testing.T) {
+	opts, exp := newExportOptions(t)
+
+	logger := slog.New(slogagent.NewExportHandler(nil, opts)).
+		With("service", "checkout").
+		WithGroup("req").
+		With("method", "GET")
+	logger.Info("handled",
+		"status", 200,
+		slog.Group("user", "id", "u1"),
+		slog.Group("empty"),
+	)
+
+	attrs := attrMap(onlyRecord(t, exp))
+	want := map[string]string{
+		"service":     "checkout",
+		"req.method":  "GET",
+		"req.user.id": "u1",
+	}
+	for k, v := range want {
+		if got := attrs[k].AsString(); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if got := attrs["req.status"].AsInt64(); got != 200 {
+		t.Errorf("req.status = %d, want 200", got)
+	}
+	if _, ok := attrs["req.empty"]; ok {
+		t.Error("empty groups must be dropped")
+	}
+}
+
+func TestExportHandler_ValueConversion(t *testing.T) {
+	opts, exp := newExportOptions(t)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	logger := slog.New(slogagent.NewExportHandler(nil, opts))
+	logger.Info("values",
+		slog.Bool("ok", true),
+		slog.Float64("ratio", 0.5),
+		slog.Duration("elapsed", 2*time.Second),
+		slog.Time("at", ts),
+		slog.Uint64("big", 1<<63),
+		slog.Any("err", errors.New("boom")),
+	)
+
+	attrs := attrMap(onlyRecord(t, exp))
+	if !attrs["ok"].AsBool() {
+		t.Error("ok = false, want true")
+	}
+	if got := attrs["ratio"].AsFloat64(); got != 0.5 {
+		t.Errorf("ratio = %v, want 0.5", got)
+	}
+	if got := attrs["elapsed"].AsInt64(); got != int64(2*time.Second) {
+		t.Errorf("elapsed = %d, want %d", got, int64(2*time.Second))
+	}
+	if got := attrs["at"].AsInt64(); got != ts.UnixNano() {
+		t.Errorf("at = %d, want %d", got, ts.UnixNano())
+	}
+	if got := attrs["big"].AsString(); got != "9223372036854775808" {
+		t.Errorf("big = %q, want decimal string", got)
+	}
+	if got := attrs["err"].AsString(); got != "boom" {
+		t.Errorf("err = %q, want %q", got, "boom")
+	}
+}
+
+func TestExportHandler_ReplaceAttrAndSource(t *testing.T) {
+	opts, exp := newExportOptions(t)
+
+	handlerOpts := &slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == "password" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+	logger := slog.New(slogagent.NewExportHandler(handlerOpts, opts))
+	logger.Info("login", "user", "alice", "password", "hunter2")
+
+	attrs := attrMap(onlyRecord(t, exp))
+	if _, ok := attrs["password"]; ok {
+		t.Error("password must be removed by ReplaceAttr")
+	}
+	if got := attrs["user"].AsString(); got != "alice" {
+		t.Errorf("user = %q, want alice", got)
+	}
+	if got := attrs["code.function"].AsString(); got == "" {
+		t.Error("code.function must be set when AddSource is true")
+	}
+}
+
+func TestHandler_Export_WritesLocallyAndExports(t *testing.T) {
+	ctx, span, cleanup := startSpan(t)
+	defer cleanup()
+	opts, exp := newExportOptions(t)
+	opts.Export = true
+
+	var buf bytes.Buffer
+	logger := slog.New(slogagent.NewJSONHandler(&buf, nil, opts)).WithGroup("req")
+	logger.InfoContext(ctx, "handled", "path", "/orders")
+
+	entry := parseJSON(t, &buf)
+	req, _ := entry["req"].(map[string]any)
+	if req["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Errorf("local req.trace_id = %v, want %s", req["trace_id"], span.SpanContext().TraceID())
+	}
+
+	r := onlyRecord(t, exp)
+	if r.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("exported TraceID = %s, want %s", r.TraceID(), span.SpanContext().TraceID())
+	}
+	attrs := attrMap(r)
+	if got := attrs["req.path"].AsString(); got != "/orders" {
+		t.Errorf("req.path = %q, want /orders", got)
+	}
+	if _, ok := attrs["req.trace_id"]; ok {
+		t.Error("exported record must not duplicate trace_id as an attribute")
+	}
+}
+
+func TestHandler_ExportDisabledByDefault(t *testing.T) {
+	opts, exp := newExportOptions(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slogagent.NewJSONHandler(&buf, nil, opts))
+	logger.Info("local only")
+
+	if n := len(exp.all()); n != 0 {
+		t.Errorf("expected no exported records without Export, got %d", n)
+	}
+}
@@ -28,6 +28,15 @@
 //	    SpanKey:  "dd.span_id",
 //	})
 //
+// Ship records to Last9 over OTLP as well as writing them locally. Trace
+// context is attached natively to each exported record:
+//
+//	slogagent.SetDefault(os.Stdout, nil, &slogagent.Options{Export: true})
+//
+// Or export only, with no local output:
+//
+//	logger := slog.New(slogagent.NewExportHandler(nil, nil))
+//
 // Note on package naming: this package is named "slog" which shadows the
 // standard library "log/slog". Import it with an alias when using both:
 //
@@ -39,9 +48,12 @@ package slog
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"math"
 
+	otellog "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -63,6 +75,15 @@ type Options struct {
 	// SpanKey is the log attribute key used for the span ID.
 	// Defaults to "span_id" if empty.
 	SpanKey string
+
+	// Export ships every record handled by a Handler to Last9 through the
+	// OpenTelemetry LoggerProvider, in addition to the inner handler's output.
+	// Default: false.
+	Export bool
+
+	// LoggerProvider is the provider used to export records.
+	// Defaults to the global provider installed by agent.Start.
+	LoggerProvider otellog.LoggerProvider
 }
 
 func (o *Options) resolvedTraceKey() string {
@@ -86,6 +107,7 @@ func (o *Options) resolvedSpanKey() string {
 // use NewHandler, NewJSONHandler, or NewTextHandler.
 type Handler struct {
 	inner    slog.Handler
+	export   *ExportHandler
 	traceKey string
 	spanKey  string
 }
@@ -106,11 +128,16 @@ func NewHandler(inner slog.Handler, opts *Options) *Handler {
 	if inner == nil {
 		panic("slog: NewHandler: inner handler must not be nil")
 	}
-	return &Handler{
+	h := &Handler{
 		inner:    inner,
 		traceKey: opts.resolvedTraceKey(),
 		spanKey:  opts.resolvedSpanKey(),
 	}
+	if opts != nil && opts.Export {
+		// Level filtering is left to the inner handler's Enabled.
+		h.export = NewExportHandler(&slog.HandlerOptions{Level: slog.Level(math.MinInt)}, opts)
+	}
+	return h
 }
 
 // NewJSONHandler creates a Handler that wraps slog.NewJSONHandler(w, handlerOpts).
@@ -161,7 +188,15 @@ func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 // Injection occurs when sc.IsValid() is true, which requires both a non-zero
 // TraceID and a non-zero SpanID. Sampled-out spans (valid but not recording)
 // still get their IDs injected, which is correct for log-trace correlation.
+//
+// When Options.Export is set, the original record is also shipped over OTLP,
+// where the trace context travels in the record itself.
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var exportErr error
+	if h.export != nil {
+		exportErr = h.export.Handle(ctx, r)
+	}
+
 	sc := trace.SpanContextFromContext(ctx)
 	if sc.IsValid() {
 		r = r.Clone()
@@ -170,17 +205,21 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 			slog.String(h.spanKey, sc.SpanID().String()),
 		)
 	}
-	return h.inner.Handle(ctx, r)
+	return errors.Join(h.inner.Handle(ctx, r), exportErr)
 }
 
 // WithAttrs implements slog.Handler. Returns a new Handler that wraps
 // inner.WithAttrs(attrs), preserving trace injection on subsequent records.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &Handler{
+	h2 := &Handler{
 		inner:    h.inner.WithAttrs(attrs),
 		traceKey: h.traceKey,
 		spanKey:  h.spanKey,
 	}
+	if h.export != nil {
+		h2.export = h.export.WithAttrs(attrs).(*ExportHandler)
+	}
+	return h2
 }
 
 // WithGroup implements slog.Handler. Returns a new Handler that wraps
@@ -189,9 +228,13 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 	if name == "" {
 		return h
 	}
-	return &Handler{
+	h2 := &Handler{
 		inner:    h.inner.WithGroup(name),
 		traceKey: h.traceKey,
 		spanKey:  h.spanKey,
 	}
+	if h.export != nil {
+		h2.export = h.export.WithGroup(name).(*ExportHandler)
+	}
+	return h2
 }
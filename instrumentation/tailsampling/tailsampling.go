@@ -0,0 +1,356 @@
+// Package tailsampling provides a SpanProcessor that decides which traces to
+// export after they finish, so that failing and slow requests are kept even
+// at low sampling ratios.
+//
+// Finished spans are buffered per trace. When the local root span ends, the
+// trace is kept if any of its spans has an error status or the root span took
+// at least LatencyThreshold; otherwise it is kept with probability Ratio,
+// derived from the trace ID like the traceidratio sampler. Kept spans are
+// handed to the next processor, typically a batch span processor.
+//
+// Traces whose root span does not end within DecisionWait, or that are
+// evicted because MaxSpans spans are buffered, are decided with the spans
+// seen so far. Spans that end after their trace was decided follow the same
+// decision.
+//
+// Tail sampling only sees spans the head sampler recorded, so leave the head
+// sampler at always_on (the default) when enabling it.
+package tailsampling
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scopeName is the instrumentation scope of the metrics reported by this package.
+const scopeName = "github.com/last9/go-agent/instrumentation/tailsampling"
+
+// Options configures a Processor.
+type Options struct {
+	// Ratio is the fraction of traces kept that have neither an error nor a
+	// slow root span. Default: 0 — only error and slow traces are kept.
+	Ratio float64
+
+	// LatencyThreshold keeps every trace whose root span takes at least this
+	// long. Default: 0 — latency is not considered.
+	LatencyThreshold time.Duration
+
+	// DecisionWait is how long a trace is buffered waiting for its root span.
+	// Default: 10s.
+	DecisionWait time.Duration
+
+	// MaxSpans bounds the number of buffered spans; the oldest traces are
+	// decided early when it is exceeded. Default: 100000.
+	MaxSpans int
+}
+
+// Stats counts the decisions made by a Processor.
+type Stats struct {
+	KeptTraces    int64
+	DroppedTraces int64
+	KeptSpans     int64
+	DroppedSpans  int64
+	// BufferedSpans is the number of spans currently awaiting a decision.
+	BufferedSpans int64
+}
+
+// Processor is a tail-sampling SpanProcessor.
+type Processor struct {
+	next    sdktrace.SpanProcessor
+	ratio   sdktrace.Sampler
+	latency time.Duration
+	wait    time.Duration
+	max     int
+
+	mu       sync.Mutex
+	pending  map[trace.TraceID]*list.Element // of *traceBuffer
+	order    *list.List                      // pending traces, oldest first
+	buffered int
+	decided  map[trace.TraceID]decision
+
+	keptTraces, droppedTraces atomic.Int64
+	keptSpans, droppedSpans   atomic.Int64
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+var _ sdktrace.SpanProcessor = (*Processor)(nil)
+
+// traceBuffer holds the finished spans of one undecided trace.
+type traceBuffer struct {
+	id       trace.TraceID
+	spans    []sdktrace.ReadOnlySpan
+	deadline time.Time
+	hasError bool
+	slow     bool
+}
+
+// decision is remembered for a while so late spans follow their trace.
+type decision struct {
+	keep    bool
+	expires time.Time
+}
+
+// New returns a Processor that passes kept spans to next. It starts a
+// goroutine that decides expired traces; Shutdown stops it.
+func New(next sdktrace.SpanProcessor, opts Options) *Processor {
+	if opts.DecisionWait <= 0 {
+		opts.DecisionWait = 10 * time.Second
+	}
+	if opts.MaxSpans <= 0 {
+		opts.MaxSpans = 100000
+	}
+	p := &Processor{
+		next:    next,
+		ratio:   sdktrace.TraceIDRatioBased(opts.Ratio),
+		latency: opts.LatencyThreshold,
+		wait:    opts.DecisionWait,
+		max:     opts.MaxSpans,
+		pending: make(map[trace.TraceID]*list.Element),
+		order:   list.New(),
+		decided: make(map[trace.TraceID]decision),
+		done:    make(chan struct{}),
+	}
+
+	tick := opts.DecisionWait / 4
+	if tick > time.Second {
+		tick = time.Second
+	}
+	p.wg.Add(1)
+	go p.run(tick)
+	return p
+}
+
+// Stats returns the decisions made so far.
+func (p *Processor) Stats() Stats {
+	p.mu.Lock()
+	buffered := p.buffered
+	p.mu.Unlock()
+	return Stats{
+		KeptTraces:    p.keptTraces.Load(),
+		DroppedTraces: p.droppedTraces.Load(),
+		KeptSpans:     p.keptSpans.Load(),
+		DroppedSpans:  p.droppedSpans.Load(),
+		BufferedSpans: int64(buffered),
+	}
+}
+
+// RegisterMetrics reports the decision counters through mp as
+// last9.agent.tail_sampling.traces and last9.agent.tail_sampling.spans,
+// split by a decision attribute of "kept" or "dropped".
+func (p *Processor) RegisterMetrics(mp metric.MeterProvider) error {
+	meter := mp.Meter(scopeName)
+	traces, err := meter.Int64ObservableCounter(
+		"last9.agent.tail_sampling.traces",
+		metric.WithDescription("Traces decided by the tail sampler"),
+		metric.WithUnit("{trace}"),
+	)
+	if err != nil {
+		return err
+	}
+	spans, err := meter.Int64ObservableCounter(
+		"last9.agent.tail_sampling.spans",
+		metric.WithDescription("Spans decided by the tail sampler"),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	kept := metric.WithAttributes(attribute.String("decision", "kept"))
+	dropped := metric.WithAttributes(attribute.String("decision", "dropped"))
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(traces, p.keptTraces.Load(), kept)
+		o.ObserveInt64(traces, p.droppedTraces.Load(), dropped)
+		o.ObserveInt64(spans, p.keptSpans.Load(), kept)
+		o.ObserveInt64(spans, p.droppedSpans.Load(), dropped)
+		return nil
+	}, traces, spans)
+	return err
+}
+
+// OnStart forwards to the next processor.
+func (p *Processor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd buffers s until its trace is decided.
+func (p *Processor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	id := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	if d, ok := p.decided[id]; ok {
+		p.mu.Unlock()
+		p.emit([]sdktrace.ReadOnlySpan{s}, d.keep, false)
+		return
+	}
+
+	var tb *traceBuffer
+	if el, ok := p.pending[id]; ok {
+		tb = el.Value.(*traceBuffer)
+	} else {
+		tb = &traceBuffer{id: id, deadline: time.Now().Add(p.wait)}
+		p.pending[id] = p.order.PushBack(tb)
+	}
+	tb.spans = append(tb.spans, s)
+	tb.hasError = tb.hasError || s.Status().Code == codes.Error
+	p.buffered++
+
+	var ready []*traceBuffer
+	if isLocalRoot(s) {
+		tb.slow = p.latency > 0 && s.EndTime().Sub(s.StartTime()) >= p.latency
+		ready = append(ready, p.remove(id))
+	}
+	for p.buffered > p.max && p.order.Len() > 0 {
+		ready = append(ready, p.remove(p.order.Front().Value.(*traceBuffer).id))
+	}
+	decisions := p.decideLocked(ready)
+	p.mu.Unlock()
+
+	for i, tb := range ready {
+		p.emit(tb.spans, decisions[i], true)
+	}
+}
+
+// Shutdown decides every buffered trace, then shuts down the next processor.
+func (p *Processor) Shutdown(ctx context.Context) error {
+	p.stop()
+	p.flushPending()
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush decides every buffered trace, then flushes the next processor.
+func (p *Processor) ForceFlush(ctx context.Context) error {
+	p.flushPending()
+	return p.next.ForceFlush(ctx)
+}
+
+func (p *Processor) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+	})
+}
+
+// run decides traces whose DecisionWait has elapsed and forgets old
+// decisions, every tick.
+func (p *Processor) run(tick time.Duration) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.expire(now)
+		}
+	}
+}
+
+func (p *Processor) expire(now time.Time) {
+	p.mu.Lock()
+	var ready []*traceBuffer
+	for p.order.Len() > 0 {
+		tb := p.order.Front().Value.(*traceBuffer)
+		if tb.deadline.After(now) {
+			break
+		}
+		ready = append(ready, p.remove(tb.id))
+	}
+	decisions := p.decideLocked(ready)
+	for id, d := range p.decided {
+		if d.expires.Before(now) {
+			delete(p.decided, id)
+		}
+	}
+	p.mu.Unlock()
+
+	for i, tb := range ready {
+		p.emit(tb.spans, decisions[i], true)
+	}
+}
+
+func (p *Processor) flushPending() {
+	p.mu.Lock()
+	ready := make([]*traceBuffer, 0, p.order.Len())
+	for p.order.Len() > 0 {
+		ready = append(ready, p.remove(p.order.Front().Value.(*traceBuffer).id))
+	}
+	decisions := p.decideLocked(ready)
+	p.mu.Unlock()
+
+	for i, tb := range ready {
+		p.emit(tb.spans, decisions[i], true)
+	}
+}
+
+// remove takes the trace out of the pending buffer. p.mu must be held.
+func (p *Processor) remove(id trace.TraceID) *traceBuffer {
+	el := p.pending[id]
+	delete(p.pending, id)
+	p.order.Remove(el)
+	tb := el.Value.(*traceBuffer)
+	p.buffered -= len(tb.spans)
+	return tb
+}
+
+// decideLocked decides each trace in ready and remembers the decisions for
+// late spans. p.mu must be held.
+func (p *Processor) decideLocked(ready []*traceBuffer) []bool {
+	if len(ready) == 0 {
+		return nil
+	}
+	keep := make([]bool, len(ready))
+	expires := time.Now().Add(p.wait)
+	for i, tb := range ready {
+		keep[i] = tb.hasError || tb.slow || p.sampledByRatio(tb.id)
+		p.decided[tb.id] = decision{keep: keep[i], expires: expires}
+	}
+	return keep
+}
+
+func (p *Processor) sampledByRatio(id trace.TraceID) bool {
+	res := p.ratio.ShouldSample(sdktrace.SamplingParameters{TraceID: id})
+	return res.Decision == sdktrace.RecordAndSample
+}
+
+// emit passes kept spans to the next processor and updates the counters.
+// countTrace is false for late spans of an already counted trace.
+func (p *Processor) emit(spans []sdktrace.ReadOnlySpan, keep, countTrace bool) {
+	if !keep {
+		if countTrace {
+			p.droppedTraces.Add(1)
+		}
+		p.droppedSpans.Add(int64(len(spans)))
+		return
+	}
+	if countTrace {
+		p.keptTraces.Add(1)
+	}
+	p.keptSpans.Add(int64(len(spans)))
+	for _, s := range spans {
+		p.next.OnEnd(s)
+	}
+}
+
+// isLocalRoot reports whether s is the first span of its trace in this
+// process: it has no parent, or its parent came from another service.
+func isLocalRoot(s sdktrace.ReadOnlySpan) bool {
+	parent := s.Parent()
+	return !parent.IsValid() || parent.IsRemote()
+}
@@ -0,0 +1,218 @@
+package tailsampling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestProvider returns a TracerProvider whose spans go through a
+// Processor into a SpanRecorder.
+func newTestProvider(t *testing.T, opts Options) (*sdktrace.TracerProvider, *Processor, *tracetest.SpanRecorder) {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	p := New(rec, opts)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp, p, rec
+}
+
+func TestKeepsErrorTraces(t *testing.T) {
+	tp, p, rec := newTestProvider(t, Options{Ratio: 0})
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	root.End()
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	ok.End()
+
+	if got := len(rec.Ended()); got != 2 {
+		t.Fatalf("exported %d spans, want 2 (the error trace only)", got)
+	}
+	stats := p.Stats()
+	if stats.KeptTraces != 1 || stats.DroppedTraces != 1 || stats.KeptSpans != 2 || stats.DroppedSpans != 1 {
+		t.Errorf("Stats() = %+v, want 1 kept / 1 dropped trace, 2 kept / 1 dropped span", stats)
+	}
+}
+
+func TestKeepsSlowTraces(t *testing.T) {
+	tp, _, rec := newTestProvider(t, Options{Ratio: 0, LatencyThreshold: time.Second})
+	tracer := tp.Tracer("test")
+
+	start := time.Now()
+	_, slow := tracer.Start(context.Background(), "slow", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+
+	_, fast := tracer.Start(context.Background(), "fast", trace.WithTimestamp(start))
+	fast.End(trace.WithTimestamp(start.Add(time.Millisecond)))
+
+	spans := rec.Ended()
+	if len(spans) != 1 || spans[0].Name() != "slow" {
+		t.Fatalf("exported %v, want only the slow span", spanNames(spans))
+	}
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		ratio float64
+		want  int
+	}{
+		{"keep all", 1, 10},
+		{"drop all", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, _, rec := newTestProvider(t, Options{Ratio: tt.ratio})
+			for i := 0; i < 10; i++ {
+				_, s := tp.Tracer("test").Start(context.Background(), "span")
+				s.End()
+			}
+			if got := len(rec.Ended()); got != tt.want {
+				t.Errorf("exported %d spans, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuffersUntilRootEnds(t *testing.T) {
+	tp, p, rec := newTestProvider(t, Options{Ratio: 1})
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+
+	if got := len(rec.Ended()); got != 0 {
+		t.Fatalf("exported %d spans before the root ended, want 0", got)
+	}
+	if got := p.Stats().BufferedSpans; got != 1 {
+		t.Errorf("BufferedSpans = %d, want 1", got)
+	}
+
+	root.End()
+	if got := len(rec.Ended()); got != 2 {
+		t.Fatalf("exported %d spans after the root ended, want 2", got)
+	}
+
+	// A span ending after the decision follows it.
+	_, late := tracer.Start(ctx, "late")
+	late.End()
+	if got := len(rec.Ended()); got != 3 {
+		t.Errorf("exported %d spans after a late span, want 3", got)
+	}
+	if got := p.Stats().KeptTraces; got != 1 {
+		t.Errorf("KeptTraces = %d, want 1 (late spans do not count as a new trace)", got)
+	}
+}
+
+func TestDecisionWaitExpiresTraces(t *testing.T) {
+	tp, _, rec := newTestProvider(t, Options{Ratio: 1, DecisionWait: 20 * time.Millisecond})
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	defer root.End()
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rec.Ended()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("trace was not decided after DecisionWait")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMaxSpansEvictsOldestTrace(t *testing.T) {
+	tp, p, rec := newTestProvider(t, Options{Ratio: 1, MaxSpans: 2})
+	tracer := tp.Tracer("test")
+
+	var roots []trace.Span
+	for i := 0; i < 3; i++ {
+		ctx, root := tracer.Start(context.Background(), "root")
+		roots = append(roots, root)
+		_, child := tracer.Start(ctx, "child")
+		child.End()
+	}
+
+	if got := len(rec.Ended()); got != 1 {
+		t.Errorf("exported %d spans, want 1 (the oldest trace, evicted)", got)
+	}
+	if got := p.Stats().BufferedSpans; got != 2 {
+		t.Errorf("BufferedSpans = %d, want 2", got)
+	}
+	for _, r := range roots {
+		r.End()
+	}
+}
+
+func TestShutdownFlushesPending(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	p := New(rec, Options{Ratio: 1})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p))
+
+	ctx, root := tp.Tracer("test").Start(context.Background(), "root")
+	_, child := tp.Tracer("test").Start(ctx, "child")
+	child.End()
+
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := len(rec.Ended()); got != 1 {
+		t.Errorf("exported %d spans on shutdown, want 1", got)
+	}
+	root.End()
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	tp, p, _ := newTestProvider(t, Options{Ratio: 0})
+	_, s := tp.Tracer("test").Start(context.Background(), "span")
+	s.End()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	if err := p.RegisterMetrics(mp); err != nil {
+		t.Fatalf("RegisterMetrics() error = %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("%s: unexpected data type %T", m.Name, m.Data)
+			}
+			for _, dp := range sum.DataPoints {
+				decision, _ := dp.Attributes.Value("decision")
+				got[m.Name+"/"+decision.AsString()] = dp.Value
+			}
+		}
+	}
+	if got["last9.agent.tail_sampling.traces/dropped"] != 1 || got["last9.agent.tail_sampling.spans/dropped"] != 1 {
+		t.Errorf("metrics = %v, want one dropped trace and span", got)
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+	return names
+}
@@ -0,0 +1,258 @@
+package zap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// scopeName is the instrumentation scope of log records shipped by this package.
+const scopeName = "github.com/last9/go-agent/instrumentation/zap"
+
+// contextFieldKey names the hidden field that carries a context.Context from
+// the *Context methods to Core. It is a zapcore.SkipType field, so encoders
+// of other cores never write it.
+const contextFieldKey = "last9.context"
+
+// ContextField returns a field carrying ctx to Core, which uses it to attach
+// trace context natively to the exported record. Other cores ignore it.
+// The *Context methods of Logger and SugaredLogger add it automatically.
+//
+//	logger.Info("request handled", zapagent.ContextField(ctx))
+func ContextField(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextFieldKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// CoreOptions configures Core. All fields are optional.
+type CoreOptions struct {
+	// Level is the minimum level shipped to Last9. Defaults to InfoLevel.
+	Level zapcore.LevelEnabler
+
+	// LoggerProvider is the provider used to export records.
+	// Defaults to the global provider installed by agent.Start.
+	LoggerProvider otellog.LoggerProvider
+}
+
+// Core is a zapcore.Core that ships every entry to Last9 through the
+// OpenTelemetry LoggerProvider started by agent.Start. Combine it with an
+// existing core to keep local output:
+//
+//	base, _ := zap.NewProduction()
+//	logger := zap.New(zapcore.NewTee(base.Core(), zapagent.NewCore(nil)))
+//	l := zapagent.New(logger, nil)
+//	l.InfoContext(ctx, "user created") // exported with trace context
+//
+// Entries logged through Logger or SugaredLogger *Context methods, or with a
+// ContextField, are emitted with that context so trace and span IDs are
+// attached natively. Nested objects and arrays are kept as maps and slices.
+type Core struct {
+	logger otellog.Logger
+	level  zapcore.LevelEnabler
+	attrs  []otellog.KeyValue
+	ctx    context.Context
+}
+
+// Compile-time assertion that Core implements zapcore.Core.
+var _ zapcore.Core = (*Core)(nil)
+
+// NewCore creates a Core. opts may be nil, in which case defaults are used.
+func NewCore(opts *CoreOptions) *Core {
+	c := &Core{level: zapcore.InfoLevel, ctx: context.Background()}
+	if opts != nil && opts.Level != nil {
+		c.level = opts.Level
+	}
+	if opts != nil && opts.LoggerProvider != nil {
+		c.logger = opts.LoggerProvider.Logger(scopeName)
+	} else {
+		c.logger = global.Logger(scopeName)
+	}
+	return c
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+// With implements zapcore.Core. The fields are converted once and attached
+// to every subsequent entry; a ContextField becomes the default context.
+func (c *Core) With(fields []zap.Field) zapcore.Core {
+	c2 := *c
+	var kvs []otellog.KeyValue
+	kvs, c2.ctx = convertFields(fields, c.ctx)
+	c2.attrs = make([]otellog.KeyValue, 0, len(c.attrs)+len(kvs))
+	c2.attrs = append(c2.attrs, c.attrs...)
+	c2.attrs = append(c2.attrs, kvs...)
+	return &c2
+}
+
+// Check implements zapcore.Core.
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core. It converts the entry to an OTel log record
+// and emits it.
+func (c *Core) Write(ent zapcore.Entry, fields []zap.Field) error {
+	kvs, ctx := convertFields(fields, c.ctx)
+
+	var rec otellog.Record
+	rec.SetTimestamp(ent.Time)
+	rec.SetObservedTimestamp(time.Now())
+	rec.SetBody(otellog.StringValue(ent.Message))
+	rec.SetSeverity(severity(ent.Level))
+	rec.SetSeverityText(ent.Level.CapitalString())
+
+	rec.AddAttributes(c.attrs...)
+	rec.AddAttributes(kvs...)
+	if ent.LoggerName != "" {
+		rec.AddAttributes(otellog.String("logger.name", ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		rec.AddAttributes(
+			otellog.String(string(semconv.CodeFunctionKey), ent.Caller.Function),
+			otellog.String(string(semconv.CodeFilepathKey), ent.Caller.File),
+			otellog.Int(string(semconv.CodeLineNumberKey), ent.Caller.Line),
+		)
+	}
+	if ent.Stack != "" {
+		rec.AddAttributes(otellog.String(string(semconv.ExceptionStacktraceKey), ent.Stack))
+	}
+
+	c.logger.Emit(ctx, rec)
+	return nil
+}
+
+// Sync implements zapcore.Core. Buffered records are flushed by the
+// LoggerProvider, on its own schedule and on agent.Shutdown.
+func (c *Core) Sync() error {
+	return nil
+}
+
+// convertFields encodes fields into OTel key-values. A ContextField replaces
+// ctx, which is returned alongside the converted fields.
+func convertFields(fields []zap.Field, ctx context.Context) ([]otellog.KeyValue, context.Context) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			if fctx, ok := f.Interface.(context.Context); ok && fctx != nil {
+				ctx = fctx
+			}
+			continue
+		}
+		f.AddTo(enc)
+	}
+
+	kvs := make([]otellog.KeyValue, 0, len(enc.Fields))
+	for k, v := range enc.Fields {
+		kvs = append(kvs, otellog.KeyValue{Key: k, Value: convertValue(v)})
+	}
+	return kvs, ctx
+}
+
+// convertValue maps a value produced by zapcore.MapObjectEncoder to its OTel
+// log equivalent. Durations are recorded in nanoseconds and times as Unix
+// nanoseconds.
+func convertValue(v any) otellog.Value {
+	switch x := v.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(x)
+	case bool:
+		return otellog.BoolValue(x)
+	case int:
+		return otellog.IntValue(x)
+	case int8:
+		return otellog.Int64Value(int64(x))
+	case int16:
+		return otellog.Int64Value(int64(x))
+	case int32:
+		return otellog.Int64Value(int64(x))
+	case int64:
+		return otellog.Int64Value(x)
+	case uint:
+		return uintValue(uint64(x))
+	case uint8:
+		return otellog.Int64Value(int64(x))
+	case uint16:
+		return otellog.Int64Value(int64(x))
+	case uint32:
+		return otellog.Int64Value(int64(x))
+	case uint64:
+		return uintValue(x)
+	case uintptr:
+		return uintValue(uint64(x))
+	case float32:
+		return otellog.Float64Value(float64(x))
+	case float64:
+		return otellog.Float64Value(x)
+	case time.Duration:
+		return otellog.Int64Value(int64(x))
+	case time.Time:
+		return otellog.Int64Value(x.UnixNano())
+	case []byte:
+		return otellog.BytesValue(x)
+	case error:
+		return otellog.StringValue(x.Error())
+	case map[string]any:
+		kvs := make([]otellog.KeyValue, 0, len(x))
+		for k, mv := range x {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: convertValue(mv)})
+		}
+		return otellog.MapValue(kvs...)
+	case []any:
+		vs := make([]otellog.Value, 0, len(x))
+		for _, sv := range x {
+			vs = append(vs, convertValue(sv))
+		}
+		return otellog.SliceValue(vs...)
+	default:
+		return otellog.StringValue(fmt.Sprintf("%+v", x))
+	}
+}
+
+// uintValue records u as an int64, or as a decimal string when it overflows.
+func uintValue(u uint64) otellog.Value {
+	if u > math.MaxInt64 {
+		return otellog.StringValue(strconv.FormatUint(u, 10))
+	}
+	return otellog.Int64Value(int64(u))
+}
+
+// severity maps a zap level onto the OTel severity range. DPanic maps to
+// ERROR4, Panic to FATAL and Fatal to FATAL4.
+func severity(level zapcore.Level) otellog.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError
+	case zapcore.DPanicLevel:
+		return otellog.SeverityError4
+	case zapcore.PanicLevel:
+		return otellog.SeverityFatal
+	case zapcore.FatalLevel:
+		return otellog.SeverityFatal4
+	default:
+		if level < zapcore.DebugLevel {
+			return otellog.SeverityTrace
+		}
+		return otellog.SeverityUndefined
+	}
+}
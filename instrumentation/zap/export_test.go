@@ -0,0 +1,178 @@
+package zap_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	zapagent "github.com/last9/go-agent/instrumentation/zap"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// memoryExporter records exported log records for inspection.
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) all() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]sdklog.Record(nil), e.records...)
+}
+
+// newExportCore returns a Core that exports synchronously to a memoryExporter.
+func newExportCore(t *testing.T, level zapcore.LevelEnabler) (*zapagent.Core, *memoryExporter) {
+	t.Helper()
+	exp := &memoryExporter{}
+	lp := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+	t.Cleanup(func() { _ = lp.Shutdown(context.Background()) })
+	return zapagent.NewCore(&zapagent.CoreOptions{Level: level, LoggerProvider: lp}), exp
+}
+
+func attrMap(r sdklog.Record) map[string]otellog.Value {
+	m := make(map[string]otellog.Value)
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		m[kv.Key] = kv.Value
+		return true
+	})
+	return m
+}
+
+func onlyRecord(t *testing.T, exp *memoryExporter) sdklog.Record {
+	t.Helper()
+	records := exp.all()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 exported record, got %d", len(records))
+	}
+	return records[0]
+}
+
+func TestCore_LoggerContext_AttachesTraceContext(t *testing.T) {
+	ctx, span, cleanup := startSpan(t)
+	defer cleanup()
+	core, exp := newExportCore(t, nil)
+
+	var buf bytes.Buffer
+	base := zap.New(zapcore.NewTee(newTestLogger(&buf).Core(), core))
+	zapagent.New(base, nil).InfoContext(ctx, "user created", zap.String("user_id", "42"))
+
+	r := onlyRecord(t, exp)
+	sc := span.SpanContext()
+	if r.TraceID() != sc.TraceID() {
+		t.Errorf("TraceID = %s, want %s", r.TraceID(), sc.TraceID())
+	}
+	if r.SpanID() != sc.SpanID() {
+		t.Errorf("SpanID = %s, want %s", r.SpanID(), sc.SpanID())
+	}
+	if got := r.Body().AsString(); got != "user created" {
+		t.Errorf("Body = %q, want %q", got, "user created")
+	}
+	if r.Severity() != otellog.SeverityInfo || r.SeverityText() != "INFO" {
+		t.Errorf("Severity = %v/%q, want INFO", r.Severity(), r.SeverityText())
+	}
+	if got := attrMap(r)["user_id"].AsString(); got != "42" {
+		t.Errorf("user_id = %q, want 42", got)
+	}
+
+	// The context field must not leak into other cores' output.
+	entry := parseJSON(t, &buf)
+	if _, ok := entry["last9.context"]; ok {
+		t.Error("context field must not be encoded by other cores")
+	}
+	if entry["trace_id"] != sc.TraceID().String() {
+		t.Errorf("local trace_id = %v, want %s", entry["trace_id"], sc.TraceID())
+	}
+}
+
+func TestCore_SugaredContext_AttachesTraceContext(t *testing.T) {
+	ctx, span, cleanup := startSpan(t)
+	defer cleanup()
+	core, exp := newExportCore(t, nil)
+
+	zapagent.NewSugared(zap.New(core).Sugar(), nil).InfowContext(ctx, "order placed", "order_id", 7)
+
+	r := onlyRecord(t, exp)
+	if r.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("TraceID = %s, want %s", r.TraceID(), span.SpanContext().TraceID())
+	}
+	if got := attrMap(r)["order_id"].AsInt64(); got != 7 {
+		t.Errorf("order_id = %d, want 7", got)
+	}
+}
+
+func TestCore_ContextField(t *testing.T) {
+	ctx, span, cleanup := startSpan(t)
+	defer cleanup()
+	core, exp := newExportCore(t, nil)
+
+	zap.New(core).Info("plain logger", zapagent.ContextField(ctx))
+
+	r := onlyRecord(t, exp)
+	if r.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("TraceID = %s, want %s", r.TraceID(), span.SpanContext().TraceID())
+	}
+	if _, ok := attrMap(r)["last9.context"]; ok {
+		t.Error("context field must not be exported as an attribute")
+	}
+}
+
+func TestCore_LevelFiltering(t *testing.T) {
+	core, exp := newExportCore(t, zapcore.WarnLevel)
+
+	logger := zap.New(core)
+	logger.Info("dropped")
+	logger.Error("kept")
+
+	r := onlyRecord(t, exp)
+	if got := r.Body().AsString(); got != "kept" {
+		t.Errorf("Body = %q, want %q", got, "kept")
+	}
+	if r.Severity() != otellog.SeverityError {
+		t.Errorf("Severity = %v, want %v", r.Severity(), otellog.SeverityError)
+	}
+}
+
+func TestCore_WithFieldsAndName(t *testing.T) {
+	core, exp := newExportCore(t, nil)
+
+	logger := zap.New(core).With(zap.String("service", "checkout")).Named("orders")
+	logger.Info("handled",
+		zap.Int("status", 200),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Dict("user", zap.String("id", "u1")),
+	)
+
+	attrs := attrMap(onlyRecord(t, exp))
+	if got := attrs["service"].AsString(); got != "checkout" {
+		t.Errorf("service = %q, want checkout", got)
+	}
+	if got := attrs["status"].AsInt64(); got != 200 {
+		t.Errorf("status = %d, want 200", got)
+	}
+	if got := attrs["logger.name"].AsString(); got != "orders" {
+		t.Errorf("logger.name = %q, want orders", got)
+	}
+	if tags := attrs["tags"].AsSlice(); len(tags) != 2 || tags[1].AsString() != "b" {
+		t.Errorf("tags = %v, want [a b]", attrs["tags"])
+	}
+	if attrs["user"].Kind() != otellog.KindMap {
+		t.Errorf("user kind = %v, want map", attrs["user"].Kind())
+	}
+}
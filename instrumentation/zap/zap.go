@@ -30,6 +30,12 @@
 //	    SpanKey:  "dd.span_id",
 //	})
 //
+// Ship entries to Last9 over OTLP by adding Core alongside the existing core.
+// Entries logged through the *Context methods carry their trace context:
+//
+//	logger := zap.New(zapcore.NewTee(base.Core(), zapagent.NewCore(nil)))
+//	zapagent.New(logger, nil).InfoContext(ctx, "user created")
+//
 // Note on package naming: this package is named "zap" which shadows
 // "go.uber.org/zap". Import it with an alias when using both:
 //
@@ -161,9 +167,10 @@ func New(base *zap.Logger, opts *Options) *Logger {
 }
 
 // appendTraceFields returns fields with trace_id and span_id appended if a
-// valid span context exists in ctx. When the caller passed fields, make+copy
-// is used to avoid clobbering spare capacity in the caller's backing array
-// (variadic slices share memory with the caller's original allocation).
+// valid span context exists in ctx, along with a ContextField so that Core
+// can export the entry with its trace context. When the caller passed fields,
+// make+copy is used to avoid clobbering spare capacity in the caller's backing
+// array (variadic slices share memory with the caller's original allocation).
 func (l *Logger) appendTraceFields(ctx context.Context, fields []zap.Field) []zap.Field {
 	traceID, spanID := extractTraceIDs(ctx)
 	if traceID == "" {
@@ -172,11 +179,11 @@ func (l *Logger) appendTraceFields(ctx context.Context, fields []zap.Field) []za
 	traceField := zap.String(l.traceKey, traceID)
 	spanField := zap.String(l.spanKey, spanID)
 	if len(fields) == 0 {
-		return []zap.Field{traceField, spanField}
+		return []zap.Field{traceField, spanField, ContextField(ctx)}
 	}
-	result := make([]zap.Field, len(fields), len(fields)+2)
+	result := make([]zap.Field, len(fields), len(fields)+3)
 	copy(result, fields)
-	return append(result, traceField, spanField)
+	return append(result, traceField, spanField, ContextField(ctx))
 }
 
 // DebugContext logs a message at DebugLevel with trace correlation.
@@ -272,21 +279,22 @@ func NewSugared(base *zap.SugaredLogger, opts *Options) *SugaredLogger {
 	}
 }
 
-// appendTraceKVs returns keysAndValues with trace_id and span_id appended if a
-// valid span context exists in ctx. When the caller passed keysAndValues,
-// make+copy is used to avoid clobbering spare capacity in the caller's backing
-// array (variadic slices share memory with the caller's original allocation).
+// appendTraceKVs returns keysAndValues with trace_id, span_id and a
+// ContextField appended if a valid span context exists in ctx. When the caller
+// passed keysAndValues, make+copy is used to avoid clobbering spare capacity
+// in the caller's backing array (variadic slices share memory with the
+// caller's original allocation).
 func (l *SugaredLogger) appendTraceKVs(ctx context.Context, keysAndValues []any) []any {
 	traceID, spanID := extractTraceIDs(ctx)
 	if traceID == "" {
 		return keysAndValues
 	}
 	if len(keysAndValues) == 0 {
-		return []any{l.traceKey, traceID, l.spanKey, spanID}
+		return []any{l.traceKey, traceID, l.spanKey, spanID, ContextField(ctx)}
 	}
-	result := make([]any, len(keysAndValues), len(keysAndValues)+4)
+	result := make([]any, len(keysAndValues), len(keysAndValues)+5)
 	copy(result, keysAndValues)
-	return append(result, l.traceKey, traceID, l.spanKey, spanID)
+	return append(result, l.traceKey, traceID, l.spanKey, spanID, ContextField(ctx))
 }
 
 // DebugwContext logs a message at DebugLevel with trace correlation.
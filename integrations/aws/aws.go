@@ -2,10 +2,9 @@
 package aws
 
 import (
-	"log"
-
 	awsconfig "github.com/aws/aws-sdk-go-v2/aws"
 	agent "github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
 
@@ -35,7 +34,7 @@ type Option = otelaws.Option
 func InstrumentSDK(cfg *awsconfig.Config, opts ...Option) {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for AWS SDK instrumentation: %v", err)
+			logging.Warnf("Failed to auto-start agent for AWS SDK instrumentation: %v", err)
 		}
 	}
 	otelaws.AppendMiddlewares(&cfg.APIOptions, opts...)
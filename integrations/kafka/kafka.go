@@ -9,12 +9,12 @@ package kafka
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -129,7 +129,7 @@ func NewSyncProducer(cfg ProducerConfig) (*SyncProducer, error) {
 		metric.WithUnit("{message}"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create messages_sent counter: %v", err)
+		logging.Warnf("Failed to create messages_sent counter: %v", err)
 	}
 
 	messageErrors, err := meter.Int64Counter(
@@ -138,7 +138,7 @@ func NewSyncProducer(cfg ProducerConfig) (*SyncProducer, error) {
 		metric.WithUnit("{error}"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create message_errors counter: %v", err)
+		logging.Warnf("Failed to create message_errors counter: %v", err)
 	}
 
 	sendDuration, err := meter.Float64Histogram(
@@ -147,7 +147,7 @@ func NewSyncProducer(cfg ProducerConfig) (*SyncProducer, error) {
 		metric.WithUnit("ms"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create send_duration histogram: %v", err)
+		logging.Warnf("Failed to create send_duration histogram: %v", err)
 	}
 
 	messageSizeBytes, err := meter.Int64Histogram(
@@ -156,7 +156,7 @@ func NewSyncProducer(cfg ProducerConfig) (*SyncProducer, error) {
 		metric.WithUnit("By"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create message_size histogram: %v", err)
+		logging.Warnf("Failed to create message_size histogram: %v", err)
 	}
 
 	return &SyncProducer{
@@ -463,7 +463,7 @@ func (c *tracedClaim) stop() {
 func WrapConsumerGroupHandler(handler sarama.ConsumerGroupHandler) *ConsumerGroupHandler {
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent: %v (consumer will not be instrumented)", err)
+			logging.Warnf("Failed to auto-start agent: %v (consumer will not be instrumented)", err)
 		}
 	}
 
@@ -476,7 +476,7 @@ func WrapConsumerGroupHandler(handler sarama.ConsumerGroupHandler) *ConsumerGrou
 		metric.WithUnit("{message}"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create messages_received counter: %v", err)
+		logging.Warnf("Failed to create messages_received counter: %v", err)
 	}
 
 	receiveErrors, err := meter.Int64Counter(
@@ -485,7 +485,7 @@ func WrapConsumerGroupHandler(handler sarama.ConsumerGroupHandler) *ConsumerGrou
 		metric.WithUnit("{error}"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create receive_errors counter: %v", err)
+		logging.Warnf("Failed to create receive_errors counter: %v", err)
 	}
 
 	processDuration, err := meter.Float64Histogram(
@@ -494,7 +494,7 @@ func WrapConsumerGroupHandler(handler sarama.ConsumerGroupHandler) *ConsumerGrou
 		metric.WithUnit("ms"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create process_duration histogram: %v", err)
+		logging.Warnf("Failed to create process_duration histogram: %v", err)
 	}
 
 	return &ConsumerGroupHandler{
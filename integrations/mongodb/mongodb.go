@@ -28,7 +28,6 @@ package mongodb
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
 	"net/url"
 	"strconv"
@@ -37,6 +36,7 @@ import (
 	"time"
 
 	"github.com/last9/go-agent"
+	"github.com/last9/go-agent/internal/logging"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -119,7 +119,7 @@ func NewClient(cfg Config) (*mongo.Client, error) {
 
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for MongoDB instrumentation: %v", err)
+			logging.Warnf("Failed to auto-start agent for MongoDB instrumentation: %v", err)
 		}
 	}
 
@@ -155,7 +155,7 @@ func Instrument(opts *options.ClientOptions) (*mongo.Client, error) {
 
 	if !agent.IsInitialized() {
 		if err := agent.Start(); err != nil {
-			log.Printf("[Last9 Agent] Warning: Failed to auto-start agent for MongoDB instrumentation: %v", err)
+			logging.Warnf("Failed to auto-start agent for MongoDB instrumentation: %v", err)
 		}
 	}
 
@@ -172,7 +172,7 @@ func Instrument(opts *options.ClientOptions) (*mongo.Client, error) {
 func connectWithMonitor(opts *options.ClientOptions, baseAttrs []attribute.KeyValue) (*mongo.Client, error) {
 	m, monitorErr := newMonitor(baseAttrs)
 	if monitorErr != nil {
-		log.Printf("[Last9 Agent] Warning: partial MongoDB monitor setup: %v", monitorErr)
+		logging.Warnf("partial MongoDB monitor setup: %v", monitorErr)
 	}
 
 	// If monitor creation failed entirely, connect without instrumentation.
@@ -206,7 +206,7 @@ func newMonitor(baseAttrs []attribute.KeyValue) (*monitor, error) {
 		metric.WithUnit("{operation}"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create MongoDB operations counter: %v", err)
+		logging.Warnf("Failed to create MongoDB operations counter: %v", err)
 		firstErr = err
 	}
 
@@ -216,7 +216,7 @@ func newMonitor(baseAttrs []attribute.KeyValue) (*monitor, error) {
 		metric.WithUnit("{error}"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create MongoDB errors counter: %v", err)
+		logging.Warnf("Failed to create MongoDB errors counter: %v", err)
 		if firstErr == nil {
 			firstErr = err
 		}
@@ -228,7 +228,7 @@ func newMonitor(baseAttrs []attribute.KeyValue) (*monitor, error) {
 		metric.WithUnit("ms"),
 	)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to create MongoDB duration histogram: %v", err)
+		logging.Warnf("Failed to create MongoDB duration histogram: %v", err)
 		if firstErr == nil {
 			firstErr = err
 		}
@@ -303,7 +303,7 @@ func (m *monitor) succeeded(ctx context.Context, evt *event.CommandSucceededEven
 		return
 	}
 	entry.span.End()
-	m.recordMetrics(ctx, evt.CommandName, false, evt.Duration)
+	m.recordMetrics(trace.ContextWithSpan(ctx, entry.span), evt.CommandName, false, evt.Duration)
 }
 
 // failed handles CommandFailedEvent: records the error and ends the span.
@@ -320,10 +320,11 @@ func (m *monitor) failed(ctx context.Context, evt *event.CommandFailedEvent) {
 	entry.span.RecordError(fmt.Errorf("%s", evt.Failure))
 	entry.span.SetStatus(codes.Error, evt.Failure)
 	entry.span.End()
-	m.recordMetrics(ctx, evt.CommandName, true, evt.Duration)
+	m.recordMetrics(trace.ContextWithSpan(ctx, entry.span), evt.CommandName, true, evt.Duration)
 }
 
-// recordMetrics records operation count and duration.
+// recordMetrics records operation count and duration. ctx carries the
+// command span, so exemplars link the duration to it.
 func (m *monitor) recordMetrics(ctx context.Context, operation string, isError bool, elapsed time.Duration) {
 	attrs := append(
 		[]attribute.KeyValue{semconv.DBSystemMongoDB, semconv.DBOperation(operation)},
@@ -4,8 +4,8 @@ package redis
 import (
 	"context"
 	"fmt"
-	"log"
 
+	"github.com/last9/go-agent/internal/logging"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
@@ -33,7 +33,7 @@ func NewClient(opts *redis.Options) (*redis.Client, error) {
 	client := redis.NewClient(opts)
 	err := setupInstrumentation(client)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to instrument Redis client: %v (client still usable)", err)
+		logging.Warnf("Failed to instrument Redis client: %v (client still usable)", err)
 	}
 	return client, err
 }
@@ -55,7 +55,7 @@ func NewClusterClient(opts *redis.ClusterOptions) (*redis.ClusterClient, error)
 	client := redis.NewClusterClient(opts)
 	err := setupClusterInstrumentation(client)
 	if err != nil {
-		log.Printf("[Last9 Agent] Warning: Failed to instrument Redis cluster client: %v (client still usable)", err)
+		logging.Warnf("Failed to instrument Redis cluster client: %v (client still usable)", err)
 	}
 	return client, err
 }
@@ -84,7 +84,7 @@ func setupInstrumentation(client *redis.Client) error {
 
 	// Optional: instrument metrics
 	if err := redisotel.InstrumentMetrics(client); err != nil {
-		log.Printf("[Last9 Agent] Warning: failed to instrument Redis metrics: %v", err)
+		logging.Warnf("failed to instrument Redis metrics: %v", err)
 	}
 
 	return nil
@@ -98,7 +98,7 @@ func setupClusterInstrumentation(client *redis.ClusterClient) error {
 
 	// Optional: instrument metrics
 	if err := redisotel.InstrumentMetrics(client); err != nil {
-		log.Printf("[Last9 Agent] Warning: failed to instrument Redis cluster metrics: %v", err)
+		logging.Warnf("failed to instrument Redis cluster metrics: %v", err)
 	}
 
 	return nil
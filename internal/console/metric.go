@@ -0,0 +1,165 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// MetricExporter prints each collection as a table with one row per data
+// point. Metrics keep the default cumulative temporality, so every table
+// shows totals since start.
+type MetricExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewMetricExporter returns a MetricExporter writing to w.
+func NewMetricExporter(w io.Writer) *MetricExporter {
+	return &MetricExporter{w: w}
+}
+
+// Temporality implements metric.Exporter.
+func (e *MetricExporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return metric.DefaultTemporalitySelector(k)
+}
+
+// Aggregation implements metric.Exporter.
+func (e *MetricExporter) Aggregation(k metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(k)
+}
+
+// Export implements metric.Exporter. Collections without data print nothing.
+func (e *MetricExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	rows := 0
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			for _, r := range rowsOf(m.Data) {
+				if rows == 0 {
+					fmt.Fprintln(tw, "METRIC\tATTRIBUTES\tVALUE\tUNIT")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.Name, r.attrs, r.value, unitOr(m.Unit))
+				rows++
+			}
+		}
+	}
+	if rows == 0 {
+		return nil
+	}
+	_ = tw.Flush()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := fmt.Fprintf(e.w, "metrics at %s\n%s", time.Now().Format(time.TimeOnly), b.String())
+	return err
+}
+
+// ForceFlush implements metric.Exporter.
+func (e *MetricExporter) ForceFlush(context.Context) error { return nil }
+
+// Shutdown implements metric.Exporter.
+func (e *MetricExporter) Shutdown(context.Context) error { return nil }
+
+type row struct {
+	attrs string
+	value string
+}
+
+func rowsOf(data metricdata.Aggregation) []row {
+	switch d := data.(type) {
+	case metricdata.Sum[int64]:
+		return valueRows(d.DataPoints)
+	case metricdata.Sum[float64]:
+		return valueRows(d.DataPoints)
+	case metricdata.Gauge[int64]:
+		return valueRows(d.DataPoints)
+	case metricdata.Gauge[float64]:
+		return valueRows(d.DataPoints)
+	case metricdata.Histogram[int64]:
+		return histogramRows(d.DataPoints)
+	case metricdata.Histogram[float64]:
+		return histogramRows(d.DataPoints)
+	case metricdata.ExponentialHistogram[int64]:
+		return exponentialRows(d.DataPoints)
+	case metricdata.ExponentialHistogram[float64]:
+		return exponentialRows(d.DataPoints)
+	case metricdata.Summary:
+		rows := make([]row, 0, len(d.DataPoints))
+		for _, p := range d.DataPoints {
+			rows = append(rows, row{formatAttributes(p.Attributes), countSum(p.Count, p.Sum)})
+		}
+		return rows
+	}
+	return nil
+}
+
+func valueRows[N int64 | float64](points []metricdata.DataPoint[N]) []row {
+	rows := make([]row, 0, len(points))
+	for _, p := range points {
+		rows = append(rows, row{formatAttributes(p.Attributes), formatNumber(p.Value)})
+	}
+	return rows
+}
+
+func histogramRows[N int64 | float64](points []metricdata.HistogramDataPoint[N]) []row {
+	rows := make([]row, 0, len(points))
+	for _, p := range points {
+		v := countSum(p.Count, p.Sum)
+		if lo, ok := p.Min.Value(); ok {
+			v += " min=" + formatNumber(lo)
+		}
+		if hi, ok := p.Max.Value(); ok {
+			v += " max=" + formatNumber(hi)
+		}
+		rows = append(rows, row{formatAttributes(p.Attributes), v})
+	}
+	return rows
+}
+
+func exponentialRows[N int64 | float64](points []metricdata.ExponentialHistogramDataPoint[N]) []row {
+	rows := make([]row, 0, len(points))
+	for _, p := range points {
+		rows = append(rows, row{formatAttributes(p.Attributes), countSum(p.Count, p.Sum)})
+	}
+	return rows
+}
+
+func countSum[N int64 | float64](count uint64, sum N) string {
+	return fmt.Sprintf("count=%d sum=%s", count, formatNumber(sum))
+}
+
+func formatNumber[N int64 | float64](n N) string {
+	if i, ok := any(n).(int64); ok {
+		return strconv.FormatInt(i, 10)
+	}
+	return strconv.FormatFloat(float64(n), 'g', 6, 64)
+}
+
+func formatAttributes(set attribute.Set) string {
+	if set.Len() == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, set.Len())
+	for _, kv := range set.ToSlice() {
+		parts = append(parts, string(kv.Key)+"="+formatValue(kv.Value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func unitOr(unit string) string {
+	if unit == "" {
+		return "-"
+	}
+	return unit
+}
@@ -0,0 +1,53 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exp := NewMetricExporter(&buf)
+	reader := sdkmetric.NewPeriodicReader(exp)
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	meter := mp.Meter("test")
+	requests, _ := meter.Int64Counter("app.requests", metric.WithUnit("{request}"))
+	requests.Add(context.Background(), 3, metric.WithAttributes(attribute.String("route", "/users")))
+	latency, _ := meter.Float64Histogram("app.latency", metric.WithUnit("s"))
+	latency.Record(context.Background(), 0.25)
+	latency.Record(context.Background(), 0.75)
+
+	if err := mp.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "metrics at ") || !strings.HasPrefix(lines[1], "METRIC") {
+		t.Fatalf("printed:\n%s", buf.String())
+	}
+	if f := strings.Fields(lines[2]); strings.Join(f, " ") != "app.requests route=/users 3 {request}" {
+		t.Errorf("counter row = %q", lines[2])
+	}
+	if f := strings.Fields(lines[3]); strings.Join(f, " ") != "app.latency - count=2 sum=1 min=0.25 max=0.75 s" {
+		t.Errorf("histogram row = %q", lines[3])
+	}
+}
+
+func TestMetricExporterSkipsEmptyCollections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewMetricExporter(&buf).Export(context.Background(), &metricdata.ResourceMetrics{}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("printed %q for an empty collection", buf.String())
+	}
+}
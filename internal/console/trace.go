@@ -0,0 +1,232 @@
+// Package console prints telemetry in a human-readable form for local
+// development: finished spans as one indented tree per trace and metrics as
+// a table per collection.
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxPendingSpans bounds the spans held back waiting for their local
+	// root; beyond it the oldest traces are printed incomplete.
+	maxPendingSpans = 10000
+	// maxPrintedTraces is how many printed trace IDs are remembered so that
+	// spans ending after their root are labelled as a continuation.
+	maxPrintedTraces = 1024
+)
+
+// TraceExporter prints spans as trees. Spans are held until the local root
+// of their trace (a span without a parent, or with a remote one) ends, so
+// that each trace prints as a whole.
+type TraceExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	pending      map[trace.TraceID][]sdktrace.ReadOnlySpan
+	order        []trace.TraceID // pending traces, oldest first
+	pendingSpans int
+
+	printed      map[trace.TraceID]bool
+	printedOrder []trace.TraceID
+}
+
+// NewTraceExporter returns a TraceExporter writing to w.
+func NewTraceExporter(w io.Writer) *TraceExporter {
+	return &TraceExporter{
+		w:       w,
+		pending: make(map[trace.TraceID][]sdktrace.ReadOnlySpan),
+		printed: make(map[trace.TraceID]bool),
+	}
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *TraceExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var late []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		id := s.SpanContext().TraceID()
+		if e.printed[id] {
+			late = append(late, s)
+			continue
+		}
+		if _, ok := e.pending[id]; !ok {
+			e.order = append(e.order, id)
+		}
+		e.pending[id] = append(e.pending[id], s)
+		e.pendingSpans++
+	}
+	if len(late) > 0 {
+		e.printLate(late)
+	}
+
+	remaining := e.order[:0]
+	for _, id := range e.order {
+		if hasLocalRoot(e.pending[id]) {
+			e.print(id, "")
+		} else {
+			remaining = append(remaining, id)
+		}
+	}
+	e.order = remaining
+
+	for e.pendingSpans > maxPendingSpans {
+		id := e.order[0]
+		e.order = e.order[1:]
+		e.print(id, " (incomplete)")
+	}
+	return nil
+}
+
+// Shutdown prints the traces still waiting for their root.
+func (e *TraceExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range e.order {
+		e.print(id, " (incomplete)")
+	}
+	e.order = nil
+	return nil
+}
+
+// print writes the pending spans of trace id and forgets them.
+func (e *TraceExporter) print(id trace.TraceID, note string) {
+	spans := e.pending[id]
+	delete(e.pending, id)
+	e.pendingSpans -= len(spans)
+
+	e.printed[id] = true
+	e.printedOrder = append(e.printedOrder, id)
+	if len(e.printedOrder) > maxPrintedTraces {
+		delete(e.printed, e.printedOrder[0])
+		e.printedOrder = e.printedOrder[1:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace %s%s\n", id, note)
+	writeTree(&b, spans)
+	_, _ = io.WriteString(e.w, b.String())
+}
+
+// printLate writes spans of already printed traces, grouped by trace.
+func (e *TraceExporter) printLate(spans []sdktrace.ReadOnlySpan) {
+	byTrace := make(map[trace.TraceID][]sdktrace.ReadOnlySpan)
+	var order []trace.TraceID
+	for _, s := range spans {
+		id := s.SpanContext().TraceID()
+		if _, ok := byTrace[id]; !ok {
+			order = append(order, id)
+		}
+		byTrace[id] = append(byTrace[id], s)
+	}
+	var b strings.Builder
+	for _, id := range order {
+		fmt.Fprintf(&b, "trace %s (continued)\n", id)
+		writeTree(&b, byTrace[id])
+	}
+	_, _ = io.WriteString(e.w, b.String())
+}
+
+func hasLocalRoot(spans []sdktrace.ReadOnlySpan) bool {
+	for _, s := range spans {
+		if !s.Parent().IsValid() || s.Parent().IsRemote() {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTree writes spans as a tree ordered by start time. Spans whose parent
+// is not among them are printed at the top level.
+func writeTree(b *strings.Builder, spans []sdktrace.ReadOnlySpan) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime().Before(spans[j].StartTime())
+	})
+	ids := make(map[trace.SpanID]bool, len(spans))
+	for _, s := range spans {
+		ids[s.SpanContext().SpanID()] = true
+	}
+	children := make(map[trace.SpanID][]sdktrace.ReadOnlySpan)
+	var roots []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		if parent := s.Parent().SpanID(); s.Parent().IsValid() && ids[parent] {
+			children[parent] = append(children[parent], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+	for i, s := range roots {
+		writeSpan(b, s, children, "", i == len(roots)-1)
+	}
+}
+
+func writeSpan(b *strings.Builder, s sdktrace.ReadOnlySpan, children map[trace.SpanID][]sdktrace.ReadOnlySpan, prefix string, last bool) {
+	branch, indent := "├── ", "│   "
+	if last {
+		branch, indent = "└── ", "    "
+	}
+	fmt.Fprintf(b, "%s%s%s (%s, %s)", prefix, branch, s.Name(), s.SpanKind(), formatDuration(s.EndTime().Sub(s.StartTime())))
+	if st := s.Status(); st.Code == codes.Error {
+		b.WriteString(" ERROR")
+		if st.Description != "" {
+			fmt.Fprintf(b, ": %s", st.Description)
+		}
+	}
+	writeAttributes(b, s.Attributes())
+	b.WriteByte('\n')
+
+	kids := children[s.SpanContext().SpanID()]
+	for _, ev := range s.Events() {
+		bar := "│ "
+		if len(kids) == 0 {
+			bar = "  "
+		}
+		fmt.Fprintf(b, "%s%s%s* %s", prefix, indent, bar, ev.Name)
+		writeAttributes(b, ev.Attributes)
+		b.WriteByte('\n')
+	}
+	for i, c := range kids {
+		writeSpan(b, c, children, prefix+indent, i == len(kids)-1)
+	}
+}
+
+func writeAttributes(b *strings.Builder, attrs []attribute.KeyValue) {
+	for _, kv := range attrs {
+		fmt.Fprintf(b, " %s=%s", kv.Key, formatValue(kv.Value))
+	}
+}
+
+// formatValue renders v, quoting strings that would otherwise be ambiguous.
+func formatValue(v attribute.Value) string {
+	s := v.Emit()
+	if v.Type() == attribute.STRING && (s == "" || strings.ContainsAny(s, " \t\n\"=")) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// formatDuration rounds d to a readable precision.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
@@ -0,0 +1,104 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestProvider(t *testing.T, buf *bytes.Buffer) (*sdktrace.TracerProvider, *TraceExporter) {
+	t.Helper()
+	exp := NewTraceExporter(buf)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp, exp
+}
+
+func TestTraceExporterPrintsTree(t *testing.T) {
+	var buf bytes.Buffer
+	tp, _ := newTestProvider(t, &buf)
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "GET /users/{id}",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("http.route", "/users/{id}")))
+	dbCtx, db := tracer.Start(ctx, "SELECT users", trace.WithSpanKind(trace.SpanKindClient))
+	_, conn := tracer.Start(dbCtx, "connect")
+	conn.End()
+	db.End()
+	_, cache := tracer.Start(ctx, "redis GET", trace.WithSpanKind(trace.SpanKindClient))
+	cache.AddEvent("miss", trace.WithAttributes(attribute.String("key", "user 42")))
+	cache.SetStatus(codes.Error, "timeout")
+	cache.End()
+
+	if buf.Len() != 0 {
+		t.Fatalf("printed before the root ended:\n%s", buf.String())
+	}
+	root.End()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"trace " + root.SpanContext().TraceID().String(),
+		"└── GET /users/{id} (server, ",
+		"    ├── SELECT users (client, ",
+		"    │   └── connect (internal, ",
+		"    └── redis GET (client, ",
+		`          * miss key="user 42"`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("printed %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	if !strings.Contains(lines[1], "http.route=/users/{id}") {
+		t.Errorf("root line %q lacks the http.route attribute", lines[1])
+	}
+	if !strings.Contains(lines[4], "ERROR: timeout") {
+		t.Errorf("redis line %q lacks the error status", lines[4])
+	}
+}
+
+func TestTraceExporterLateSpans(t *testing.T) {
+	var buf bytes.Buffer
+	tp, _ := newTestProvider(t, &buf)
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, async := tracer.Start(ctx, "async")
+	root.End()
+	buf.Reset()
+	async.End()
+
+	if got := buf.String(); !strings.Contains(got, "(continued)") || !strings.Contains(got, "└── async") {
+		t.Errorf("late span printed as:\n%s", got)
+	}
+}
+
+func TestTraceExporterShutdownPrintsIncomplete(t *testing.T) {
+	var buf bytes.Buffer
+	tp, _ := newTestProvider(t, &buf)
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	if buf.Len() != 0 {
+		t.Fatalf("printed before the root ended:\n%s", buf.String())
+	}
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "(incomplete)") || !strings.Contains(got, "└── child") {
+		t.Errorf("Shutdown printed:\n%s", got)
+	}
+	root.End()
+}
@@ -0,0 +1,155 @@
+// Package destination filters the spans and metrics sent to an additional
+// export destination (config.Destination) and fans spans out to several
+// span processors.
+package destination
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/last9/go-agent/config"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanProcessor returns a processor passing the spans that match f to next.
+// Without span criteria in f, next is returned unchanged.
+func SpanProcessor(next sdktrace.SpanProcessor, f config.DestinationFilter) sdktrace.SpanProcessor {
+	if len(f.SpanStatus) == 0 && len(f.SpanKinds) == 0 && len(f.Scopes) == 0 {
+		return next
+	}
+	return &spanFilter{next: next, filter: f}
+}
+
+type spanFilter struct {
+	next   sdktrace.SpanProcessor
+	filter config.DestinationFilter
+}
+
+func (p *spanFilter) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *spanFilter) OnEnd(s sdktrace.ReadOnlySpan) {
+	f := &p.filter
+	if len(f.SpanStatus) > 0 && !contains(f.SpanStatus, statusName(s.Status().Code)) {
+		return
+	}
+	if len(f.SpanKinds) > 0 && !contains(f.SpanKinds, s.SpanKind().String()) {
+		return
+	}
+	if len(f.Scopes) > 0 && !hasPrefix(s.InstrumentationScope().Name, f.Scopes) {
+		return
+	}
+	p.next.OnEnd(s)
+}
+
+func (p *spanFilter) Shutdown(ctx context.Context) error   { return p.next.Shutdown(ctx) }
+func (p *spanFilter) ForceFlush(ctx context.Context) error { return p.next.ForceFlush(ctx) }
+
+func statusName(c codes.Code) string {
+	switch c {
+	case codes.Error:
+		return "error"
+	case codes.Ok:
+		return "ok"
+	default:
+		return "unset"
+	}
+}
+
+// MetricExporter returns an exporter passing the metrics that match f to
+// next. Collections left empty by the filter are not exported. Without
+// metric criteria in f, next is returned unchanged.
+func MetricExporter(next metric.Exporter, f config.DestinationFilter) metric.Exporter {
+	if len(f.Scopes) == 0 && len(f.MetricPrefixes) == 0 {
+		return next
+	}
+	return &metricFilter{Exporter: next, filter: f}
+}
+
+type metricFilter struct {
+	metric.Exporter
+	filter config.DestinationFilter
+}
+
+func (e *metricFilter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	filtered := metricdata.ResourceMetrics{Resource: rm.Resource}
+	for _, sm := range rm.ScopeMetrics {
+		if len(e.filter.Scopes) > 0 && !hasPrefix(sm.Scope.Name, e.filter.Scopes) {
+			continue
+		}
+		kept := metricdata.ScopeMetrics{Scope: sm.Scope}
+		for _, m := range sm.Metrics {
+			if len(e.filter.MetricPrefixes) == 0 || hasPrefix(m.Name, e.filter.MetricPrefixes) {
+				kept.Metrics = append(kept.Metrics, m)
+			}
+		}
+		if len(kept.Metrics) > 0 {
+			filtered.ScopeMetrics = append(filtered.ScopeMetrics, kept)
+		}
+	}
+	if len(filtered.ScopeMetrics) == 0 {
+		return nil
+	}
+	return e.Exporter.Export(ctx, &filtered)
+}
+
+// Fanout returns a processor calling each of processors in turn.
+func Fanout(processors ...sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	if len(processors) == 1 {
+		return processors[0]
+	}
+	return fanout(processors)
+}
+
+type fanout []sdktrace.SpanProcessor
+
+func (f fanout) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	for _, p := range f {
+		p.OnStart(parent, s)
+	}
+}
+
+func (f fanout) OnEnd(s sdktrace.ReadOnlySpan) {
+	for _, p := range f {
+		p.OnEnd(s)
+	}
+}
+
+func (f fanout) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, p := range f {
+		errs = append(errs, p.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, p := range f {
+		errs = append(errs, p.ForceFlush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
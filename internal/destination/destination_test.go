@@ -0,0 +1,138 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/last9/go-agent/config"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingProcessor records the names of ended spans.
+type recordingProcessor struct {
+	ended    []string
+	shutdown error
+}
+
+func (p *recordingProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+func (p *recordingProcessor) OnEnd(s sdktrace.ReadOnlySpan)                   { p.ended = append(p.ended, s.Name()) }
+func (p *recordingProcessor) Shutdown(context.Context) error                  { return p.shutdown }
+func (p *recordingProcessor) ForceFlush(context.Context) error                { return nil }
+
+func TestSpanProcessor(t *testing.T) {
+	spans := []tracetest.SpanStub{
+		{Name: "server-error", SpanKind: trace.SpanKindServer, Status: sdktrace.Status{Code: codes.Error},
+			InstrumentationScope: instrumentation.Scope{Name: "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"}},
+		{Name: "server-ok", SpanKind: trace.SpanKindServer, Status: sdktrace.Status{Code: codes.Ok},
+			InstrumentationScope: instrumentation.Scope{Name: "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"}},
+		{Name: "client-error", SpanKind: trace.SpanKindClient, Status: sdktrace.Status{Code: codes.Error},
+			InstrumentationScope: instrumentation.Scope{Name: "github.com/last9/go-agent/integrations/database"}},
+		{Name: "internal-unset", SpanKind: trace.SpanKindInternal,
+			InstrumentationScope: instrumentation.Scope{Name: "checkout"}},
+	}
+	tests := []struct {
+		name   string
+		filter config.DestinationFilter
+		want   string
+	}{
+		{"no filter", config.DestinationFilter{}, "server-error server-ok client-error internal-unset"},
+		{"status", config.DestinationFilter{SpanStatus: []string{"error"}}, "server-error client-error"},
+		{"kind", config.DestinationFilter{SpanKinds: []string{"client", "internal"}}, "client-error internal-unset"},
+		{"scope", config.DestinationFilter{Scopes: []string{"go.opentelemetry.io/contrib/"}}, "server-error server-ok"},
+		{"status and kind", config.DestinationFilter{SpanStatus: []string{"error"}, SpanKinds: []string{"server"}}, "server-error"},
+		{"unset status", config.DestinationFilter{SpanStatus: []string{"unset"}}, "internal-unset"},
+		{"metric criteria only", config.DestinationFilter{MetricPrefixes: []string{"http."}}, "server-error server-ok client-error internal-unset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingProcessor{}
+			p := SpanProcessor(next, tt.filter)
+			for _, s := range spans {
+				p.OnEnd(s.Snapshot())
+			}
+			if got := strings.Join(next.ended, " "); got != tt.want {
+				t.Errorf("passed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingExporter records the metric names of each export.
+type recordingExporter struct {
+	metric.Exporter
+	exports [][]string
+}
+
+func (e *recordingExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	var names []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names = append(names, sm.Scope.Name+":"+m.Name)
+		}
+	}
+	e.exports = append(e.exports, names)
+	return nil
+}
+
+func TestMetricExporter(t *testing.T) {
+	rm := &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{
+		{Scope: instrumentation.Scope{Name: "otelhttp"}, Metrics: []metricdata.Metrics{
+			{Name: "http.server.request.duration"}, {Name: "http.server.active_requests"},
+		}},
+		{Scope: instrumentation.Scope{Name: "runtime"}, Metrics: []metricdata.Metrics{
+			{Name: "go.memory.used"},
+		}},
+	}}
+	tests := []struct {
+		name   string
+		filter config.DestinationFilter
+		want   []string
+	}{
+		{"no filter", config.DestinationFilter{}, []string{"otelhttp:http.server.request.duration otelhttp:http.server.active_requests runtime:go.memory.used"}},
+		{"prefix", config.DestinationFilter{MetricPrefixes: []string{"http.server.request", "go."}}, []string{"otelhttp:http.server.request.duration runtime:go.memory.used"}},
+		{"scope", config.DestinationFilter{Scopes: []string{"runtime"}}, []string{"runtime:go.memory.used"}},
+		{"nothing left", config.DestinationFilter{MetricPrefixes: []string{"db."}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingExporter{}
+			if err := MetricExporter(next, tt.filter).Export(context.Background(), rm); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			var got []string
+			for _, names := range next.exports {
+				got = append(got, strings.Join(names, " "))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("exported %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if len(rm.ScopeMetrics[0].Metrics) != 2 {
+		t.Error("Export() modified the collected metrics")
+	}
+}
+
+func TestFanout(t *testing.T) {
+	a, b := &recordingProcessor{}, &recordingProcessor{shutdown: errors.New("b failed")}
+	if p := Fanout(a); p != sdktrace.SpanProcessor(a) {
+		t.Errorf("Fanout() of one processor = %T, want it unchanged", p)
+	}
+
+	p := Fanout(a, b)
+	p.OnEnd(tracetest.SpanStub{Name: "span"}.Snapshot())
+	if len(a.ended) != 1 || len(b.ended) != 1 {
+		t.Errorf("ended = %v, %v, want the span in both", a.ended, b.ended)
+	}
+	if err := p.Shutdown(context.Background()); err == nil || err.Error() != "b failed" {
+		t.Errorf("Shutdown() error = %v, want b failed", err)
+	}
+}
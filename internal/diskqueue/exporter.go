@@ -0,0 +1,265 @@
+package diskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/last9/go-agent/internal/logging"
+	"github.com/last9/go-agent/internal/otlpclient"
+	"github.com/last9/go-agent/internal/otlpconv"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+// Replay backoff bounds. The delay doubles after every failed attempt and
+// resets once a batch is delivered. Variables so that tests can shorten them.
+var (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// Options configures the persistent queue of one signal.
+type Options struct {
+	// Dir is the queue root; each signal uses its own subdirectory.
+	Dir string
+	// MaxBytes bounds the queued data per signal. Zero means no limit.
+	MaxBytes int64
+	// MaxAge is how long a batch is kept before it is dropped. Zero means no limit.
+	MaxAge time.Duration
+}
+
+// Sender delivers a serialized export request. *otlpclient.Client implements it.
+type Sender interface {
+	Send(ctx context.Context, payload []byte) error
+}
+
+// spool routes batches to the exporter while it is healthy and to the queue
+// otherwise, and replays queued batches in the background.
+type spool struct {
+	queue  *Queue
+	sender Sender
+
+	mu      sync.Mutex
+	spooled bool // whether the queue was non-empty at the last check, for logging
+
+	wake     chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+func newSpool(dir string, opts Options, sender Sender) (*spool, error) {
+	q, err := Open(dir, opts.MaxBytes, opts.MaxAge)
+	if err != nil {
+		return nil, err
+	}
+	s := &spool{
+		queue:  q,
+		sender: sender,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if q.Len() > 0 {
+		logging.Infof("Replaying %d batches left in persistent queue %s", q.Len(), dir)
+		s.spooled = true
+		s.notify()
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// export sends a batch with direct, or queues it when direct fails or older
+// batches are still waiting, so that batches are delivered in order.
+// encode is only called when the batch must be queued.
+func (s *spool) export(ctx context.Context, direct func(context.Context) error, encode func() ([]byte, error)) error {
+	var exportErr error
+	if s.queue.Len() == 0 {
+		if exportErr = direct(ctx); exportErr == nil {
+			return nil
+		}
+	}
+
+	data, err := encode()
+	if err != nil {
+		return errors.Join(exportErr, fmt.Errorf("encode batch: %w", err))
+	}
+	if err := s.queue.Put(data); err != nil {
+		return errors.Join(exportErr, fmt.Errorf("persistent queue: %w", err))
+	}
+
+	s.mu.Lock()
+	if !s.spooled && exportErr != nil {
+		logging.Warnf("Export failed, queueing batches in %s until the endpoint recovers: %v",
+			s.queue.Dir(), exportErr)
+	}
+	s.spooled = true
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+func (s *spool) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run replays queued batches until stop is called. While the endpoint is
+// failing it retries with exponential backoff, ignoring new batches.
+func (s *spool) run() {
+	defer s.wg.Done()
+
+	backoff := minBackoff
+	var retry <-chan time.Time
+	for {
+		wake := s.wake
+		if retry != nil {
+			wake = nil
+		}
+		select {
+		case <-s.done:
+			return
+		case <-wake:
+		case <-retry:
+		}
+
+		if s.drain() {
+			backoff = minBackoff
+			retry = nil
+			continue
+		}
+		retry = time.After(backoff)
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// drain sends queued batches oldest first. It returns false when the sender
+// failed with a retryable error, leaving the batch queued.
+func (s *spool) drain() bool {
+	sent := 0
+	for {
+		select {
+		case <-s.done:
+			return true
+		default:
+		}
+
+		name, data, ok := s.queue.Peek()
+		if !ok {
+			break
+		}
+		err := s.sender.Send(context.Background(), data)
+		if err != nil && !errors.Is(err, otlpclient.ErrPermanent) {
+			return false
+		}
+		if err != nil {
+			logging.Errorf("Dropping queued batch %s rejected by the endpoint: %v", name, err)
+		} else {
+			sent++
+		}
+		s.queue.Remove(name)
+	}
+
+	s.mu.Lock()
+	if s.spooled && s.queue.Len() == 0 {
+		logging.Infof("Endpoint recovered, replayed %d queued batches from %s", sent, s.queue.Dir())
+		s.spooled = false
+	}
+	s.mu.Unlock()
+	return true
+}
+
+// stop ends the replay loop and closes the sender if it is an io.Closer.
+// Batches still queued stay on disk and are replayed by the next process
+// that opens the directory.
+func (s *spool) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		if c, ok := s.sender.(io.Closer); ok {
+			_ = c.Close()
+		}
+	})
+}
+
+// TraceExporter is a span exporter backed by a persistent queue.
+type TraceExporter struct {
+	next  sdktrace.SpanExporter
+	spool *spool
+}
+
+var _ sdktrace.SpanExporter = (*TraceExporter)(nil)
+
+// NewTraceExporter wraps next so that batches it fails to export are queued
+// under opts.Dir/traces and replayed through sender. next should not retry
+// on its own, or each batch is held back for the whole retry period first.
+func NewTraceExporter(next sdktrace.SpanExporter, sender Sender, opts Options) (*TraceExporter, error) {
+	s, err := newSpool(filepath.Join(opts.Dir, string(otlpclient.Traces)), opts, sender)
+	if err != nil {
+		return nil, err
+	}
+	return &TraceExporter{next: next, spool: s}, nil
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *TraceExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return e.spool.export(ctx,
+		func(ctx context.Context) error { return e.next.ExportSpans(ctx, spans) },
+		func() ([]byte, error) { return proto.Marshal(otlpconv.Spans(spans)) },
+	)
+}
+
+// Shutdown stops replaying and shuts down the wrapped exporter.
+func (e *TraceExporter) Shutdown(ctx context.Context) error {
+	e.spool.stop()
+	return e.next.Shutdown(ctx)
+}
+
+// MetricExporter is a metric exporter backed by a persistent queue.
+type MetricExporter struct {
+	metric.Exporter
+	spool *spool
+}
+
+var _ metric.Exporter = (*MetricExporter)(nil)
+
+// NewMetricExporter wraps next so that batches it fails to export are queued
+// under opts.Dir/metrics and replayed through sender. next should not retry
+// on its own, or each batch is held back for the whole retry period first.
+func NewMetricExporter(next metric.Exporter, sender Sender, opts Options) (*MetricExporter, error) {
+	s, err := newSpool(filepath.Join(opts.Dir, string(otlpclient.Metrics)), opts, sender)
+	if err != nil {
+		return nil, err
+	}
+	return &MetricExporter{Exporter: next, spool: s}, nil
+}
+
+// Export implements metric.Exporter. rm is encoded before Export returns,
+// as the reader reuses it for the next collection.
+func (e *MetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	return e.spool.export(ctx,
+		func(ctx context.Context) error { return e.Exporter.Export(ctx, rm) },
+		func() ([]byte, error) {
+			req, err := otlpconv.Metrics(rm)
+			if err != nil {
+				logging.Warnf("Queueing metrics batch without some metrics: %v", err)
+			}
+			return proto.Marshal(req)
+		},
+	)
+}
+
+// Shutdown stops replaying and shuts down the wrapped exporter.
+func (e *MetricExporter) Shutdown(ctx context.Context) error {
+	e.spool.stop()
+	return e.Exporter.Shutdown(ctx)
+}
@@ -0,0 +1,233 @@
+package diskqueue
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/last9/go-agent/internal/otlpclient"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeCollector is an OTLP/HTTP endpoint that can be taken down, answering
+// 503 while it is down.
+type fakeCollector struct {
+	*httptest.Server
+	up atomic.Bool
+
+	mu      sync.Mutex
+	spans   []string
+	metrics []string
+}
+
+func newFakeCollector(t *testing.T) *fakeCollector {
+	t.Helper()
+	c := &fakeCollector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/traces":
+			req := &coltracepb.ExportTraceServiceRequest{}
+			_ = proto.Unmarshal(body, req)
+			for _, rs := range req.ResourceSpans {
+				for _, ss := range rs.ScopeSpans {
+					for _, s := range ss.Spans {
+						c.spans = append(c.spans, s.Name)
+					}
+				}
+			}
+		case "/v1/metrics":
+			req := &colmetricpb.ExportMetricsServiceRequest{}
+			_ = proto.Unmarshal(body, req)
+			for _, rm := range req.ResourceMetrics {
+				for _, sm := range rm.ScopeMetrics {
+					for _, m := range sm.Metrics {
+						c.metrics = append(c.metrics, m.Name)
+					}
+				}
+			}
+		}
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *fakeCollector) spanNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.spans...)
+}
+
+func (c *fakeCollector) metricNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.metrics...)
+}
+
+// fastBackoff shortens the replay backoff for the duration of a test.
+func fastBackoff(t *testing.T) {
+	t.Helper()
+	oldMin, oldMax := minBackoff, maxBackoff
+	minBackoff, maxBackoff = 10*time.Millisecond, 50*time.Millisecond
+	t.Cleanup(func() { minBackoff, maxBackoff = oldMin, oldMax })
+}
+
+func newTestTraceExporter(t *testing.T, c *fakeCollector, dir string) *TraceExporter {
+	t.Helper()
+	next, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(c.URL+"/v1/traces"),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := otlpclient.New(otlpclient.Traces, otlpclient.ProtocolHTTPProtobuf, c.URL+"/v1/traces", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := NewTraceExporter(next, sender, Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewTraceExporter() error = %v", err)
+	}
+	t.Cleanup(func() { _ = exp.Shutdown(context.Background()) })
+	return exp
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTraceExporterSurvivesOutage(t *testing.T) {
+	fastBackoff(t)
+	c := newFakeCollector(t)
+	exp := newTestTraceExporter(t, c, t.TempDir())
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tracer := tp.Tracer("test")
+
+	for _, name := range []string{"a", "b"} {
+		_, s := tracer.Start(context.Background(), name)
+		s.End()
+	}
+	if got := exp.spool.queue.Len(); got != 2 {
+		t.Fatalf("queued %d batches during the outage, want 2", got)
+	}
+	if got := c.spanNames(); len(got) != 0 {
+		t.Fatalf("collector received %v while down", got)
+	}
+
+	c.up.Store(true)
+	waitFor(t, "the queue to drain", func() bool { return exp.spool.queue.Len() == 0 })
+	_, s := tracer.Start(context.Background(), "c")
+	s.End()
+
+	if got := c.spanNames(); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("collector received %v, want [a b c] in order", got)
+	}
+}
+
+func TestTraceExporterReplaysAfterRestart(t *testing.T) {
+	fastBackoff(t)
+	c := newFakeCollector(t)
+	dir := t.TempDir()
+
+	exp := newTestTraceExporter(t, c, dir)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	_, s := tp.Tracer("test").Start(context.Background(), "before-restart")
+	s.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	c.up.Store(true)
+	newTestTraceExporter(t, c, dir)
+	waitFor(t, "the batch to be replayed", func() bool { return len(c.spanNames()) == 1 })
+	if got := c.spanNames(); got[0] != "before-restart" {
+		t.Errorf("collector received %v, want [before-restart]", got)
+	}
+}
+
+func TestTraceExporterDropsRejectedBatches(t *testing.T) {
+	fastBackoff(t)
+	dir := t.TempDir()
+	sender := senderFunc(func(context.Context, []byte) error {
+		return otlpclient.ErrPermanent
+	})
+	q, _ := Open(dir+"/traces", 0, 0)
+	_ = q.Put([]byte("malformed"))
+
+	exp, err := NewTraceExporter(noopSpanExporter{}, sender, Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewTraceExporter() error = %v", err)
+	}
+	defer func() { _ = exp.Shutdown(context.Background()) }()
+	waitFor(t, "the rejected batch to be dropped", func() bool { return exp.spool.queue.Len() == 0 })
+}
+
+func TestMetricExporterSurvivesOutage(t *testing.T) {
+	fastBackoff(t)
+	c := newFakeCollector(t)
+	next, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpointURL(c.URL+"/v1/metrics"),
+		otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{Enabled: false}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, _ := otlpclient.New(otlpclient.Metrics, otlpclient.ProtocolHTTPProtobuf, c.URL+"/v1/metrics", nil)
+	exp, err := NewMetricExporter(next, sender, Options{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewMetricExporter() error = %v", err)
+	}
+
+	reader := sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(time.Hour))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	counter, _ := mp.Meter("test").Int64Counter("requests")
+	counter.Add(context.Background(), 1)
+
+	if err := mp.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush() error = %v, want nil (batch queued)", err)
+	}
+	if got := exp.spool.queue.Len(); got != 1 {
+		t.Fatalf("queued %d batches during the outage, want 1", got)
+	}
+
+	c.up.Store(true)
+	waitFor(t, "the metrics to be replayed", func() bool { return len(c.metricNames()) == 1 })
+	if got := c.metricNames(); got[0] != "requests" {
+		t.Errorf("collector received %v, want [requests]", got)
+	}
+}
+
+type senderFunc func(context.Context, []byte) error
+
+func (f senderFunc) Send(ctx context.Context, payload []byte) error { return f(ctx, payload) }
+
+type noopSpanExporter struct{}
+
+func (noopSpanExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error { return nil }
+func (noopSpanExporter) Shutdown(context.Context) error                             { return nil }
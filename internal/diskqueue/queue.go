@@ -0,0 +1,195 @@
+// Package diskqueue spools OTLP export requests to disk while the collector
+// is unreachable and replays them, oldest first, once it recovers.
+package diskqueue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/go-agent/internal/logging"
+)
+
+// fileExt is the extension of queued batches. Partially written batches use
+// a ".tmp" suffix until they are renamed into place.
+const fileExt = ".pb"
+
+// Queue is a bounded directory of serialized export requests, one per file.
+// File names start with the enqueue time in nanoseconds so that sorting them
+// yields FIFO order, including across process restarts.
+type Queue struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	seq   uint64
+	files []queuedFile // oldest first
+	size  int64
+}
+
+type queuedFile struct {
+	name     string
+	size     int64
+	enqueued time.Time
+}
+
+// Open creates dir if needed and loads any batches left by a previous run.
+// maxBytes bounds the total size of queued batches and maxAge how long a
+// batch is kept; the oldest batches are dropped first. Zero disables a limit.
+func Open(dir string, maxBytes int64, maxAge time.Duration) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create queue directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read queue directory: %w", err)
+	}
+
+	q := &Queue{dir: dir, maxBytes: maxBytes, maxAge: maxAge, now: time.Now}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			_ = os.Remove(filepath.Join(dir, name)) // interrupted write
+			continue
+		}
+		enqueued, ok := parseName(name)
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		q.files = append(q.files, queuedFile{name: name, size: info.Size(), enqueued: enqueued})
+		q.size += info.Size()
+	}
+	sort.Slice(q.files, func(i, j int) bool { return q.files[i].name < q.files[j].name })
+
+	q.mu.Lock()
+	q.enforceLimits()
+	q.mu.Unlock()
+	return q, nil
+}
+
+// Put writes data as the newest batch, then drops the oldest batches if the
+// queue is over its limits.
+func (q *Queue) Put(data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d%s", now.UnixNano(), q.seq%1000000, fileExt)
+	path := filepath.Join(q.dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return fmt.Errorf("write batch: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return fmt.Errorf("write batch: %w", err)
+	}
+
+	q.files = append(q.files, queuedFile{name: name, size: int64(len(data)), enqueued: now})
+	q.size += int64(len(data))
+	q.enforceLimits()
+	return nil
+}
+
+// Peek returns the oldest batch and its name, or ok=false when the queue is
+// empty. Batches that can no longer be read are dropped.
+func (q *Queue) Peek() (name string, data []byte, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.enforceLimits()
+	for len(q.files) > 0 {
+		f := q.files[0]
+		data, err := os.ReadFile(filepath.Join(q.dir, f.name))
+		if err == nil {
+			return f.name, data, true
+		}
+		logging.Errorf("Dropping unreadable queued batch %s: %v", f.name, err)
+		q.removeFirst()
+	}
+	return "", nil, false
+}
+
+// Remove deletes the batch returned by Peek once it has been delivered.
+func (q *Queue) Remove(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, f := range q.files {
+		if f.name == name {
+			_ = os.Remove(filepath.Join(q.dir, name))
+			q.size -= f.size
+			q.files = append(q.files[:i], q.files[i+1:]...)
+			return
+		}
+	}
+}
+
+// Len returns the number of queued batches.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.files)
+}
+
+// Dir returns the queue directory.
+func (q *Queue) Dir() string {
+	return q.dir
+}
+
+// enforceLimits drops batches older than maxAge, then the oldest batches
+// until the queue fits in maxBytes. The newest batch is always kept.
+// q.mu must be held.
+func (q *Queue) enforceLimits() {
+	dropped := 0
+	if q.maxAge > 0 {
+		cutoff := q.now().Add(-q.maxAge)
+		for len(q.files) > 0 && q.files[0].enqueued.Before(cutoff) {
+			q.removeFirst()
+			dropped++
+		}
+	}
+	if q.maxBytes > 0 {
+		for len(q.files) > 1 && q.size > q.maxBytes {
+			q.removeFirst()
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		logging.Errorf("Persistent queue %s is over its limits, dropped %d oldest batches", q.dir, dropped)
+	}
+}
+
+// removeFirst deletes the oldest batch. q.mu must be held.
+func (q *Queue) removeFirst() {
+	f := q.files[0]
+	_ = os.Remove(filepath.Join(q.dir, f.name))
+	q.size -= f.size
+	q.files = q.files[1:]
+}
+
+// parseName returns the enqueue time encoded in a batch file name.
+func parseName(name string) (time.Time, bool) {
+	base, ok := strings.CutSuffix(name, fileExt)
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, _, _ := strings.Cut(base, "-")
+	ns, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
@@ -0,0 +1,103 @@
+package diskqueue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueFIFO(t *testing.T) {
+	q, err := Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, s := range []string{"a", "b", "c"} {
+		if err := q.Put([]byte(s)); err != nil {
+			t.Fatalf("Put(%q) error = %v", s, err)
+		}
+	}
+
+	var got string
+	for {
+		name, data, ok := q.Peek()
+		if !ok {
+			break
+		}
+		got += string(data)
+		q.Remove(name)
+	}
+	if got != "abc" {
+		t.Errorf("dequeued %q, want abc", got)
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() = %d after draining, want 0", q.Len())
+	}
+}
+
+func TestQueueReopen(t *testing.T) {
+	dir := t.TempDir()
+	q, _ := Open(dir, 0, 0)
+	_ = q.Put([]byte("first"))
+	_ = q.Put([]byte("second"))
+	if err := os.WriteFile(filepath.Join(dir, "x.pb.tmp"), []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d after reopening, want 2", q.Len())
+	}
+	if _, data, _ := q.Peek(); string(data) != "first" {
+		t.Errorf("Peek() = %q, want first", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x.pb.tmp")); !os.IsNotExist(err) {
+		t.Error("partial write was not removed on Open")
+	}
+}
+
+func TestQueueMaxBytes(t *testing.T) {
+	q, _ := Open(t.TempDir(), 10, 0)
+	for _, s := range []string{"aaaa", "bbbb", "cccc"} {
+		_ = q.Put([]byte(s))
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2 (oldest dropped to fit 10 bytes)", q.Len())
+	}
+	if _, data, _ := q.Peek(); string(data) != "bbbb" {
+		t.Errorf("Peek() = %q, want bbbb", data)
+	}
+
+	// A batch larger than the limit is still kept on its own.
+	_ = q.Put(make([]byte, 20))
+	if q.Len() != 1 {
+		t.Errorf("Len() = %d after an oversized batch, want 1", q.Len())
+	}
+}
+
+func TestQueueMaxAge(t *testing.T) {
+	now := time.Now()
+	q, _ := Open(t.TempDir(), 0, time.Hour)
+	q.now = func() time.Time { return now }
+	_ = q.Put([]byte("old"))
+
+	now = now.Add(30 * time.Minute)
+	_ = q.Put([]byte("new"))
+
+	now = now.Add(45 * time.Minute)
+	name, data, ok := q.Peek()
+	if !ok || string(data) != "new" {
+		t.Fatalf("Peek() = %q, %v, want new (old expired)", data, ok)
+	}
+	q.Remove(name)
+
+	now = now.Add(2 * time.Hour)
+	_ = q.Put([]byte("x"))
+	now = now.Add(2 * time.Hour)
+	if _, _, ok := q.Peek(); ok {
+		t.Error("Peek() returned an expired batch")
+	}
+}
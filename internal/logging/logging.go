@@ -0,0 +1,149 @@
+// Package logging is the agent's internal logger. Every package of the agent
+// logs through it, so that its messages can be routed to the application's
+// own slog logger (agent.WithLogger) and filtered by level (LAST9_LOG_LEVEL).
+//
+// By default messages go to the standard log package, as
+// "[Last9 Agent] Warning: ..." lines. The logger and level are process-wide:
+// the agent created last with WithLogger or LAST9_LOG_LEVEL sets them for
+// all agents and instrumentation packages.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	level  slog.LevelVar // the zero value is slog.LevelInfo
+	logger atomic.Pointer[slog.Logger]
+
+	// held buffers messages while any Hold is in effect; holding mirrors
+	// holds > 0 so that logging stays lock-free the rest of the time.
+	holding atomic.Bool
+	heldMu  sync.Mutex
+	holds   int
+	held    []slog.Record
+)
+
+func init() {
+	logger.Store(slog.New(stdHandler{}))
+}
+
+// SetLogger sends the agent's messages to l, tagged with
+// component=last9-agent. A nil l restores the standard log package.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(stdHandler{})
+	} else {
+		l = l.With(slog.String("component", "last9-agent"))
+	}
+	logger.Store(l)
+}
+
+// SetLevel discards messages below lvl.
+func SetLevel(lvl slog.Level) {
+	level.Set(lvl)
+}
+
+// Enabled reports whether messages at lvl are logged, so that callers can
+// skip building debug messages on hot paths.
+func Enabled(lvl slog.Level) bool {
+	return lvl >= level.Level() || holding.Load()
+}
+
+// Hold buffers messages until the returned function is called, which passes
+// them to the logger and level in effect at that point. The agent holds
+// messages while it loads its configuration, so that warnings about the
+// configuration reach the logger the configuration sets. Overlapping holds
+// release their messages when the last one ends.
+func Hold() (release func()) {
+	heldMu.Lock()
+	holds++
+	holding.Store(true)
+	heldMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			heldMu.Lock()
+			var records []slog.Record
+			if holds--; holds == 0 {
+				records, held = held, nil
+				holding.Store(false)
+			}
+			heldMu.Unlock()
+			for _, r := range records {
+				emit(r)
+			}
+		})
+	}
+}
+
+// Debugf logs a message for troubleshooting the agent itself, such as
+// sampler decisions and export batches.
+func Debugf(format string, args ...any) { logf(slog.LevelDebug, format, args...) }
+
+// Infof logs a message about the agent's normal operation.
+func Infof(format string, args ...any) { logf(slog.LevelInfo, format, args...) }
+
+// Warnf logs a problem the agent worked around, such as an invalid setting
+// replaced by its default.
+func Warnf(format string, args ...any) { logf(slog.LevelWarn, format, args...) }
+
+// Errorf logs a failure that loses telemetry.
+func Errorf(format string, args ...any) { logf(slog.LevelError, format, args...) }
+
+func logf(lvl slog.Level, format string, args ...any) {
+	if !Enabled(lvl) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), lvl, fmt.Sprintf(format, args...), 0)
+	if holding.Load() {
+		heldMu.Lock()
+		if holding.Load() {
+			held = append(held, r)
+			heldMu.Unlock()
+			return
+		}
+		heldMu.Unlock()
+	}
+	emit(r)
+}
+
+func emit(r slog.Record) {
+	if r.Level < level.Level() {
+		return
+	}
+	ctx := context.Background()
+	if h := logger.Load().Handler(); h.Enabled(ctx, r.Level) {
+		_ = h.Handle(ctx, r)
+	}
+}
+
+// stdHandler writes messages through the standard log package, in the
+// format the agent has always used.
+type stdHandler struct{}
+
+func (stdHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (stdHandler) Handle(_ context.Context, r slog.Record) error {
+	var prefix string
+	switch {
+	case r.Level >= slog.LevelError:
+		prefix = "Error: "
+	case r.Level >= slog.LevelWarn:
+		prefix = "Warning: "
+	case r.Level < slog.LevelInfo:
+		prefix = "Debug: "
+	}
+	log.Print("[Last9 Agent] " + prefix + r.Message)
+	return nil
+}
+
+func (h stdHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h stdHandler) WithGroup(string) slog.Handler      { return h }
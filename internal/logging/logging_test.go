@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// capture sends messages to a text handler for the duration of the test
+// and returns its output.
+func capture(t *testing.T, lvl slog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	SetLevel(lvl)
+	t.Cleanup(func() {
+		SetLogger(nil)
+		SetLevel(slog.LevelInfo)
+	})
+	return &buf
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  []string
+	}{
+		{slog.LevelDebug, []string{"level=DEBUG", "level=INFO", "level=WARN", "level=ERROR"}},
+		{slog.LevelInfo, []string{"level=INFO", "level=WARN", "level=ERROR"}},
+		{slog.LevelWarn, []string{"level=WARN", "level=ERROR"}},
+		{slog.LevelError, []string{"level=ERROR"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			buf := capture(t, tt.level)
+			Debugf("debug %d", 1)
+			Infof("info %d", 2)
+			Warnf("warn %d", 3)
+			Errorf("error %d", 4)
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d messages, want %d:\n%s", len(lines), len(tt.want), buf)
+			}
+			for i, line := range lines {
+				if !strings.Contains(line, tt.want[i]) || !strings.Contains(line, "component=last9-agent") {
+					t.Errorf("message %d = %q, want %s and component=last9-agent", i, line, tt.want[i])
+				}
+			}
+			if got := Enabled(slog.LevelDebug); got != (tt.level == slog.LevelDebug) {
+				t.Errorf("Enabled(debug) = %t", got)
+			}
+		})
+	}
+}
+
+func TestHold(t *testing.T) {
+	buf := capture(t, slog.LevelInfo)
+
+	release := Hold()
+	inner := Hold()
+	Warnf("held")
+	Debugf("held debug")
+	inner()
+	if buf.Len() != 0 {
+		t.Fatalf("message logged while held: %s", buf)
+	}
+
+	// Released messages go to the logger and level in effect at release.
+	var next bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&next, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	SetLevel(slog.LevelDebug)
+	release()
+	release() // no-op
+	if buf.Len() != 0 {
+		t.Errorf("held messages went to the previous logger: %s", buf)
+	}
+	if out := next.String(); !strings.Contains(out, "msg=held") || !strings.Contains(out, `msg="held debug"`) {
+		t.Errorf("released messages = %q, want both held messages", out)
+	}
+
+	next.Reset()
+	Infof("after")
+	if !strings.Contains(next.String(), "msg=after") {
+		t.Errorf("message after release = %q, want it logged", next.String())
+	}
+}
+
+func TestDefaultFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+	SetLevel(slog.LevelDebug)
+	defer SetLevel(slog.LevelInfo)
+
+	Debugf("a")
+	Infof("b")
+	Warnf("c")
+	Errorf("d")
+
+	want := "[Last9 Agent] Debug: a\n[Last9 Agent] b\n[Last9 Agent] Warning: c\n[Last9 Agent] Error: d\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,173 @@
+// Package otlpclient sends already-serialized OTLP export requests to a
+// collector over http/protobuf or gRPC. It is used to replay batches that
+// were written to disk while the collector was unreachable.
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Signal identifies the kind of export request a Client sends.
+type Signal string
+
+// Supported signals.
+const (
+	Traces  Signal = "traces"
+	Metrics Signal = "metrics"
+)
+
+// Supported protocols, matching config.ProtocolGRPC and config.ProtocolHTTPProtobuf.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// defaultTimeout bounds a single Send when ctx has no deadline.
+const defaultTimeout = 10 * time.Second
+
+// ErrPermanent marks errors that retrying will not fix, such as a request
+// rejected as malformed. Test with errors.Is.
+var ErrPermanent = errors.New("permanent export error")
+
+// Client sends serialized ExportTraceServiceRequest or
+// ExportMetricsServiceRequest messages for one signal.
+type Client struct {
+	signal   Signal
+	endpoint string
+	headers  map[string]string
+
+	httpClient *http.Client
+	conn       *grpc.ClientConn
+}
+
+// New returns a Client for signal. For http/protobuf, endpoint is the full
+// URL including the signal path (e.g. https://collector:4318/v1/traces); for
+// grpc it is a URL whose scheme selects TLS (https) or plaintext (http).
+func New(signal Signal, protocol, endpoint string, headers map[string]string) (*Client, error) {
+	if signal != Traces && signal != Metrics {
+		return nil, fmt.Errorf("unsupported signal %q", signal)
+	}
+	c := &Client{signal: signal, endpoint: endpoint, headers: headers}
+	if protocol != ProtocolGRPC {
+		c.httpClient = &http.Client{}
+		return c, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	creds := insecure.NewCredentials()
+	if u.Scheme == "https" {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	c.conn, err = grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("create grpc client: %w", err)
+	}
+	return c, nil
+}
+
+// Send exports one serialized request. Errors wrap ErrPermanent when the
+// collector rejected the request in a way that a retry will not fix.
+func (c *Client) Send(ctx context.Context, payload []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+	if c.conn != nil {
+		return c.sendGRPC(ctx, payload)
+	}
+	return c.sendHTTP(ctx, payload)
+}
+
+// Close releases the gRPC connection, if any.
+func (c *Client) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+func (c *Client) sendHTTP(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		return fmt.Errorf("export failed: %s", resp.Status)
+	default:
+		return fmt.Errorf("%w: %s", ErrPermanent, resp.Status)
+	}
+}
+
+func (c *Client) sendGRPC(ctx context.Context, payload []byte) error {
+	if len(c.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(c.headers))
+	}
+
+	var err error
+	switch c.signal {
+	case Traces:
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if uErr := proto.Unmarshal(payload, req); uErr != nil {
+			return fmt.Errorf("%w: %v", ErrPermanent, uErr)
+		}
+		_, err = coltracepb.NewTraceServiceClient(c.conn).Export(ctx, req)
+	case Metrics:
+		req := &colmetricpb.ExportMetricsServiceRequest{}
+		if uErr := proto.Unmarshal(payload, req); uErr != nil {
+			return fmt.Errorf("%w: %v", ErrPermanent, uErr)
+		}
+		_, err = colmetricpb.NewMetricsServiceClient(c.conn).Export(ctx, req)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// Retryable codes per the OTLP specification.
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange,
+		codes.Unavailable, codes.DataLoss, codes.ResourceExhausted:
+		return err
+	default:
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+}
@@ -0,0 +1,162 @@
+package otlpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSendHTTP(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{"ok", http.StatusOK, false, false},
+		{"unavailable", http.StatusServiceUnavailable, true, false},
+		{"too many requests", http.StatusTooManyRequests, true, false},
+		{"bad request", http.StatusBadRequest, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody []byte
+			var gotHeader, gotType string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotBody, _ = io.ReadAll(r.Body)
+				gotHeader = r.Header.Get("Authorization")
+				gotType = r.Header.Get("Content-Type")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			c, err := New(Traces, ProtocolHTTPProtobuf, srv.URL+"/v1/traces", map[string]string{"Authorization": "Basic x"})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			err = c.Send(context.Background(), []byte("payload"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrPermanent); got != tt.wantPermanent {
+				t.Errorf("errors.Is(err, ErrPermanent) = %v, want %v", got, tt.wantPermanent)
+			}
+			if string(gotBody) != "payload" || gotHeader != "Basic x" || gotType != "application/x-protobuf" {
+				t.Errorf("server got body %q, Authorization %q, Content-Type %q", gotBody, gotHeader, gotType)
+			}
+		})
+	}
+}
+
+func TestSendHTTPConnectionRefused(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	c, _ := New(Metrics, ProtocolHTTPProtobuf, url+"/v1/metrics", nil)
+	err := c.Send(context.Background(), []byte("payload"))
+	if err == nil || errors.Is(err, ErrPermanent) {
+		t.Errorf("Send() error = %v, want a retryable error", err)
+	}
+}
+
+type traceServer struct {
+	coltracepb.UnimplementedTraceServiceServer
+	code    codes.Code
+	got     chan *coltracepb.ExportTraceServiceRequest
+	headers chan metadata.MD
+}
+
+func (s *traceServer) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.headers <- md
+	s.got <- req
+	if s.code != codes.OK {
+		return nil, status.Error(s.code, "rejected")
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestSendGRPC(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          codes.Code
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{"ok", codes.OK, false, false},
+		{"unavailable", codes.Unavailable, true, false},
+		{"invalid argument", codes.InvalidArgument, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts := &traceServer{
+				code:    tt.code,
+				got:     make(chan *coltracepb.ExportTraceServiceRequest, 1),
+				headers: make(chan metadata.MD, 1),
+			}
+			srv := grpc.NewServer()
+			coltracepb.RegisterTraceServiceServer(srv, ts)
+			go func() { _ = srv.Serve(lis) }()
+			defer srv.Stop()
+
+			c, err := New(Traces, ProtocolGRPC, "http://"+lis.Addr().String(), map[string]string{"authorization": "Basic x"})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+
+			payload, _ := proto.Marshal(&coltracepb.ExportTraceServiceRequest{
+				ResourceSpans: []*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{
+					Spans: []*tracepb.Span{{Name: "span"}},
+				}}}},
+			})
+			err = c.Send(context.Background(), payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrPermanent); got != tt.wantPermanent {
+				t.Errorf("errors.Is(err, ErrPermanent) = %v, want %v", got, tt.wantPermanent)
+			}
+			if req := <-ts.got; req.ResourceSpans[0].ScopeSpans[0].Spans[0].Name != "span" {
+				t.Errorf("server got %v, want the span", req)
+			}
+			if md := <-ts.headers; len(md.Get("authorization")) != 1 || md.Get("authorization")[0] != "Basic x" {
+				t.Errorf("server got metadata %v, want authorization", md)
+			}
+		})
+	}
+}
+
+func TestSendGRPCInvalidPayload(t *testing.T) {
+	c, err := New(Traces, ProtocolGRPC, "http://127.0.0.1:1", nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Send(context.Background(), []byte{0xff}); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Send() error = %v, want ErrPermanent", err)
+	}
+}
+
+func TestNewUnsupportedSignal(t *testing.T) {
+	if _, err := New("logs", ProtocolHTTPProtobuf, "http://localhost", nil); err == nil {
+		t.Error("New() error = nil, want an error for an unsupported signal")
+	}
+}
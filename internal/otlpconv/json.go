@@ -0,0 +1,87 @@
+package otlpconv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// idFields are the OTLP fields holding trace and span IDs, which the OTLP
+// JSON encoding writes as hex strings rather than protobuf's base64.
+var idFields = map[string]bool{
+	"traceId":      true,
+	"spanId":       true,
+	"parentSpanId": true,
+}
+
+// MarshalJSON encodes an OTLP message in the OTLP/JSON format: lowerCamelCase
+// field names, enums as integers and hex trace and span IDs. The output is a
+// single line.
+func MarshalJSON(m proto.Message) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return convertIDs(data, func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return hex.EncodeToString(b), err
+	})
+}
+
+// UnmarshalJSON decodes an OTLP/JSON message written by MarshalJSON or by
+// the OpenTelemetry Collector's file exporter.
+func UnmarshalJSON(data []byte, m proto.Message) error {
+	data, err := convertIDs(data, func(s string) (string, error) {
+		b, err := hex.DecodeString(s)
+		return base64.StdEncoding.EncodeToString(b), err
+	})
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+}
+
+// convertIDs rewrites every ID field of the JSON document data with conv.
+func convertIDs(data []byte, conv func(string) (string, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if err := walkIDs(doc, conv); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+func walkIDs(v any, conv func(string) (string, error)) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if s, ok := child.(string); ok && idFields[k] {
+				id, err := conv(s)
+				if err != nil {
+					return fmt.Errorf("invalid %s %q: %w", k, s, err)
+				}
+				v[k] = id
+				continue
+			}
+			if err := walkIDs(child, conv); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := walkIDs(child, conv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package otlpconv
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	_, child := tp.Tracer("test").Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	req := Spans(rec.Ended())
+	data, err := MarshalJSON(req)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if bytes.ContainsRune(data, '\n') {
+		t.Error("MarshalJSON() output spans several lines")
+	}
+	traceID := parent.SpanContext().TraceID().String()
+	spanID := parent.SpanContext().SpanID().String()
+	for _, want := range []string{`"traceId":"` + traceID + `"`, `"parentSpanId":"` + spanID + `"`, `"resourceSpans"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("MarshalJSON() output lacks %s:\n%s", want, data)
+		}
+	}
+
+	got := &coltracepb.ExportTraceServiceRequest{}
+	if err := UnmarshalJSON(data, got); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if !proto.Equal(got, req) {
+		t.Errorf("UnmarshalJSON() = %v, want %v", got, req)
+	}
+}
+
+func TestUnmarshalJSONInvalidID(t *testing.T) {
+	data := []byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"not-hex"}]}]}]}`)
+	if err := UnmarshalJSON(data, &coltracepb.ExportTraceServiceRequest{}); err == nil {
+		t.Error("UnmarshalJSON() error = nil, want an invalid traceId error")
+	}
+}
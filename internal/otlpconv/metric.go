@@ -0,0 +1,224 @@
+package otlpconv
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// Metrics converts rm into an OTLP metrics export request. Aggregations of
+// an unknown type are skipped and reported in the returned error; the
+// request holds everything else.
+func Metrics(rm *metricdata.ResourceMetrics) (*colmetricpb.ExportMetricsServiceRequest, error) {
+	rs := &metricpb.ResourceMetrics{Resource: resourceProto(rm.Resource)}
+	if rm.Resource != nil {
+		rs.SchemaUrl = rm.Resource.SchemaURL()
+	}
+
+	var err error
+	for _, sm := range rm.ScopeMetrics {
+		scope := &metricpb.ScopeMetrics{Scope: scopeProto(sm.Scope), SchemaUrl: sm.Scope.SchemaURL}
+		for _, m := range sm.Metrics {
+			pb, mErr := metricProto(m)
+			if mErr != nil {
+				err = mErr
+				continue
+			}
+			scope.Metrics = append(scope.Metrics, pb)
+		}
+		rs.ScopeMetrics = append(rs.ScopeMetrics, scope)
+	}
+	return &colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: []*metricpb.ResourceMetrics{rs}}, err
+}
+
+func metricProto(m metricdata.Metrics) (*metricpb.Metric, error) {
+	out := &metricpb.Metric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+	switch a := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberPoints(a.DataPoints)}}
+	case metricdata.Gauge[float64]:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberPoints(a.DataPoints)}}
+	case metricdata.Sum[int64]:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             numberPoints(a.DataPoints),
+			AggregationTemporality: temporality(a.Temporality),
+			IsMonotonic:            a.IsMonotonic,
+		}}
+	case metricdata.Sum[float64]:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             numberPoints(a.DataPoints),
+			AggregationTemporality: temporality(a.Temporality),
+			IsMonotonic:            a.IsMonotonic,
+		}}
+	case metricdata.Histogram[int64]:
+		out.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints:             histogramPoints(a.DataPoints),
+			AggregationTemporality: temporality(a.Temporality),
+		}}
+	case metricdata.Histogram[float64]:
+		out.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints:             histogramPoints(a.DataPoints),
+			AggregationTemporality: temporality(a.Temporality),
+		}}
+	case metricdata.ExponentialHistogram[int64]:
+		out.Data = &metricpb.Metric_ExponentialHistogram{ExponentialHistogram: &metricpb.ExponentialHistogram{
+			DataPoints:             exponentialPoints(a.DataPoints),
+			AggregationTemporality: temporality(a.Temporality),
+		}}
+	case metricdata.ExponentialHistogram[float64]:
+		out.Data = &metricpb.Metric_ExponentialHistogram{ExponentialHistogram: &metricpb.ExponentialHistogram{
+			DataPoints:             exponentialPoints(a.DataPoints),
+			AggregationTemporality: temporality(a.Temporality),
+		}}
+	case metricdata.Summary:
+		out.Data = &metricpb.Metric_Summary{Summary: &metricpb.Summary{DataPoints: summaryPoints(a.DataPoints)}}
+	default:
+		return nil, fmt.Errorf("metric %s: unsupported aggregation %T", m.Name, m.Data)
+	}
+	return out, nil
+}
+
+func temporality(t metricdata.Temporality) metricpb.AggregationTemporality {
+	switch t {
+	case metricdata.DeltaTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	case metricdata.CumulativeTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	default:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+	}
+}
+
+func numberPoints[N int64 | float64](points []metricdata.DataPoint[N]) []*metricpb.NumberDataPoint {
+	out := make([]*metricpb.NumberDataPoint, 0, len(points))
+	for _, p := range points {
+		pb := &metricpb.NumberDataPoint{
+			Attributes:        keyValues(p.Attributes.ToSlice()),
+			StartTimeUnixNano: timeUnixNano(p.StartTime),
+			TimeUnixNano:      timeUnixNano(p.Time),
+			Exemplars:         exemplars(p.Exemplars),
+		}
+		switch v := any(p.Value).(type) {
+		case int64:
+			pb.Value = &metricpb.NumberDataPoint_AsInt{AsInt: v}
+		case float64:
+			pb.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: v}
+		}
+		out = append(out, pb)
+	}
+	return out
+}
+
+func histogramPoints[N int64 | float64](points []metricdata.HistogramDataPoint[N]) []*metricpb.HistogramDataPoint {
+	out := make([]*metricpb.HistogramDataPoint, 0, len(points))
+	for _, p := range points {
+		sum := float64(p.Sum)
+		out = append(out, &metricpb.HistogramDataPoint{
+			Attributes:        keyValues(p.Attributes.ToSlice()),
+			StartTimeUnixNano: timeUnixNano(p.StartTime),
+			TimeUnixNano:      timeUnixNano(p.Time),
+			Count:             p.Count,
+			Sum:               &sum,
+			BucketCounts:      p.BucketCounts,
+			ExplicitBounds:    p.Bounds,
+			Exemplars:         exemplars(p.Exemplars),
+			Min:               extrema(p.Min),
+			Max:               extrema(p.Max),
+		})
+	}
+	return out
+}
+
+func exponentialPoints[N int64 | float64](points []metricdata.ExponentialHistogramDataPoint[N]) []*metricpb.ExponentialHistogramDataPoint {
+	out := make([]*metricpb.ExponentialHistogramDataPoint, 0, len(points))
+	for _, p := range points {
+		sum := float64(p.Sum)
+		out = append(out, &metricpb.ExponentialHistogramDataPoint{
+			Attributes:        keyValues(p.Attributes.ToSlice()),
+			StartTimeUnixNano: timeUnixNano(p.StartTime),
+			TimeUnixNano:      timeUnixNano(p.Time),
+			Count:             p.Count,
+			Sum:               &sum,
+			Scale:             p.Scale,
+			ZeroCount:         p.ZeroCount,
+			ZeroThreshold:     p.ZeroThreshold,
+			Positive: &metricpb.ExponentialHistogramDataPoint_Buckets{
+				Offset:       p.PositiveBucket.Offset,
+				BucketCounts: p.PositiveBucket.Counts,
+			},
+			Negative: &metricpb.ExponentialHistogramDataPoint_Buckets{
+				Offset:       p.NegativeBucket.Offset,
+				BucketCounts: p.NegativeBucket.Counts,
+			},
+			Exemplars: exemplars(p.Exemplars),
+			Min:       extrema(p.Min),
+			Max:       extrema(p.Max),
+		})
+	}
+	return out
+}
+
+func summaryPoints(points []metricdata.SummaryDataPoint) []*metricpb.SummaryDataPoint {
+	out := make([]*metricpb.SummaryDataPoint, 0, len(points))
+	for _, p := range points {
+		pb := &metricpb.SummaryDataPoint{
+			Attributes:        keyValues(p.Attributes.ToSlice()),
+			StartTimeUnixNano: timeUnixNano(p.StartTime),
+			TimeUnixNano:      timeUnixNano(p.Time),
+			Count:             p.Count,
+			Sum:               p.Sum,
+		}
+		for _, q := range p.QuantileValues {
+			pb.QuantileValues = append(pb.QuantileValues, &metricpb.SummaryDataPoint_ValueAtQuantile{
+				Quantile: q.Quantile,
+				Value:    q.Value,
+			})
+		}
+		out = append(out, pb)
+	}
+	return out
+}
+
+func exemplars[N int64 | float64](exs []metricdata.Exemplar[N]) []*metricpb.Exemplar {
+	if len(exs) == 0 {
+		return nil
+	}
+	out := make([]*metricpb.Exemplar, 0, len(exs))
+	for _, e := range exs {
+		pb := &metricpb.Exemplar{
+			FilteredAttributes: keyValues(e.FilteredAttributes),
+			TimeUnixNano:       timeUnixNano(e.Time),
+			SpanId:             e.SpanID,
+			TraceId:            e.TraceID,
+		}
+		switch v := any(e.Value).(type) {
+		case int64:
+			pb.Value = &metricpb.Exemplar_AsInt{AsInt: v}
+		case float64:
+			pb.Value = &metricpb.Exemplar_AsDouble{AsDouble: v}
+		}
+		out = append(out, pb)
+	}
+	return out
+}
+
+// extrema returns a pointer to the min or max value, or nil when it is unset.
+func extrema[N int64 | float64](e metricdata.Extrema[N]) *float64 {
+	v, ok := e.Value()
+	if !ok {
+		return nil
+	}
+	f := float64(v)
+	return &f
+}
+
+// timeUnixNano converts t, mapping the zero time to 0.
+func timeUnixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return unixNano(t.UnixNano())
+}
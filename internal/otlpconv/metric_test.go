@@ -0,0 +1,92 @@
+package otlpconv
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(resource.NewSchemaless(attribute.String("service.name", "checkout"))),
+	)
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	meter := mp.Meter("test")
+
+	counter, _ := meter.Int64Counter("requests")
+	counter.Add(context.Background(), 3, metric.WithAttributes(attribute.String("route", "/a")))
+	hist, _ := meter.Float64Histogram("latency", metric.WithUnit("s"))
+	hist.Record(context.Background(), 0.25)
+	hist.Record(context.Background(), 2)
+	gauge, _ := meter.Float64Gauge("temperature")
+	gauge.Record(context.Background(), 21.5)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	req, err := Metrics(&rm)
+	if err != nil {
+		t.Fatalf("Metrics() error = %v", err)
+	}
+	if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("got %d ResourceMetrics, want 1 with one scope", len(req.ResourceMetrics))
+	}
+	rs := req.ResourceMetrics[0]
+	if got := stringAttr(rs.Resource.Attributes, "service.name"); got != "checkout" {
+		t.Errorf("resource service.name = %q, want checkout", got)
+	}
+
+	metrics := map[string]*metricpb.Metric{}
+	for _, m := range rs.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	sum := metrics["requests"].GetSum()
+	if sum == nil || !sum.IsMonotonic || sum.AggregationTemporality != metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Fatalf("requests = %v, want a cumulative monotonic sum", metrics["requests"])
+	}
+	if dp := sum.DataPoints[0]; dp.GetAsInt() != 3 || stringAttr(dp.Attributes, "route") != "/a" {
+		t.Errorf("requests point = %v, want 3 with route=/a", dp)
+	}
+
+	h := metrics["latency"].GetHistogram()
+	if h == nil || metrics["latency"].Unit != "s" {
+		t.Fatalf("latency = %v, want a histogram in s", metrics["latency"])
+	}
+	dp := h.DataPoints[0]
+	if dp.Count != 2 || dp.GetSum() != 2.25 || dp.GetMin() != 0.25 || dp.GetMax() != 2 {
+		t.Errorf("latency point count=%d sum=%v min=%v max=%v, want 2, 2.25, 0.25, 2", dp.Count, dp.GetSum(), dp.GetMin(), dp.GetMax())
+	}
+	if len(dp.BucketCounts) != len(dp.ExplicitBounds)+1 {
+		t.Errorf("latency has %d buckets for %d bounds", len(dp.BucketCounts), len(dp.ExplicitBounds))
+	}
+
+	if g := metrics["temperature"].GetGauge(); g == nil || g.DataPoints[0].GetAsDouble() != 21.5 {
+		t.Errorf("temperature = %v, want a gauge of 21.5", metrics["temperature"])
+	}
+}
+
+func TestMetricsUnsupportedAggregation(t *testing.T) {
+	rm := &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{{
+		Metrics: []metricdata.Metrics{
+			{Name: "bad", Data: nil},
+			{Name: "good", Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Value: 1}}}},
+		},
+	}}}
+	req, err := Metrics(rm)
+	if err == nil {
+		t.Error("Metrics() error = nil, want an error for the unsupported aggregation")
+	}
+	if got := req.ResourceMetrics[0].ScopeMetrics[0].Metrics; len(got) != 1 || got[0].Name != "good" {
+		t.Errorf("Metrics() kept %v, want only good", got)
+	}
+}
@@ -0,0 +1,120 @@
+package otlpconv
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Spans converts spans into an OTLP trace export request, grouped by
+// resource and instrumentation scope in the order they first appear.
+func Spans(spans []sdktrace.ReadOnlySpan) *coltracepb.ExportTraceServiceRequest {
+	type scopeKey struct {
+		resource attribute.Distinct
+		scope    instrumentation.Scope
+	}
+	resources := make(map[attribute.Distinct]*tracepb.ResourceSpans)
+	scopes := make(map[scopeKey]*tracepb.ScopeSpans)
+
+	req := &coltracepb.ExportTraceServiceRequest{}
+	for _, s := range spans {
+		if s == nil {
+			continue
+		}
+		res := s.Resource()
+		rKey := res.Equivalent()
+		rs, ok := resources[rKey]
+		if !ok {
+			rs = &tracepb.ResourceSpans{Resource: resourceProto(res), SchemaUrl: res.SchemaURL()}
+			resources[rKey] = rs
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+
+		scope := s.InstrumentationScope()
+		sKey := scopeKey{resource: rKey, scope: scope}
+		ss, ok := scopes[sKey]
+		if !ok {
+			ss = &tracepb.ScopeSpans{Scope: scopeProto(scope), SchemaUrl: scope.SchemaURL}
+			scopes[sKey] = ss
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, spanProto(s))
+	}
+	return req
+}
+
+func spanProto(s sdktrace.ReadOnlySpan) *tracepb.Span {
+	sc := s.SpanContext()
+	traceID, spanID := sc.TraceID(), sc.SpanID()
+	out := &tracepb.Span{
+		TraceId:                traceID[:],
+		SpanId:                 spanID[:],
+		TraceState:             sc.TraceState().String(),
+		Flags:                  spanFlags(sc.TraceFlags(), s.Parent().IsRemote()),
+		Name:                   s.Name(),
+		Kind:                   tracepb.Span_SpanKind(s.SpanKind()),
+		StartTimeUnixNano:      unixNano(s.StartTime().UnixNano()),
+		EndTimeUnixNano:        unixNano(s.EndTime().UnixNano()),
+		Attributes:             keyValues(s.Attributes()),
+		DroppedAttributesCount: uint32(s.DroppedAttributes()),
+		DroppedEventsCount:     uint32(s.DroppedEvents()),
+		DroppedLinksCount:      uint32(s.DroppedLinks()),
+		Status:                 statusProto(s.Status()),
+	}
+	if parent := s.Parent().SpanID(); parent.IsValid() {
+		out.ParentSpanId = parent[:]
+	}
+	for _, e := range s.Events() {
+		out.Events = append(out.Events, &tracepb.Span_Event{
+			TimeUnixNano:           unixNano(e.Time.UnixNano()),
+			Name:                   e.Name,
+			Attributes:             keyValues(e.Attributes),
+			DroppedAttributesCount: uint32(e.DroppedAttributeCount),
+		})
+	}
+	for _, l := range s.Links() {
+		linkTraceID, linkSpanID := l.SpanContext.TraceID(), l.SpanContext.SpanID()
+		out.Links = append(out.Links, &tracepb.Span_Link{
+			TraceId:                linkTraceID[:],
+			SpanId:                 linkSpanID[:],
+			TraceState:             l.SpanContext.TraceState().String(),
+			Attributes:             keyValues(l.Attributes),
+			DroppedAttributesCount: uint32(l.DroppedAttributeCount),
+			Flags:                  spanFlags(l.SpanContext.TraceFlags(), l.SpanContext.IsRemote()),
+		})
+	}
+	return out
+}
+
+// spanFlags packs the W3C trace flags and whether the parent (or linked)
+// span context is remote into the OTLP flags field.
+func spanFlags(traceFlags trace.TraceFlags, remote bool) uint32 {
+	flags := uint32(traceFlags) | uint32(tracepb.SpanFlags_SPAN_FLAGS_CONTEXT_HAS_IS_REMOTE_MASK)
+	if remote {
+		flags |= uint32(tracepb.SpanFlags_SPAN_FLAGS_CONTEXT_IS_REMOTE_MASK)
+	}
+	return flags
+}
+
+func statusProto(s sdktrace.Status) *tracepb.Status {
+	code := tracepb.Status_STATUS_CODE_UNSET
+	switch s.Code {
+	case codes.Ok:
+		code = tracepb.Status_STATUS_CODE_OK
+	case codes.Error:
+		code = tracepb.Status_STATUS_CODE_ERROR
+	}
+	return &tracepb.Status{Code: code, Message: s.Description}
+}
+
+// unixNano converts a timestamp, mapping times before the epoch to 0.
+func unixNano(ns int64) uint64 {
+	if ns < 0 {
+		return 0
+	}
+	return uint64(ns)
+}
@@ -0,0 +1,108 @@
+package otlpconv
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	res := resource.NewSchemaless(attribute.String("service.name", "checkout"))
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec), sdktrace.WithResource(res))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tracer := tp.Tracer("scope-a", trace.WithInstrumentationVersion("1.0"))
+	ctx, root := tracer.Start(context.Background(), "root", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(ctx, "child", trace.WithAttributes(
+		attribute.String("s", "v"),
+		attribute.Int64("i", 7),
+		attribute.Float64("f", 1.5),
+		attribute.Bool("b", true),
+		attribute.StringSlice("ss", []string{"x", "y"}),
+	))
+	child.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", 2)))
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	root.End()
+	_, other := tp.Tracer("scope-b").Start(context.Background(), "other")
+	other.End()
+
+	req := Spans(rec.Ended())
+	if len(req.ResourceSpans) != 1 {
+		t.Fatalf("got %d ResourceSpans, want 1", len(req.ResourceSpans))
+	}
+	rs := req.ResourceSpans[0]
+	if got := stringAttr(rs.Resource.Attributes, "service.name"); got != "checkout" {
+		t.Errorf("resource service.name = %q, want checkout", got)
+	}
+	if len(rs.ScopeSpans) != 2 {
+		t.Fatalf("got %d ScopeSpans, want 2", len(rs.ScopeSpans))
+	}
+	scope := rs.ScopeSpans[0]
+	if scope.Scope.Name != "scope-a" || scope.Scope.Version != "1.0" || len(scope.Spans) != 2 {
+		t.Fatalf("first scope = %s@%s with %d spans, want scope-a@1.0 with 2", scope.Scope.Name, scope.Scope.Version, len(scope.Spans))
+	}
+
+	c, r := scope.Spans[0], scope.Spans[1]
+	if c.Name != "child" || r.Name != "root" {
+		t.Fatalf("span names = %q, %q, want child, root", c.Name, r.Name)
+	}
+	rootSpanID, rootTraceID := root.SpanContext().SpanID(), root.SpanContext().TraceID()
+	if string(c.ParentSpanId) != string(rootSpanID[:]) || string(c.TraceId) != string(rootTraceID[:]) {
+		t.Error("child span does not reference the root span")
+	}
+	if r.ParentSpanId != nil {
+		t.Errorf("root ParentSpanId = %x, want none", r.ParentSpanId)
+	}
+	if r.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("root Kind = %v, want SERVER", r.Kind)
+	}
+	if c.Status.Code != tracepb.Status_STATUS_CODE_ERROR || c.Status.Message != "boom" {
+		t.Errorf("child Status = %v, want ERROR boom", c.Status)
+	}
+	if c.StartTimeUnixNano == 0 || c.EndTimeUnixNano < c.StartTimeUnixNano {
+		t.Errorf("child times = %d..%d", c.StartTimeUnixNano, c.EndTimeUnixNano)
+	}
+	if c.Flags&uint32(trace.FlagsSampled) == 0 {
+		t.Errorf("child Flags = %#x, want the sampled bit set", c.Flags)
+	}
+	if len(c.Events) != 1 || c.Events[0].Name != "retry" {
+		t.Errorf("child Events = %v, want one retry event", c.Events)
+	}
+
+	attrs := map[string]*commonpb.AnyValue{}
+	for _, kv := range c.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["s"].GetStringValue() != "v" || attrs["i"].GetIntValue() != 7 ||
+		attrs["f"].GetDoubleValue() != 1.5 || !attrs["b"].GetBoolValue() {
+		t.Errorf("child scalar attributes = %v", c.Attributes)
+	}
+	if vals := attrs["ss"].GetArrayValue().GetValues(); len(vals) != 2 || vals[1].GetStringValue() != "y" {
+		t.Errorf("child ss attribute = %v, want [x y]", attrs["ss"])
+	}
+}
+
+func TestSpansEmpty(t *testing.T) {
+	if req := Spans(nil); len(req.ResourceSpans) != 0 {
+		t.Errorf("Spans(nil) has %d ResourceSpans, want 0", len(req.ResourceSpans))
+	}
+}
+
+func stringAttr(kvs []*commonpb.KeyValue, key string) string {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
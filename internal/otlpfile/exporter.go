@@ -0,0 +1,112 @@
+package otlpfile
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"github.com/last9/go-agent/internal/otlpconv"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// File names of each signal, without suffix.
+const (
+	tracesName  = "traces"
+	metricsName = "metrics"
+)
+
+// Options configures the file set of each signal.
+type Options struct {
+	// Dir holds the files of every signal.
+	Dir string
+	// MaxBytes bounds the size of each file. Zero means no limit.
+	MaxBytes int64
+	// MaxFiles is the number of rotated files kept per signal. Zero means no limit.
+	MaxFiles int
+}
+
+func (o Options) open(name string) (*Writer, error) {
+	return OpenWriter(filepath.Clean(o.Dir), name, o.MaxBytes, o.MaxFiles)
+}
+
+// TraceExporter writes span batches to <Dir>/traces.jsonl.
+type TraceExporter struct {
+	w *Writer
+}
+
+// NewTraceExporter opens the trace file set described by opts.
+func NewTraceExporter(opts Options) (*TraceExporter, error) {
+	w, err := opts.open(tracesName)
+	if err != nil {
+		return nil, err
+	}
+	return &TraceExporter{w: w}, nil
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *TraceExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	line, err := otlpconv.MarshalJSON(otlpconv.Spans(spans))
+	if err != nil {
+		return err
+	}
+	return e.w.WriteLine(line)
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (e *TraceExporter) Shutdown(context.Context) error {
+	return errors.Join(e.w.Sync(), e.w.Close())
+}
+
+// MetricExporter writes metric collections to <Dir>/metrics.jsonl.
+type MetricExporter struct {
+	w *Writer
+}
+
+// NewMetricExporter opens the metric file set described by opts.
+func NewMetricExporter(opts Options) (*MetricExporter, error) {
+	w, err := opts.open(metricsName)
+	if err != nil {
+		return nil, err
+	}
+	return &MetricExporter{w: w}, nil
+}
+
+// Temporality implements metric.Exporter.
+func (e *MetricExporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return metric.DefaultTemporalitySelector(k)
+}
+
+// Aggregation implements metric.Exporter.
+func (e *MetricExporter) Aggregation(k metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(k)
+}
+
+// Export implements metric.Exporter. Metrics that cannot be converted are
+// reported in the error; the rest are still written. Empty collections are
+// skipped.
+func (e *MetricExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	if len(rm.ScopeMetrics) == 0 {
+		return nil
+	}
+	req, convErr := otlpconv.Metrics(rm)
+	line, err := otlpconv.MarshalJSON(req)
+	if err != nil {
+		return err
+	}
+	return errors.Join(convErr, e.w.WriteLine(line))
+}
+
+// ForceFlush implements metric.Exporter.
+func (e *MetricExporter) ForceFlush(context.Context) error {
+	return e.w.Sync()
+}
+
+// Shutdown implements metric.Exporter.
+func (e *MetricExporter) Shutdown(context.Context) error {
+	return errors.Join(e.w.Sync(), e.w.Close())
+}
@@ -0,0 +1,82 @@
+package otlpfile
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/last9/go-agent/internal/otlpconv"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+)
+
+func TestTraceExporter(t *testing.T) {
+	dir := t.TempDir()
+	exp, err := NewTraceExporter(Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewTraceExporter() error = %v", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	for _, name := range []string{"a", "b"} {
+		_, s := tp.Tracer("test").Start(context.Background(), name)
+		s.End()
+	}
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	var names []string
+	err = ReadLines(filepath.Join(dir, "traces.jsonl"), func(line []byte) error {
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := otlpconv.UnmarshalJSON(line, req); err != nil {
+			return err
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					names = append(names, s.Name)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadLines() error = %v", err)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("spans written = %v, want [a b]", names)
+	}
+}
+
+func TestMetricExporter(t *testing.T) {
+	dir := t.TempDir()
+	exp, err := NewMetricExporter(Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewMetricExporter() error = %v", err)
+	}
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)))
+	counter, _ := mp.Meter("test").Int64Counter("requests")
+	counter.Add(context.Background(), 2)
+	if err := mp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	var lines int
+	err = ReadLines(filepath.Join(dir, "metrics.jsonl"), func(line []byte) error {
+		lines++
+		req := &colmetricpb.ExportMetricsServiceRequest{}
+		if err := otlpconv.UnmarshalJSON(line, req); err != nil {
+			return err
+		}
+		m := req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+		if m.Name != "requests" || m.GetSum().DataPoints[0].GetAsInt() != 2 {
+			t.Errorf("metric written = %v, want requests=2", m)
+		}
+		return nil
+	})
+	if err != nil || lines != 1 {
+		t.Fatalf("ReadLines() = %d lines, %v, want 1 line", lines, err)
+	}
+}
@@ -0,0 +1,69 @@
+package otlpfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files returns the OTLP/JSON files under dir in the order they were
+// written: each signal's archives, oldest first, followed by its active file.
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && (strings.HasSuffix(name, activeSuffix) || strings.HasSuffix(name, rotatedSuffix)) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	// "-" sorts before ".", so "traces-<stamp>.jsonl.gz" precedes "traces.jsonl".
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadLines calls fn with each non-empty line of the file at path, which is
+// decompressed when its name ends in .gz. It stops at the first error from fn.
+func ReadLines(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if fnErr := fn(line); fnErr != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNo, fnErr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package otlpfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesSkipsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"metrics.jsonl", "traces.jsonl", "traces-1.jsonl.gz", "notes.txt", "traces-2.jsonl.gz.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	if got := strings.Join(names, " "); got != "metrics.jsonl traces-1.jsonl.gz traces.jsonl" {
+		t.Errorf("Files() = %s", got)
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	if err := os.WriteFile(path, []byte("one\n\ntwo\nthree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err := ReadLines(path, func(line []byte) error {
+		got = append(got, string(line))
+		return nil
+	})
+	if err != nil || strings.Join(got, " ") != "one two three" {
+		t.Errorf("ReadLines() = %v, %v, want [one two three]", got, err)
+	}
+
+	boom := errors.New("boom")
+	err = ReadLines(path, func([]byte) error { return boom })
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "traces.jsonl:1") {
+		t.Errorf("ReadLines() error = %v, want boom at line 1", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package otlpfile writes traces and metrics as OTLP/JSON lines to a rotating
+// set of files, for environments that cannot reach an OTLP endpoint, and
+// reads them back for replay.
+//
+// Each signal is written to <dir>/<signal>.jsonl, one export request per
+// line. When the file would grow past its size limit it is gzipped to
+// <dir>/<signal>-<UTC timestamp>.jsonl.gz and a new file is started; the
+// oldest rotated files are removed beyond the count limit. File names sort in
+// the order they were written.
+package otlpfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/last9/go-agent/internal/logging"
+)
+
+const (
+	activeSuffix  = ".jsonl"
+	rotatedSuffix = ".jsonl.gz"
+)
+
+// Writer appends lines to one signal's file set.
+type Writer struct {
+	mu       sync.Mutex
+	dir      string
+	name     string
+	maxBytes int64
+	maxFiles int
+
+	f    *os.File
+	size int64
+
+	now func() time.Time
+}
+
+// OpenWriter opens or creates <dir>/<name>.jsonl for appending. maxBytes
+// bounds the size of each file and maxFiles the number of rotated files
+// kept; zero means no limit.
+func OpenWriter(dir, name string, maxBytes int64, maxFiles int) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	w := &Writer{dir: dir, name: name, maxBytes: maxBytes, maxFiles: maxFiles, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteLine appends line and a newline, rotating first if the file would
+// exceed its size limit. A line larger than the limit gets a file of its own.
+// When rotation fails the line is appended to the current file.
+func (w *Writer) WriteLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+
+	n := int64(len(line)) + 1
+	if w.maxBytes > 0 && w.size > 0 && w.size+n > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			logging.Warnf("Failed to rotate %s: %v", w.activePath(), err)
+		}
+		if w.f == nil {
+			return fmt.Errorf("reopen %s after rotation failed", w.activePath())
+		}
+	}
+	written, err := w.f.Write(append(line, '\n'))
+	w.size += int64(written)
+	return err
+}
+
+// Sync flushes the active file to disk.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	return w.f.Sync()
+}
+
+// Close closes the active file. It is left uncompressed so that a later
+// Writer continues it.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+func (w *Writer) activePath() string {
+	return filepath.Join(w.dir, w.name+activeSuffix)
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.activePath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+// rotate compresses the active file into a timestamped archive, prunes old
+// archives and starts a new active file. On failure the active file is
+// reopened if possible, so that writes continue.
+func (w *Writer) rotate() error {
+	if err := w.f.Close(); err != nil {
+		w.f = nil
+		return errors.Join(err, w.open())
+	}
+	w.f = nil
+
+	stamp := w.now().UTC().Format("20060102T150405.000000000")
+	archive := filepath.Join(w.dir, w.name+"-"+stamp+rotatedSuffix)
+	err := compress(w.activePath(), archive)
+	if err == nil {
+		err = os.Remove(w.activePath())
+		w.prune()
+	}
+	return errors.Join(err, w.open())
+}
+
+// compress writes a gzipped copy of src to dst atomically.
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// prune removes the oldest archives beyond maxFiles.
+func (w *Writer) prune() {
+	if w.maxFiles <= 0 {
+		return
+	}
+	archives, err := filepath.Glob(filepath.Join(w.dir, w.name+"-*"+rotatedSuffix))
+	if err != nil || len(archives) <= w.maxFiles {
+		return
+	}
+	sort.Strings(archives)
+	for _, path := range archives[:len(archives)-w.maxFiles] {
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,99 @@
+package otlpfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readAll returns the lines of every file under dir, in replay order.
+func readAll(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	var lines []string
+	for _, f := range files {
+		err := ReadLines(f, func(line []byte) error {
+			lines = append(lines, string(line))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ReadLines(%s) error = %v", f, err)
+		}
+	}
+	return lines
+}
+
+func newTestWriter(t *testing.T, dir string, maxBytes int64, maxFiles int) *Writer {
+	t.Helper()
+	w, err := OpenWriter(dir, "traces", maxBytes, maxFiles)
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	return w
+}
+
+func TestWriterRotates(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 10, 0)
+	for _, s := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+		if err := w.WriteLine([]byte(s)); err != nil {
+			t.Fatalf("WriteLine(%q) error = %v", s, err)
+		}
+	}
+
+	files, _ := Files(dir)
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	want := []string{"traces-20260101T000001.000000000.jsonl.gz", "traces.jsonl"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("files = %v, want %v", names, want)
+	}
+	if got := readAll(t, dir); strings.Join(got, "") != "aaaabbbbccccdddd" {
+		t.Errorf("lines = %v, want [aaaa bbbb cccc dddd]", got)
+	}
+}
+
+func TestWriterPrunesOldestFiles(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 5, 2)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		_ = w.WriteLine([]byte(s + s + s + s))
+	}
+	if got := readAll(t, dir); strings.Join(got, " ") != "cccc dddd eeee" {
+		t.Errorf("lines = %v, want the 2 newest rotated files and the active one", got)
+	}
+}
+
+func TestWriterContinuesActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 0, 0)
+	_ = w.WriteLine([]byte("first"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteLine([]byte("closed")); err == nil {
+		t.Error("WriteLine() after Close() error = nil")
+	}
+
+	w = newTestWriter(t, dir, 0, 0)
+	_ = w.WriteLine([]byte("second"))
+	if got := readAll(t, dir); strings.Join(got, " ") != "first second" {
+		t.Errorf("lines = %v, want [first second]", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "traces.jsonl")); err != nil {
+		t.Errorf("active file: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package resourcedetect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// imdsEndpoint is the EC2 instance metadata service.
+const imdsEndpoint = "http://169.254.169.254"
+
+// ec2Detector reads the instance identity document through IMDSv2.
+type ec2Detector struct {
+	// endpoint overrides imdsEndpoint in tests.
+	endpoint string
+}
+
+// identityDocument is the subset of the EC2 instance identity document the
+// detector reports.
+type identityDocument struct {
+	AccountID        string `json:"accountId"`
+	Region           string `json:"region"`
+	AvailabilityZone string `json:"availabilityZone"`
+	InstanceID       string `json:"instanceId"`
+	InstanceType     string `json:"instanceType"`
+	ImageID          string `json:"imageId"`
+}
+
+// Detect implements resource.Detector.
+func (d ec2Detector) Detect(ctx context.Context) (*resource.Resource, error) {
+	doc, _, err := d.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resource.NewSchemaless(doc.attributes(semconv.CloudPlatformAWSEC2)...), nil
+}
+
+// identity fetches the identity document and returns it with the session
+// token for further requests.
+func (d ec2Detector) identity(ctx context.Context) (identityDocument, string, error) {
+	var doc identityDocument
+	token, _, err := fetch(ctx, http.MethodPut, d.url("/latest/api/token"),
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return doc, "", err
+	}
+	body, _, err := d.get(ctx, string(token), "/latest/dynamic/instance-identity/document")
+	if err != nil {
+		return doc, "", err
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return doc, "", fmt.Errorf("decode instance identity document: %w", err)
+	}
+	return doc, string(token), nil
+}
+
+func (d ec2Detector) get(ctx context.Context, token, path string) ([]byte, http.Header, error) {
+	return fetch(ctx, http.MethodGet, d.url(path), map[string]string{"X-aws-ec2-metadata-token": token})
+}
+
+func (d ec2Detector) url(path string) string {
+	if d.endpoint != "" {
+		return d.endpoint + path
+	}
+	return imdsEndpoint + path
+}
+
+func (doc identityDocument) attributes(platform attribute.KeyValue) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.CloudProviderAWS, platform}
+	return appendNonEmpty(attrs,
+		semconv.CloudAccountID(doc.AccountID),
+		semconv.CloudRegion(doc.Region),
+		semconv.CloudAvailabilityZone(doc.AvailabilityZone),
+		semconv.HostID(doc.InstanceID),
+		semconv.HostType(doc.InstanceType),
+		semconv.HostImageID(doc.ImageID),
+	)
+}
+
+// eksDetector reports EC2 worker nodes of a Kubernetes cluster as EKS. The
+// cluster name comes from the node's eks:cluster-name tag, which IMDS only
+// serves when instance tags are allowed in metadata.
+type eksDetector struct {
+	ec2 ec2Detector
+}
+
+// Detect implements resource.Detector.
+func (d eksDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil, fmt.Errorf("%w: KUBERNETES_SERVICE_HOST is not set", errNotDetected)
+	}
+	doc, token, err := d.ec2.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	attrs := doc.attributes(semconv.CloudPlatformAWSEKS)
+	if name, _, err := d.ec2.get(ctx, token, "/latest/meta-data/tags/instance/eks:cluster-name"); err == nil {
+		attrs = appendNonEmpty(attrs, semconv.K8SClusterName(strings.TrimSpace(string(name))))
+	}
+	return resource.NewSchemaless(attrs...), nil
+}
+
+// ecsDetector reads the ECS task metadata endpoint (version 4), whose URL
+// the ECS agent sets in ECS_CONTAINER_METADATA_URI_V4.
+type ecsDetector struct {
+	// endpoint overrides ECS_CONTAINER_METADATA_URI_V4 in tests.
+	endpoint string
+}
+
+// ecsTask is the subset of the task metadata the detector reports.
+type ecsTask struct {
+	Cluster          string `json:"Cluster"`
+	TaskARN          string `json:"TaskARN"`
+	Family           string `json:"Family"`
+	Revision         string `json:"Revision"`
+	AvailabilityZone string `json:"AvailabilityZone"`
+	LaunchType       string `json:"LaunchType"`
+}
+
+// Detect implements resource.Detector.
+func (d ecsDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	endpoint := d.endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("ECS_CONTAINER_METADATA_URI_V4")
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("%w: ECS_CONTAINER_METADATA_URI_V4 is not set", errNotDetected)
+	}
+
+	body, _, err := fetch(ctx, http.MethodGet, endpoint+"/task", nil)
+	if err != nil {
+		return nil, err
+	}
+	var task ecsTask
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, fmt.Errorf("decode task metadata: %w", err)
+	}
+	var container struct {
+		ContainerARN string `json:"ContainerARN"`
+	}
+	if body, _, err := fetch(ctx, http.MethodGet, endpoint, nil); err == nil {
+		_ = json.Unmarshal(body, &container)
+	}
+
+	// arn:<partition>:ecs:<region>:<account>:task/<cluster>/<id>
+	arn := strings.SplitN(task.TaskARN, ":", 6)
+	var partition, region, account string
+	if len(arn) == 6 {
+		partition, region, account = arn[1], arn[3], arn[4]
+	}
+	clusterARN := task.Cluster
+	if clusterARN != "" && !strings.HasPrefix(clusterARN, "arn:") && account != "" {
+		clusterARN = fmt.Sprintf("arn:%s:ecs:%s:%s:cluster/%s", partition, region, account, clusterARN)
+	}
+
+	attrs := []attribute.KeyValue{semconv.CloudProviderAWS, semconv.CloudPlatformAWSECS}
+	return resource.NewSchemaless(appendNonEmpty(attrs,
+		semconv.CloudAccountID(account),
+		semconv.CloudRegion(region),
+		semconv.CloudAvailabilityZone(task.AvailabilityZone),
+		semconv.AWSECSTaskARN(task.TaskARN),
+		semconv.AWSECSTaskFamily(task.Family),
+		semconv.AWSECSTaskRevision(task.Revision),
+		semconv.AWSECSClusterARN(clusterARN),
+		semconv.AWSECSLaunchtypeKey.String(strings.ToLower(task.LaunchType)),
+		semconv.AWSECSContainerARN(container.ContainerARN),
+	)...), nil
+}
+
+// appendNonEmpty appends the attributes whose values are not empty strings.
+func appendNonEmpty(attrs []attribute.KeyValue, kvs ...attribute.KeyValue) []attribute.KeyValue {
+	for _, kv := range kvs {
+		if kv.Value.AsString() != "" {
+			attrs = append(attrs, kv)
+		}
+	}
+	return attrs
+}
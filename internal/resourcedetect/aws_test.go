@@ -0,0 +1,165 @@
+package resourcedetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+const identityJSON = `{
+	"accountId": "123456789012",
+	"region": "us-east-1",
+	"availabilityZone": "us-east-1a",
+	"instanceId": "i-0abc",
+	"instanceType": "m5.large",
+	"imageId": "ami-1234"
+}`
+
+// newIMDS serves an IMDSv2 identity document, rejecting requests without
+// the session token. tags holds instance tags served under meta-data/tags.
+func newIMDS(t *testing.T, tags map[string]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "token-1")
+	})
+	withToken := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-aws-ec2-metadata-token") != "token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("GET /latest/dynamic/instance-identity/document", withToken(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, identityJSON)
+	}))
+	mux.HandleFunc("GET /latest/meta-data/tags/instance/{tag}", withToken(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := tags[r.PathValue("tag")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, v)
+	}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var ec2Attrs = map[string]string{
+	"cloud.provider":          "aws",
+	"cloud.platform":          "aws_ec2",
+	"cloud.account.id":        "123456789012",
+	"cloud.region":            "us-east-1",
+	"cloud.availability_zone": "us-east-1a",
+	"host.id":                 "i-0abc",
+	"host.type":               "m5.large",
+	"host.image.id":           "ami-1234",
+}
+
+func TestEC2Detector(t *testing.T) {
+	srv := newIMDS(t, nil)
+	res, err := ec2Detector{endpoint: srv.URL}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	checkAttrs(t, res, ec2Attrs)
+}
+
+func TestEC2DetectorNotOnEC2(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	_, err := ec2Detector{endpoint: srv.URL}.Detect(context.Background())
+	if !errors.Is(err, errNotDetected) {
+		t.Errorf("Detect() error = %v, want errNotDetected", err)
+	}
+}
+
+func TestEKSDetector(t *testing.T) {
+	srv := newIMDS(t, map[string]string{"eks:cluster-name": "prod"})
+	d := eksDetector{ec2: ec2Detector{endpoint: srv.URL}}
+
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	if _, err := d.Detect(context.Background()); !errors.Is(err, errNotDetected) {
+		t.Errorf("outside Kubernetes: Detect() error = %v, want errNotDetected", err)
+	}
+
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	defer os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	res, err := d.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	want := map[string]string{"k8s.cluster.name": "prod"}
+	for k, v := range ec2Attrs {
+		want[k] = v
+	}
+	want["cloud.platform"] = "aws_eks"
+	checkAttrs(t, res, want)
+}
+
+func TestECSDetector(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster string
+		want    string
+	}{
+		{"cluster ARN", "arn:aws:ecs:us-west-2:111122223333:cluster/default", "arn:aws:ecs:us-west-2:111122223333:cluster/default"},
+		{"cluster name", "default", "arn:aws:ecs:us-west-2:111122223333:cluster/default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v4/abc/task", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{
+					"Cluster": %q,
+					"TaskARN": "arn:aws:ecs:us-west-2:111122223333:task/default/158d1c8083dd49d6b527399fd6414f5c",
+					"Family": "checkout",
+					"Revision": "7",
+					"AvailabilityZone": "us-west-2c",
+					"LaunchType": "FARGATE"
+				}`, tt.cluster)
+			})
+			mux.HandleFunc("GET /v4/abc", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"ContainerARN": "arn:aws:ecs:us-west-2:111122223333:container/0206b271"}`)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			res, err := ecsDetector{endpoint: srv.URL + "/v4/abc"}.Detect(context.Background())
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			checkAttrs(t, res, map[string]string{
+				"cloud.provider":          "aws",
+				"cloud.platform":          "aws_ecs",
+				"cloud.account.id":        "111122223333",
+				"cloud.region":            "us-west-2",
+				"cloud.availability_zone": "us-west-2c",
+				"aws.ecs.task.arn":        "arn:aws:ecs:us-west-2:111122223333:task/default/158d1c8083dd49d6b527399fd6414f5c",
+				"aws.ecs.task.family":     "checkout",
+				"aws.ecs.task.revision":   "7",
+				"aws.ecs.cluster.arn":     tt.want,
+				"aws.ecs.launchtype":      "fargate",
+				"aws.ecs.container.arn":   "arn:aws:ecs:us-west-2:111122223333:container/0206b271",
+			})
+		})
+	}
+}
+
+func TestECSDetectorOutsideECS(t *testing.T) {
+	os.Unsetenv("ECS_CONTAINER_METADATA_URI_V4")
+	if _, err := (ecsDetector{}).Detect(context.Background()); !errors.Is(err, errNotDetected) {
+		t.Errorf("Detect() error = %v, want errNotDetected", err)
+	}
+}
@@ -0,0 +1,64 @@
+package resourcedetect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// azureEndpoint is the Azure instance metadata service.
+const azureEndpoint = "http://169.254.169.254"
+
+// azureComputePath is the compute section of the instance metadata.
+const azureComputePath = "/metadata/instance/compute?api-version=2021-12-13&format=json"
+
+// azureDetector reads the Azure instance metadata service.
+type azureDetector struct {
+	// endpoint overrides azureEndpoint in tests.
+	endpoint string
+}
+
+// azureCompute is the subset of the compute metadata the detector reports.
+type azureCompute struct {
+	Location          string `json:"location"`
+	Zone              string `json:"zone"`
+	VMID              string `json:"vmId"`
+	VMSize            string `json:"vmSize"`
+	Name              string `json:"name"`
+	SubscriptionID    string `json:"subscriptionId"`
+	ResourceGroupName string `json:"resourceGroupName"`
+	VMScaleSetName    string `json:"vmScaleSetName"`
+}
+
+// Detect implements resource.Detector.
+func (d azureDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	endpoint := d.endpoint
+	if endpoint == "" {
+		endpoint = azureEndpoint
+	}
+	body, _, err := fetch(ctx, http.MethodGet, endpoint+azureComputePath, map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+	var vm azureCompute
+	if err := json.Unmarshal(body, &vm); err != nil {
+		return nil, fmt.Errorf("decode compute metadata: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{semconv.CloudProviderAzure, semconv.CloudPlatformAzureVM}
+	return resource.NewSchemaless(appendNonEmpty(attrs,
+		semconv.CloudAccountID(vm.SubscriptionID),
+		semconv.CloudRegion(vm.Location),
+		semconv.CloudAvailabilityZone(vm.Zone),
+		semconv.HostID(vm.VMID),
+		semconv.HostType(vm.VMSize),
+		semconv.HostName(vm.Name),
+		attribute.String("azure.resourcegroup.name", vm.ResourceGroupName),
+		attribute.String("azure.vm.scaleset.name", vm.VMScaleSetName),
+	)...), nil
+}
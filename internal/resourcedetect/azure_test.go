@@ -0,0 +1,58 @@
+package resourcedetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureDetector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Path != "/metadata/instance/compute" || r.URL.Query().Get("api-version") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{
+			"location": "westeurope",
+			"zone": "2",
+			"vmId": "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+			"vmSize": "Standard_D2s_v3",
+			"name": "web-1",
+			"subscriptionId": "8d10da13-8125-4ba9-a717-bf7490507b3d",
+			"resourceGroupName": "checkout",
+			"vmScaleSetName": ""
+		}`)
+	}))
+	defer srv.Close()
+
+	res, err := azureDetector{endpoint: srv.URL}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	checkAttrs(t, res, map[string]string{
+		"cloud.provider":           "azure",
+		"cloud.platform":           "azure_vm",
+		"cloud.account.id":         "8d10da13-8125-4ba9-a717-bf7490507b3d",
+		"cloud.region":             "westeurope",
+		"cloud.availability_zone":  "2",
+		"host.id":                  "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+		"host.type":                "Standard_D2s_v3",
+		"host.name":                "web-1",
+		"azure.resourcegroup.name": "checkout",
+	})
+}
+
+func TestAzureDetectorInvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html>")
+	}))
+	defer srv.Close()
+
+	_, err := azureDetector{endpoint: srv.URL}.Detect(context.Background())
+	if err == nil || errors.Is(err, errNotDetected) {
+		t.Errorf("Detect() error = %v, want a decode error", err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package resourcedetect fills cloud.*, host.* and platform-specific resource
+// attributes from the metadata services of AWS (EC2, ECS and EKS), Google
+// Compute Engine and Azure virtual machines, and k8s.* attributes from the
+// files of a Kubernetes pod.
+//
+// Cloud detectors are opt-in by name, since each one makes network requests
+// at startup. Every detector gets a short timeout, runs concurrently with the
+// others and runs at most once per process; later calls reuse its result.
+package resourcedetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/go-agent/internal/logging"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// Detector names accepted by New.
+const (
+	EC2   = "ec2"
+	EKS   = "eks"
+	ECS   = "ecs"
+	GCE   = "gce"
+	Azure = "azure"
+
+	// All enables every detector. Detectors that find no metadata service
+	// are silent, as only one platform is expected to answer.
+	All = "all"
+)
+
+// Timeout bounds each detector, so that hosts without a metadata service
+// start quickly.
+const Timeout = time.Second
+
+// maxResponseBytes bounds a metadata response.
+const maxResponseBytes = 1 << 20
+
+// errNotDetected reports that the platform's metadata service is not
+// reachable or did not recognize the request: the process runs elsewhere.
+var errNotDetected = errors.New("metadata service not found")
+
+// detectors holds the cached detector of each name. Their results are merged
+// in this order, so more specific platforms win: ECS and EKS over EC2.
+var detectors = []struct {
+	name     string
+	detector *cached
+}{
+	{EC2, &cached{detector: ec2Detector{}}},
+	{EKS, &cached{detector: eksDetector{}}},
+	{ECS, &cached{detector: ecsDetector{}}},
+	{GCE, &cached{detector: gceDetector{}}},
+	{Azure, &cached{detector: azureDetector{}}},
+}
+
+// New returns a detector running the named detectors, or nil when names
+// enables none. Unknown names are logged and ignored.
+func New(names []string) resource.Detector {
+	enabled := make(map[string]bool, len(names))
+	all := false
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == All:
+			all = true
+		case known(name):
+			enabled[name] = true
+		default:
+			logging.Warnf("Unknown resource detector %q (want ec2, ecs, eks, gce, azure or all), ignoring", name)
+		}
+	}
+
+	m := &multiDetector{quiet: all}
+	for _, d := range detectors {
+		if all || enabled[d.name] {
+			m.names = append(m.names, d.name)
+			m.detectors = append(m.detectors, d.detector)
+		}
+	}
+	if len(m.detectors) == 0 {
+		return nil
+	}
+	return m
+}
+
+func known(name string) bool {
+	for _, d := range detectors {
+		if d.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// multiDetector runs detectors concurrently and merges their resources in
+// order. Failures are logged rather than returned, so that resource creation
+// never fails because of a metadata service.
+type multiDetector struct {
+	names     []string
+	detectors []resource.Detector
+	// quiet skips logging detectors that found no metadata service.
+	quiet bool
+}
+
+// Detect implements resource.Detector.
+func (m *multiDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	results := make([]*resource.Resource, len(m.detectors))
+	var wg sync.WaitGroup
+	for i, d := range m.detectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, Timeout)
+			defer cancel()
+			res, err := d.Detect(ctx)
+			if err != nil {
+				if !m.quiet || !errors.Is(err, errNotDetected) {
+					logging.Warnf("%s resource detector: %v", m.names[i], err)
+				}
+				return
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	var merged *resource.Resource
+	for _, res := range results {
+		// Detectors return schemaless resources, which always merge.
+		merged, _ = resource.Merge(merged, res)
+	}
+	return merged, nil
+}
+
+// cached runs detector once and returns its result on every call.
+type cached struct {
+	detector resource.Detector
+	once     sync.Once
+	res      *resource.Resource
+	err      error
+}
+
+// Detect implements resource.Detector.
+func (c *cached) Detect(ctx context.Context) (*resource.Resource, error) {
+	c.once.Do(func() {
+		c.res, c.err = c.detector.Detect(ctx)
+	})
+	return c.res, c.err
+}
+
+// client fetches metadata. Metadata services are link-local, so proxies
+// configured in the environment are bypassed.
+var client = &http.Client{Transport: &http.Transport{Proxy: nil}}
+
+// fetch sends a metadata request and returns the response body and headers.
+// Connection failures and non-200 responses wrap errNotDetected.
+func fetch(ctx context.Context, method, url string, header map[string]string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errNotDetected, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%w: %s %s: %s", errNotDetected, method, url, resp.Status)
+	}
+	return body, resp.Header, nil
+}
@@ -0,0 +1,129 @@
+package resourcedetect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// attrs returns the attributes of res as a map.
+func attrs(res *resource.Resource) map[string]string {
+	m := make(map[string]string)
+	if res == nil {
+		return m
+	}
+	for _, kv := range res.Attributes() {
+		m[string(kv.Key)] = kv.Value.Emit()
+	}
+	return m
+}
+
+// checkAttrs reports attributes of got that differ from want.
+func checkAttrs(t *testing.T, got *resource.Resource, want map[string]string) {
+	t.Helper()
+	have := attrs(got)
+	for k, v := range want {
+		if have[k] != v {
+			t.Errorf("%s = %q, want %q", k, have[k], v)
+		}
+	}
+	if len(have) != len(want) {
+		t.Errorf("attributes = %v, want %v", have, want)
+	}
+}
+
+type detectorFunc func(context.Context) (*resource.Resource, error)
+
+func (f detectorFunc) Detect(ctx context.Context) (*resource.Resource, error) { return f(ctx) }
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"unknown"}, ""},
+		{[]string{"gce"}, "gce"},
+		{[]string{" ECS ", "ec2", "ec2", "bogus"}, "ec2 ecs"},
+		{[]string{"azure", "all"}, "ec2 eks ecs gce azure"},
+	}
+	for _, tt := range tests {
+		d := New(tt.names)
+		var got string
+		if m, ok := d.(*multiDetector); ok {
+			got = strings.Join(m.names, " ")
+		} else if d != nil {
+			t.Fatalf("New(%v) = %T", tt.names, d)
+		}
+		if got != tt.want {
+			t.Errorf("New(%v) runs %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestMultiDetectorMergesInOrder(t *testing.T) {
+	fixed := func(kvs ...attribute.KeyValue) resource.Detector {
+		return detectorFunc(func(context.Context) (*resource.Resource, error) {
+			return resource.NewSchemaless(kvs...), nil
+		})
+	}
+	m := &multiDetector{
+		names: []string{"ec2", "failing", "slow", "ecs"},
+		detectors: []resource.Detector{
+			fixed(attribute.String("cloud.platform", "aws_ec2"), attribute.String("host.id", "i-1")),
+			detectorFunc(func(context.Context) (*resource.Resource, error) {
+				return nil, errors.New("boom")
+			}),
+			detectorFunc(func(ctx context.Context) (*resource.Resource, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}),
+			fixed(attribute.String("cloud.platform", "aws_ecs")),
+		},
+	}
+
+	start := time.Now()
+	res, err := m.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*Timeout {
+		t.Errorf("Detect() took %v, want about %v", elapsed, Timeout)
+	}
+	checkAttrs(t, res, map[string]string{"cloud.platform": "aws_ecs", "host.id": "i-1"})
+}
+
+func TestCachedRunsOnce(t *testing.T) {
+	calls := 0
+	c := &cached{detector: detectorFunc(func(context.Context) (*resource.Resource, error) {
+		calls++
+		return resource.NewSchemaless(attribute.String("host.id", "i-1")), nil
+	})}
+	for i := 0; i < 3; i++ {
+		res, err := c.Detect(context.Background())
+		if err != nil || attrs(res)["host.id"] != "i-1" {
+			t.Fatalf("Detect() = %v, %v", res, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("detector ran %d times, want 1", calls)
+	}
+}
+
+func TestFetchNotDetected(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	if _, _, err := fetch(context.Background(), http.MethodGet, srv.URL, nil); !errors.Is(err, errNotDetected) {
+		t.Errorf("404: fetch() error = %v, want errNotDetected", err)
+	}
+	srv.Close()
+	if _, _, err := fetch(context.Background(), http.MethodGet, srv.URL, nil); !errors.Is(err, errNotDetected) {
+		t.Errorf("unreachable: fetch() error = %v, want errNotDetected", err)
+	}
+}
@@ -0,0 +1,79 @@
+package resourcedetect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// gceEndpoint is the Compute Engine metadata server.
+const gceEndpoint = "http://metadata.google.internal"
+
+// gceDetector reads the Compute Engine metadata server.
+type gceDetector struct {
+	// endpoint overrides gceEndpoint in tests.
+	endpoint string
+}
+
+// Detect implements resource.Detector.
+func (d gceDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	project, err := d.get(ctx, "project/project-id")
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, key := range []string{"id", "machine-type", "zone", "name"} {
+		// Missing instance values are left out rather than failing detection.
+		values[key], _ = d.get(ctx, "instance/"+key)
+	}
+
+	// The zone is "projects/<number>/zones/us-central1-a", and its region
+	// drops the last component: "us-central1".
+	zone := lastComponent(values["zone"])
+	var region string
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+
+	attrs := []attribute.KeyValue{semconv.CloudProviderGCP, semconv.CloudPlatformGCPComputeEngine}
+	return resource.NewSchemaless(appendNonEmpty(attrs,
+		semconv.CloudAccountID(project),
+		semconv.CloudRegion(region),
+		semconv.CloudAvailabilityZone(zone),
+		semconv.HostID(values["id"]),
+		semconv.HostType(lastComponent(values["machine-type"])),
+		semconv.HostName(values["name"]),
+	)...), nil
+}
+
+// get returns one metadata value. Responses without the Metadata-Flavor
+// header come from something other than a metadata server.
+func (d gceDetector) get(ctx context.Context, key string) (string, error) {
+	endpoint := d.endpoint
+	if endpoint == "" {
+		endpoint = gceEndpoint
+	}
+	body, header, err := fetch(ctx, http.MethodGet, endpoint+"/computeMetadata/v1/"+key,
+		map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return "", err
+	}
+	if header.Get("Metadata-Flavor") != "Google" {
+		return "", fmt.Errorf("%w: %s is not a Compute Engine metadata server", errNotDetected, endpoint)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// lastComponent returns the part of s after its last slash.
+func lastComponent(s string) string {
+	if s == "" {
+		return ""
+	}
+	return path.Base(s)
+}
@@ -0,0 +1,56 @@
+package resourcedetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGCEDetector(t *testing.T) {
+	values := map[string]string{
+		"project/project-id":    "my-project",
+		"instance/id":           "4520031799277581759",
+		"instance/machine-type": "projects/123456/machineTypes/e2-medium",
+		"instance/zone":         "projects/123456/zones/us-central1-a",
+		"instance/name":         "web-1",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := values[r.URL.Path[len("/computeMetadata/v1/"):]]
+		if r.Header.Get("Metadata-Flavor") != "Google" || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+		fmt.Fprint(w, v)
+	}))
+	defer srv.Close()
+
+	res, err := gceDetector{endpoint: srv.URL}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	checkAttrs(t, res, map[string]string{
+		"cloud.provider":          "gcp",
+		"cloud.platform":          "gcp_compute_engine",
+		"cloud.account.id":        "my-project",
+		"cloud.region":            "us-central1",
+		"cloud.availability_zone": "us-central1-a",
+		"host.id":                 "4520031799277581759",
+		"host.type":               "e2-medium",
+		"host.name":               "web-1",
+	})
+}
+
+func TestGCEDetectorRequiresMetadataFlavor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "my-project")
+	}))
+	defer srv.Close()
+
+	if _, err := (gceDetector{endpoint: srv.URL}).Detect(context.Background()); !errors.Is(err, errNotDetected) {
+		t.Errorf("Detect() error = %v, want errNotDetected", err)
+	}
+}
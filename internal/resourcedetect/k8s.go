@@ -0,0 +1,145 @@
+package resourcedetect
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// Files read by the Kubernetes detector, relative to its root.
+const (
+	namespaceFile = "var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	hostnameFile  = "etc/hostname"
+	cgroupFile    = "proc/self/cgroup"
+	mountinfoFile = "proc/self/mountinfo"
+)
+
+// Environment variables commonly set through the downward API, in order of
+// preference.
+var (
+	namespaceEnv = []string{"K8S_NAMESPACE_NAME", "K8S_POD_NAMESPACE", "POD_NAMESPACE"}
+	podNameEnv   = []string{"K8S_POD_NAME", "POD_NAME"}
+	podUIDEnv    = []string{"K8S_POD_UID", "POD_UID"}
+	nodeNameEnv  = []string{"K8S_NODE_NAME", "NODE_NAME"}
+	containerEnv = []string{"K8S_CONTAINER_NAME", "CONTAINER_NAME"}
+)
+
+var (
+	// cgroupPodUID matches the pod UID in cgroup v1 paths, such as
+	// /kubepods/burstable/pod<uid>/<container> or the systemd form
+	// kubepods-burstable-pod<uid with underscores>.slice.
+	cgroupPodUID = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+	// mountPodUID matches the kubelet pod directory that the container's
+	// volumes, /etc/hosts and termination log are mounted from. Used with
+	// cgroup v2, where /proc/self/cgroup is just "0::/".
+	mountPodUID = regexp.MustCompile(`/pods/([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})/`)
+
+	// replicaSetPod matches pods created by a Deployment:
+	// <deployment>-<pod template hash>-<suffix>, both hashes drawn from the
+	// alphabet Kubernetes uses for generated names.
+	replicaSetPod = regexp.MustCompile(`^((.+)-[bcdfghjklmnpqrstvwxz2456789]{6,10})-[bcdfghjklmnpqrstvwxz2456789]{5}$`)
+)
+
+// K8sDetector describes the pod the process runs in, from downward API
+// environment variables, the service account namespace file and cgroup
+// paths. It reads only local files, so unlike the cloud detectors it needs
+// no opt-in, and it finds nothing outside Kubernetes.
+type K8sDetector struct {
+	// Root is prepended to every file path; "" means "/". Tests point it
+	// at a directory laid out like a pod's filesystem.
+	Root string
+}
+
+// Detect implements resource.Detector.
+func (d K8sDetector) Detect(context.Context) (*resource.Resource, error) {
+	namespace := firstEnv(namespaceEnv)
+	if namespace == "" {
+		namespace = d.readFile(namespaceFile)
+	}
+	if namespace == "" && os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil, nil
+	}
+
+	// A pod's hostname is its name unless the pod spec sets one.
+	podName := firstEnv(podNameEnv)
+	if podName == "" {
+		podName = os.Getenv("HOSTNAME")
+	}
+	if podName == "" {
+		podName = d.readFile(hostnameFile)
+	}
+	podUID := firstEnv(podUIDEnv)
+	if podUID == "" {
+		podUID = d.podUID()
+	}
+
+	attrs := appendNonEmpty(nil,
+		semconv.K8SNamespaceName(namespace),
+		semconv.K8SPodName(podName),
+		semconv.K8SPodUID(podUID),
+		semconv.K8SNodeName(firstEnv(nodeNameEnv)),
+		semconv.K8SContainerName(firstEnv(containerEnv)),
+	)
+	if m := replicaSetPod.FindStringSubmatch(podName); m != nil {
+		attrs = append(attrs, semconv.K8SReplicaSetName(m[1]), semconv.K8SDeploymentName(m[2]))
+	}
+	return resource.NewSchemaless(attrs...), nil
+}
+
+// podUID finds the pod UID in the process's cgroup or, failing that, in its
+// mount table.
+func (d K8sDetector) podUID() string {
+	if uid := d.scan(cgroupFile, cgroupPodUID); uid != "" {
+		return strings.ReplaceAll(uid, "_", "-")
+	}
+	return d.scan(mountinfoFile, mountPodUID)
+}
+
+// scan returns the first submatch of re in the file at name.
+func (d K8sDetector) scan(name string, re *regexp.Regexp) string {
+	f, err := os.Open(d.path(name))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if m := re.FindStringSubmatch(s.Text()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func (d K8sDetector) readFile(name string) string {
+	b, err := os.ReadFile(d.path(name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (d K8sDetector) path(name string) string {
+	root := d.Root
+	if root == "" {
+		root = "/"
+	}
+	return filepath.Join(root, name)
+}
+
+// firstEnv returns the first non-empty variable of keys.
+func firstEnv(keys []string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package resourcedetect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// clearK8sEnv unsets every variable the Kubernetes detector reads and
+// restores them when the test ends.
+func clearK8sEnv(t *testing.T) {
+	t.Helper()
+	keys := []string{"KUBERNETES_SERVICE_HOST", "HOSTNAME"}
+	for _, list := range [][]string{namespaceEnv, podNameEnv, podUIDEnv, nodeNameEnv, containerEnv} {
+		keys = append(keys, list...)
+	}
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Cleanup(func() { os.Setenv(k, v) })
+		}
+		os.Unsetenv(k)
+	}
+	t.Cleanup(func() {
+		for _, k := range keys {
+			os.Unsetenv(k)
+		}
+	})
+}
+
+// podRoot lays out files under a temporary root, keyed by path relative to it.
+func podRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestK8sDetectorFromFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"cgroup v1", map[string]string{
+			cgroupFile: "12:memory:/kubepods/burstable/pod6f1c9d8e-2b4a-4c1e-9f3d-0a7b5e8c2d41/3f0e4c\n",
+		}},
+		{"cgroup v1 systemd", map[string]string{
+			cgroupFile: "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod6f1c9d8e_2b4a_4c1e_9f3d_0a7b5e8c2d41.slice/cri-containerd-3f0e4c.scope\n",
+		}},
+		{"cgroup v2", map[string]string{
+			cgroupFile:    "0::/\n",
+			mountinfoFile: "1 0 0:1 / / rw - overlay overlay rw\n2 1 8:1 /var/lib/kubelet/pods/6f1c9d8e-2b4a-4c1e-9f3d-0a7b5e8c2d41/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearK8sEnv(t)
+			tt.files[namespaceFile] = "payments\n"
+			tt.files[hostnameFile] = "checkout-7d9f8b6c5d-x2k4p\n"
+
+			res, err := K8sDetector{Root: podRoot(t, tt.files)}.Detect(context.Background())
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			checkAttrs(t, res, map[string]string{
+				"k8s.namespace.name":  "payments",
+				"k8s.pod.name":        "checkout-7d9f8b6c5d-x2k4p",
+				"k8s.pod.uid":         "6f1c9d8e-2b4a-4c1e-9f3d-0a7b5e8c2d41",
+				"k8s.replicaset.name": "checkout-7d9f8b6c5d",
+				"k8s.deployment.name": "checkout",
+			})
+		})
+	}
+}
+
+func TestK8sDetectorFromEnv(t *testing.T) {
+	clearK8sEnv(t)
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	os.Setenv("POD_NAMESPACE", "payments")
+	os.Setenv("K8S_POD_NAME", "web-0")
+	os.Setenv("POD_UID", "0b8e1f52-91a3-4d5c-8e2f-6c4b7a9d1e30")
+	os.Setenv("NODE_NAME", "node-1")
+	os.Setenv("K8S_CONTAINER_NAME", "app")
+
+	res, err := K8sDetector{Root: t.TempDir()}.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	checkAttrs(t, res, map[string]string{
+		"k8s.namespace.name": "payments",
+		"k8s.pod.name":       "web-0",
+		"k8s.pod.uid":        "0b8e1f52-91a3-4d5c-8e2f-6c4b7a9d1e30",
+		"k8s.node.name":      "node-1",
+		"k8s.container.name": "app",
+	})
+}
+
+func TestK8sDetectorOutsideKubernetes(t *testing.T) {
+	clearK8sEnv(t)
+	os.Setenv("HOSTNAME", "laptop")
+
+	res, err := K8sDetector{Root: t.TempDir()}.Detect(context.Background())
+	if err != nil || len(attrs(res)) != 0 {
+		t.Errorf("Detect() = %v, %v, want no attributes", attrs(res), err)
+	}
+}
+
+func TestReplicaSetPod(t *testing.T) {
+	tests := []struct {
+		pod, deployment string
+	}{
+		{"checkout-7d9f8b6c5d-x2k4p", "checkout"},
+		{"api-gateway-5c6b9d4f8-qwz7m", "api-gateway"},
+		{"web-0", ""},                     // StatefulSet
+		{"fluentd-x2k4p", ""},             // DaemonSet
+		{"backup-28391040-x2k4p", ""},     // CronJob
+		{"checkout-7d9f8b6c5d-X2K4P", ""}, // not a generated suffix
+	}
+	for _, tt := range tests {
+		var got string
+		if m := replicaSetPod.FindStringSubmatch(tt.pod); m != nil {
+			got = m[2]
+		}
+		if got != tt.deployment {
+			t.Errorf("deployment of %q = %q, want %q", tt.pod, got, tt.deployment)
+		}
+	}
+}
@@ -46,9 +46,29 @@ func New(exactPaths, prefixes, patterns []string) *RouteMatcher {
 	}
 }
 
+// Parse creates a RouteMatcher for a single route pattern, choosing the
+// layer from its shape: "/api/**" matches the "/api/" prefix, patterns with
+// glob metacharacters (*, ?, [) use path.Match, and anything else must match
+// exactly.
+func Parse(pattern string) *RouteMatcher {
+	if prefix, ok := strings.CutSuffix(pattern, "**"); ok && !strings.ContainsAny(prefix, "*?[") {
+		return New(nil, []string{prefix}, nil)
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		return New(nil, nil, []string{pattern})
+	}
+	return New([]string{pattern}, nil, nil)
+}
+
 // ShouldExclude returns true if the path matches any exclusion rule.
 // Safe to call on a nil receiver (returns false).
 func (rm *RouteMatcher) ShouldExclude(urlPath string) bool {
+	return rm.Match(urlPath)
+}
+
+// Match returns true if the path matches any rule.
+// Safe to call on a nil receiver (returns false).
+func (rm *RouteMatcher) Match(urlPath string) bool {
 	if rm == nil {
 		return false
 	}
@@ -180,3 +180,28 @@ func TestIsEmptyNilReceiver(t *testing.T) {
 		t.Error("nil RouteMatcher should be empty")
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{name: "exact match", pattern: "/api/v1/poll", path: "/api/v1/poll", want: true},
+		{name: "exact no match", pattern: "/api/v1/poll", path: "/api/v1/poll/1", want: false},
+		{name: "prefix match", pattern: "/checkout/**", path: "/checkout/cart/items", want: true},
+		{name: "prefix no match", pattern: "/checkout/**", path: "/checkout", want: false},
+		{name: "glob match", pattern: "/users/*/orders", path: "/users/42/orders", want: true},
+		{name: "glob does not cross segments", pattern: "/users/*", path: "/users/42/orders", want: false},
+		{name: "trailing ** after a glob is a glob", pattern: "/*/items/**", path: "/a/items/b/c", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.pattern).Match(tt.path); got != tt.want {
+				t.Errorf("Parse(%q).Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
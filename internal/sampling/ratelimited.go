@@ -0,0 +1,155 @@
+package sampling
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProbabilityKey is the span attribute recording the estimated probability
+// with which a rate-limited trace was sampled, so that the backend can
+// extrapolate request counts (count / probability).
+const ProbabilityKey = attribute.Key("last9.sampling.probability")
+
+// maxRouteBuckets bounds the number of per-route buckets. Routes seen after
+// the limit is reached share a single overflow bucket, so that high-cardinality
+// paths cannot grow memory without bound.
+const maxRouteBuckets = 1000
+
+// overflowRoute is the bucket key for spans without a route and for routes
+// beyond maxRouteBuckets.
+const overflowRoute = ""
+
+// rateLimited samples at most limit new traces per second using a token
+// bucket, optionally one bucket per route.
+type rateLimited struct {
+	limit    float64
+	perRoute bool
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket is a token bucket holding up to one second of budget. It also counts
+// arrivals per second to estimate the sampling probability.
+type bucket struct {
+	tokens float64
+	last   time.Time
+
+	windowStart time.Time
+	seen        int // arrivals in the current one-second window
+	prevSeen    int // arrivals in the previous window
+}
+
+// NewRateLimited returns a sampler that samples at most limit traces per
+// second, per route when perRoute is set. It does not look at the parent;
+// wrap it in sdktrace.ParentBased to honor upstream decisions.
+func NewRateLimited(limit float64, perRoute bool) sdktrace.Sampler {
+	return &rateLimited{
+		limit:    limit,
+		perRoute: perRoute,
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *rateLimited) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	key := overflowRoute
+	if s.perRoute {
+		key = route(p.Attributes)
+	}
+
+	s.mu.Lock()
+	b := s.bucketFor(key)
+	sampled, probability := b.take(s.now(), s.limit)
+	s.mu.Unlock()
+
+	res := sdktrace.SamplingResult{
+		Decision:   sdktrace.Drop,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+	if sampled {
+		res.Decision = sdktrace.RecordAndSample
+		res.Attributes = []attribute.KeyValue{ProbabilityKey.Float64(probability)}
+	}
+	return res
+}
+
+// Description implements sdktrace.Sampler.
+func (s *rateLimited) Description() string {
+	if s.perRoute {
+		return fmt.Sprintf("RateLimited{%g,per_route}", s.limit)
+	}
+	return fmt.Sprintf("RateLimited{%g}", s.limit)
+}
+
+// bucketFor returns the bucket for key, creating it if there is room.
+// s.mu must be held.
+func (s *rateLimited) bucketFor(key string) *bucket {
+	if b, ok := s.buckets[key]; ok {
+		return b
+	}
+	if len(s.buckets) >= maxRouteBuckets {
+		key = overflowRoute
+		if b, ok := s.buckets[key]; ok {
+			return b
+		}
+	}
+	b := &bucket{tokens: burst(s.limit), last: s.now()}
+	b.windowStart = b.last
+	s.buckets[key] = b
+	return b
+}
+
+// take records an arrival at now and spends a token if one is available.
+// It returns whether the trace is sampled and the estimated probability that
+// a trace arriving in this bucket is sampled.
+func (b *bucket) take(now time.Time, limit float64) (bool, float64) {
+	if elapsed := now.Sub(b.windowStart); elapsed >= time.Second {
+		if elapsed < 2*time.Second {
+			b.prevSeen = b.seen
+		} else {
+			b.prevSeen = 0
+		}
+		b.seen = 0
+		b.windowStart = now
+	}
+	b.seen++
+
+	b.tokens += now.Sub(b.last).Seconds() * limit
+	if capacity := burst(limit); b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, 0
+	}
+	b.tokens--
+
+	// Estimate the arrival rate from the larger of the previous full window
+	// and the current one, which covers both steady load and a spike.
+	rate := float64(b.prevSeen)
+	if float64(b.seen) > rate {
+		rate = float64(b.seen)
+	}
+	if rate <= limit {
+		return true, 1
+	}
+	return true, limit / rate
+}
+
+// burst is the bucket capacity: one second of budget, and at least one token
+// so that limits below 1/s still sample.
+func burst(limit float64) float64 {
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
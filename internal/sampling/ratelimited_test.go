@@ -0,0 +1,117 @@
+package sampling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// fakeClock is a manually advanced time source.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestRateLimited(limit float64, perRoute bool) (*rateLimited, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	s := NewRateLimited(limit, perRoute).(*rateLimited)
+	s.now = clock.now
+	return s, clock
+}
+
+// sampleN samples n root spans for route and returns how many were kept and
+// the probability recorded on the last kept span.
+func sampleN(s sdktrace.Sampler, n int, route string) (kept int, probability float64) {
+	var attrs []attribute.KeyValue
+	if route != "" {
+		attrs = []attribute.KeyValue{attribute.String("http.route", route)}
+	}
+	for i := 0; i < n; i++ {
+		res := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), Attributes: attrs})
+		if res.Decision == sdktrace.RecordAndSample {
+			kept++
+			for _, kv := range res.Attributes {
+				if kv.Key == ProbabilityKey {
+					probability = kv.Value.AsFloat64()
+				}
+			}
+		}
+	}
+	return kept, probability
+}
+
+func TestRateLimitedCapsTracesPerSecond(t *testing.T) {
+	s, clock := newTestRateLimited(10, false)
+
+	if kept, _ := sampleN(s, 100, ""); kept != 10 {
+		t.Errorf("first second kept %d, want 10 (burst)", kept)
+	}
+
+	clock.advance(500 * time.Millisecond)
+	if kept, _ := sampleN(s, 100, ""); kept != 5 {
+		t.Errorf("after 500ms kept %d, want 5 (refill)", kept)
+	}
+
+	clock.advance(10 * time.Second)
+	if kept, _ := sampleN(s, 100, ""); kept != 10 {
+		t.Errorf("after idle kept %d, want 10 (bucket capped at one second)", kept)
+	}
+}
+
+func TestRateLimitedRecordsProbability(t *testing.T) {
+	s, clock := newTestRateLimited(10, false)
+
+	if _, p := sampleN(s, 5, ""); p != 1 {
+		t.Errorf("probability under the limit = %v, want 1", p)
+	}
+
+	// 40 arrivals in the next second with 10 allowed.
+	clock.advance(time.Second)
+	sampleN(s, 40, "")
+	clock.advance(time.Second)
+	if _, p := sampleN(s, 1, ""); p != 0.25 {
+		t.Errorf("probability = %v, want 0.25 (10/s of 40/s)", p)
+	}
+}
+
+func TestRateLimitedPerRoute(t *testing.T) {
+	s, _ := newTestRateLimited(2, true)
+
+	if kept, _ := sampleN(s, 10, "/poll"); kept != 2 {
+		t.Errorf("/poll kept %d, want 2", kept)
+	}
+	if kept, _ := sampleN(s, 10, "/checkout"); kept != 2 {
+		t.Errorf("/checkout kept %d, want 2 (own budget)", kept)
+	}
+	if got := s.Description(); got != "RateLimited{2,per_route}" {
+		t.Errorf("Description() = %q", got)
+	}
+}
+
+func TestRateLimitedBoundsRouteBuckets(t *testing.T) {
+	s, _ := newTestRateLimited(1, true)
+	for i := 0; i < maxRouteBuckets+10; i++ {
+		sampleN(s, 1, "/users/"+time.Duration(i).String())
+	}
+	if got := len(s.buckets); got > maxRouteBuckets+1 {
+		t.Errorf("buckets = %d, want at most %d", got, maxRouteBuckets+1)
+	}
+}
+
+func TestRateLimitedBelowOnePerSecond(t *testing.T) {
+	s, clock := newTestRateLimited(0.5, false)
+	if kept, _ := sampleN(s, 5, ""); kept != 1 {
+		t.Errorf("kept %d, want 1", kept)
+	}
+	clock.advance(time.Second)
+	if kept, _ := sampleN(s, 5, ""); kept != 0 {
+		t.Errorf("after 1s kept %d, want 0", kept)
+	}
+	clock.advance(time.Second)
+	if kept, _ := sampleN(s, 5, ""); kept != 1 {
+		t.Errorf("after 2s kept %d, want 1", kept)
+	}
+}
@@ -0,0 +1,192 @@
+// Package sampling provides the agent's custom trace samplers.
+package sampling
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/last9/go-agent/config"
+	"github.com/last9/go-agent/internal/logging"
+	"github.com/last9/go-agent/internal/routematcher"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Legacy HTTP attribute keys still set by some instrumentation.
+const (
+	httpTargetKey = attribute.Key("http.target")
+	httpMethodKey = attribute.Key("http.method")
+)
+
+// spanKinds maps config.SamplingRule.SpanKind values to trace.SpanKind.
+var spanKinds = map[string]trace.SpanKind{
+	"server":   trace.SpanKindServer,
+	"client":   trace.SpanKindClient,
+	"producer": trace.SpanKindProducer,
+	"consumer": trace.SpanKindConsumer,
+	"internal": trace.SpanKindInternal,
+}
+
+// rule is a compiled config.SamplingRule.
+type rule struct {
+	route      *routematcher.RouteMatcher // nil matches any route
+	method     string
+	spanName   string
+	spanKind   trace.SpanKind // SpanKindUnspecified matches any kind
+	rpcService string
+	ratio      float64
+	sampler    sdktrace.Sampler
+}
+
+// rulesSampler applies the first matching rule to spans that start a trace
+// in this process. Spans with a local parent follow the parent's decision,
+// so that a trace a rule drops or keeps is dropped or kept whole, whatever
+// the fallback. Spans with a remote parent, and spans no rule matches, are
+// left to the fallback sampler.
+type rulesSampler struct {
+	rules    []rule
+	fallback sdktrace.Sampler
+	limiter  sdktrace.Sampler // nil when there is no rate limit
+}
+
+// NewRules returns a sampler that samples new traces at the ratio of the
+// first rule their root span matches. Child spans of a local parent follow
+// the parent's decision; everything else is delegated to fallback.
+//
+// limiter, when not nil, is the token bucket of a ratelimited fallback: the
+// traces a rule samples must also pass it, so that rules cannot exceed the
+// traces-per-second cap. Root spans sampled by a rule record
+// last9.sampling.probability: the rule's ratio, times the limiter's
+// probability.
+func NewRules(rules []config.SamplingRule, fallback, limiter sdktrace.Sampler) sdktrace.Sampler {
+	s := &rulesSampler{fallback: fallback, limiter: limiter}
+	for _, r := range rules {
+		kind, ok := spanKinds[strings.ToLower(r.SpanKind)]
+		if r.SpanKind != "" && !ok {
+			logging.Warnf("Unknown span kind %q in sampling rule, skipping rule", r.SpanKind)
+			continue
+		}
+		compiled := rule{
+			method:     strings.ToUpper(r.Method),
+			spanName:   r.SpanName,
+			spanKind:   kind,
+			rpcService: r.RPCService,
+			ratio:      r.Ratio,
+			sampler:    sdktrace.TraceIDRatioBased(r.Ratio),
+		}
+		if r.Route != "" {
+			compiled.route = routematcher.Parse(r.Route)
+		}
+		s.rules = append(s.rules, compiled)
+	}
+	return s
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *rulesSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if parent := trace.SpanContextFromContext(p.ParentContext); parent.IsValid() {
+		switch {
+		case parent.IsRemote():
+			return s.fallback.ShouldSample(p)
+		case parent.IsSampled():
+			return sdktrace.AlwaysSample().ShouldSample(p)
+		default:
+			return sdktrace.NeverSample().ShouldSample(p)
+		}
+	}
+	for i := range s.rules {
+		if s.rules[i].matches(p) {
+			return s.sample(&s.rules[i], p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// sample applies r, and then the limiter, to a root span r matches.
+func (s *rulesSampler) sample(r *rule, p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	res := r.sampler.ShouldSample(p)
+	if res.Decision != sdktrace.RecordAndSample {
+		return res
+	}
+	probability := r.ratio
+	if s.limiter != nil {
+		limited := s.limiter.ShouldSample(p)
+		if limited.Decision != sdktrace.RecordAndSample {
+			return limited
+		}
+		for _, kv := range limited.Attributes {
+			if kv.Key == ProbabilityKey {
+				probability *= kv.Value.AsFloat64()
+			}
+		}
+	}
+	res.Attributes = append(res.Attributes, ProbabilityKey.Float64(probability))
+	return res
+}
+
+// Description implements sdktrace.Sampler.
+func (s *rulesSampler) Description() string {
+	return fmt.Sprintf("Last9Rules{rules:%d,fallback:%s}", len(s.rules), s.fallback.Description())
+}
+
+func (r *rule) matches(p sdktrace.SamplingParameters) bool {
+	if r.spanKind != trace.SpanKindUnspecified && p.Kind != r.spanKind {
+		return false
+	}
+	if r.spanName != "" && !globMatch(r.spanName, p.Name) {
+		return false
+	}
+	if r.method == "" && r.route == nil && r.rpcService == "" {
+		return true
+	}
+
+	var method, rpcService string
+	for _, kv := range p.Attributes {
+		switch kv.Key {
+		case semconv.HTTPRequestMethodKey, httpMethodKey:
+			method = kv.Value.AsString()
+		case semconv.RPCServiceKey:
+			rpcService = kv.Value.AsString()
+		}
+	}
+
+	if r.method != "" && !strings.EqualFold(method, r.method) {
+		return false
+	}
+	if r.route != nil && !r.route.Match(route(p.Attributes)) {
+		return false
+	}
+	if r.rpcService != "" && !globMatch(r.rpcService, rpcService) {
+		return false
+	}
+	return true
+}
+
+// route returns http.route, falling back to url.path (or the legacy
+// http.target) without its query string.
+func route(attrs []attribute.KeyValue) string {
+	var urlPath string
+	for _, kv := range attrs {
+		switch kv.Key {
+		case semconv.HTTPRouteKey:
+			return kv.Value.AsString()
+		case semconv.URLPathKey, httpTargetKey:
+			if urlPath == "" {
+				urlPath, _, _ = strings.Cut(kv.Value.AsString(), "?")
+			}
+		}
+	}
+	return urlPath
+}
+
+// globMatch reports whether s equals pattern or matches it as a path.Match glob.
+func globMatch(pattern, s string) bool {
+	if pattern == s {
+		return true
+	}
+	matched, _ := path.Match(pattern, s)
+	return matched
+}
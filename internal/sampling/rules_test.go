@@ -0,0 +1,183 @@
+package sampling
+
+import (
+	"context"
+	"testing"
+
+	"github.com/last9/go-agent/config"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRulesSampler(t *testing.T) {
+	rules := []config.SamplingRule{
+		{Route: "/api/v1/poll", Ratio: 0},
+		{Route: "/checkout/**", Method: "post", Ratio: 1},
+		{Route: "/checkout/**", Ratio: 0},
+		{SpanName: "Kafka *", SpanKind: "consumer", Ratio: 0},
+		{RPCService: "grpc.health.*", Ratio: 0},
+	}
+	s := NewRules(rules, sdktrace.AlwaysSample(), nil)
+
+	tests := []struct {
+		name  string
+		span  string
+		kind  trace.SpanKind
+		attrs []attribute.KeyValue
+		want  sdktrace.SamplingDecision
+	}{
+		{
+			name:  "exact route dropped",
+			kind:  trace.SpanKindServer,
+			attrs: []attribute.KeyValue{attribute.String("http.route", "/api/v1/poll")},
+			want:  sdktrace.Drop,
+		},
+		{
+			name:  "url.path used without http.route, query stripped",
+			kind:  trace.SpanKindServer,
+			attrs: []attribute.KeyValue{attribute.String("http.target", "/api/v1/poll?since=1")},
+			want:  sdktrace.Drop,
+		},
+		{
+			name: "first matching rule wins",
+			kind: trace.SpanKindServer,
+			attrs: []attribute.KeyValue{
+				attribute.String("url.path", "/checkout/cart"),
+				attribute.String("http.request.method", "POST"),
+			},
+			want: sdktrace.RecordAndSample,
+		},
+		{
+			name: "method mismatch falls through to next rule",
+			kind: trace.SpanKindServer,
+			attrs: []attribute.KeyValue{
+				attribute.String("url.path", "/checkout/cart"),
+				attribute.String("http.method", "GET"),
+			},
+			want: sdktrace.Drop,
+		},
+		{
+			name: "span name and kind",
+			span: "Kafka orders",
+			kind: trace.SpanKindConsumer,
+			want: sdktrace.Drop,
+		},
+		{
+			name: "span kind mismatch",
+			span: "Kafka orders",
+			kind: trace.SpanKindProducer,
+			want: sdktrace.RecordAndSample,
+		},
+		{
+			name:  "rpc.service glob",
+			kind:  trace.SpanKindServer,
+			attrs: []attribute.KeyValue{attribute.String("rpc.service", "grpc.health.v1.Health")},
+			want:  sdktrace.Drop,
+		},
+		{
+			name:  "no match uses fallback",
+			kind:  trace.SpanKindServer,
+			attrs: []attribute.KeyValue{attribute.String("http.route", "/api/v1/users")},
+			want:  sdktrace.RecordAndSample,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := s.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: context.Background(),
+				TraceID:       trace.TraceID{1},
+				Name:          tt.span,
+				Kind:          tt.kind,
+				Attributes:    tt.attrs,
+			})
+			if res.Decision != tt.want {
+				t.Errorf("decision = %v, want %v", res.Decision, tt.want)
+			}
+		})
+	}
+}
+
+func TestRulesSamplerChildSpansUseFallback(t *testing.T) {
+	s := NewRules([]config.SamplingRule{{Ratio: 0}}, sdktrace.ParentBased(sdktrace.NeverSample()), nil)
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	res := s.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: trace.ContextWithSpanContext(context.Background(), parent),
+		TraceID:       parent.TraceID(),
+	})
+	if res.Decision != sdktrace.RecordAndSample {
+		t.Errorf("decision = %v, want RecordAndSample (parent decision honored)", res.Decision)
+	}
+}
+
+func TestRulesSamplerChildSpansFollowLocalParent(t *testing.T) {
+	rules := []config.SamplingRule{
+		{SpanName: "GET /api/v1/poll", Ratio: 0},
+		{SpanName: "POST /checkout", Ratio: 1},
+	}
+	tests := []struct {
+		name     string
+		fallback sdktrace.Sampler
+		root     string
+		want     int // spans exported
+	}{
+		{"dropped root, always_on fallback", sdktrace.AlwaysSample(), "GET /api/v1/poll", 0},
+		{"kept root, always_off fallback", sdktrace.NeverSample(), "POST /checkout", 2},
+		{"kept root, traceidratio fallback", sdktrace.TraceIDRatioBased(0), "POST /checkout", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSampler(NewRules(rules, tt.fallback, nil)),
+				sdktrace.WithSpanProcessor(rec),
+			)
+			ctx, root := tp.Tracer("test").Start(context.Background(), tt.root)
+			_, child := tp.Tracer("test").Start(ctx, "db.query")
+			child.End()
+			root.End()
+
+			if got := len(rec.Ended()); got != tt.want {
+				t.Errorf("exported %d spans, want %d (children must follow the root)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRulesSamplerSkipsUnknownSpanKind(t *testing.T) {
+	s := NewRules([]config.SamplingRule{{SpanKind: "sever", Ratio: 0}}, sdktrace.AlwaysSample(), nil)
+	res := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), Kind: trace.SpanKindServer})
+	if res.Decision != sdktrace.RecordAndSample {
+		t.Errorf("decision = %v, want RecordAndSample (misspelled kind must not match everything)", res.Decision)
+	}
+	if got := s.Description(); got != "Last9Rules{rules:0,fallback:AlwaysOnSampler}" {
+		t.Errorf("Description() = %q", got)
+	}
+}
+
+func TestRulesSamplerRecordsProbability(t *testing.T) {
+	s := NewRules([]config.SamplingRule{{Route: "/search", Ratio: 0.5}}, sdktrace.AlwaysSample(), nil)
+	if kept, probability := sampleN(s, 1, "/search"); kept != 1 || probability != 0.5 {
+		t.Errorf("kept %d with probability %v, want 1 with 0.5", kept, probability)
+	}
+}
+
+func TestRulesSamplerAppliesRateLimit(t *testing.T) {
+	limiter, _ := newTestRateLimited(2, false)
+	s := NewRules([]config.SamplingRule{{Route: "/checkout", Ratio: 1}}, sdktrace.ParentBased(limiter), limiter)
+
+	kept, probability := sampleN(s, 10, "/checkout")
+	if kept != 2 {
+		t.Errorf("kept %d, want 2 (rule-sampled traces must respect the limit)", kept)
+	}
+	if probability <= 0 || probability > 1 {
+		t.Errorf("probability = %v, want the limiter's estimate", probability)
+	}
+}
@@ -0,0 +1,141 @@
+package selftelemetry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/last9/go-agent/internal/logging"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values of the signal attribute.
+const (
+	signalTraces  = "traces"
+	signalMetrics = "metrics"
+)
+
+// WrapSpanExporter returns exp with every export call timed and counted.
+// Spans in a failed call are counted as dropped with reason export_failed.
+func (t *Telemetry) WrapSpanExporter(exp sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return &spanExporter{SpanExporter: exp, t: t}
+}
+
+type spanExporter struct {
+	sdktrace.SpanExporter
+	t *Telemetry
+}
+
+func (e *spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	start := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.t.record(ctx, signalTraces, start, err)
+	logExport(signalTraces, len(spans), "spans", start, err)
+
+	n := int64(len(spans))
+	if err != nil {
+		e.t.droppedExportFailed.Add(n)
+	} else {
+		e.t.spansExported.Add(n)
+	}
+	e.t.spansQueued.Add(-n)
+	return err
+}
+
+// WrapMetricExporter returns exp with every export call timed and counted.
+func (t *Telemetry) WrapMetricExporter(exp sdkmetric.Exporter) sdkmetric.Exporter {
+	return &metricExporter{Exporter: exp, t: t}
+}
+
+type metricExporter struct {
+	sdkmetric.Exporter
+	t *Telemetry
+}
+
+func (e *metricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	start := time.Now()
+	err := e.Exporter.Export(ctx, rm)
+	e.t.record(ctx, signalMetrics, start, err)
+	if logging.Enabled(slog.LevelDebug) {
+		var n int
+		for _, sm := range rm.ScopeMetrics {
+			n += len(sm.Metrics)
+		}
+		logExport(signalMetrics, n, "metrics", start, err)
+	}
+	return err
+}
+
+// logExport logs an export call at debug level.
+func logExport(signal string, n int, unit string, start time.Time, err error) {
+	if err != nil {
+		logging.Debugf("Failed to export %s batch of %d %s after %s: %v", signal, n, unit, time.Since(start), err)
+		return
+	}
+	logging.Debugf("Exported %s batch of %d %s in %s", signal, n, unit, time.Since(start))
+}
+
+// record reports the duration and, on failure, the error class of an
+// export call.
+func (t *Telemetry) record(ctx context.Context, signal string, start time.Time, err error) {
+	// The export context may already be done; the measurements still count.
+	ctx = context.WithoutCancel(ctx)
+	inst := t.instruments.Load()
+	inst.exportDuration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(signalKey.String(signal)))
+	if err != nil {
+		inst.exportFailures.Add(ctx, 1,
+			metric.WithAttributes(signalKey.String(signal), errorTypeKey.String(errorClass(err))))
+	}
+}
+
+// errorClass maps an export error to a low-cardinality error.type value.
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown && s.Code() != codes.OK {
+		return snakeCase(s.Code().String())
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	// The OTLP/HTTP exporters report 429 and 5xx answers that are worth
+	// retrying with this message and no typed error.
+	if strings.Contains(err.Error(), "retry-able request failure") {
+		return "unavailable"
+	}
+	return "other"
+}
+
+// snakeCase converts a gRPC code name such as ResourceExhausted to
+// resource_exhausted.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package selftelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type failingSpanExporter struct{ err error }
+
+func (e failingSpanExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return e.err
+}
+func (failingSpanExporter) Shutdown(context.Context) error { return nil }
+
+type failingMetricExporter struct {
+	sdkmetric.Exporter
+	err error
+}
+
+func (e failingMetricExporter) Export(context.Context, *metricdata.ResourceMetrics) error {
+	return e.err
+}
+
+func TestWrapSpanExporter(t *testing.T) {
+	tel := New()
+	read := collect(t, tel)
+	spans := tracetest.SpanStubs{{Name: "a"}, {Name: "b"}}.Snapshots()
+	tel.spansQueued.Store(4)
+
+	ok := tel.WrapSpanExporter(tracetest.NewInMemoryExporter())
+	if err := ok.ExportSpans(context.Background(), spans); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+	failing := tel.WrapSpanExporter(failingSpanExporter{status.Error(codes.Unavailable, "down")})
+	if err := failing.ExportSpans(context.Background(), spans); err == nil {
+		t.Fatal("ExportSpans() error = nil, want the exporter's error")
+	}
+
+	if s := tel.Stats(); s.SpansExported != 2 || s.SpansDropped != 2 || s.SpansQueued != 0 {
+		t.Errorf("Stats() = %+v, want 2 exported, 2 dropped, 0 queued", s)
+	}
+	got := read()
+	if v := int64Value(t, got["last9.agent.export.failures"], errorTypeKey, "unavailable"); v != 1 {
+		t.Errorf("export.failures{error.type=unavailable} = %d, want 1", v)
+	}
+	hist, isHist := got["last9.agent.export.duration"].Data.(metricdata.Histogram[float64])
+	if !isHist || len(hist.DataPoints) != 1 || hist.DataPoints[0].Count != 2 {
+		t.Errorf("export.duration = %+v, want one traces series with 2 calls", got["last9.agent.export.duration"].Data)
+	}
+}
+
+func TestWrapMetricExporter(t *testing.T) {
+	tel := New()
+	read := collect(t, tel)
+
+	exp := tel.WrapMetricExporter(failingMetricExporter{err: context.DeadlineExceeded})
+	if err := exp.Export(context.Background(), &metricdata.ResourceMetrics{}); err == nil {
+		t.Fatal("Export() error = nil, want the exporter's error")
+	}
+
+	m := read()["last9.agent.export.failures"]
+	if v := int64Value(t, m, errorTypeKey, "timeout"); v != 1 {
+		t.Errorf("export.failures{error.type=timeout} = %d, want 1", v)
+	}
+	if v := int64Value(t, m, signalKey, signalMetrics); v != 1 {
+		t.Errorf("export.failures{signal=metrics} = %d, want 1", v)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"deadline", fmt.Errorf("export: %w", context.DeadlineExceeded), "timeout"},
+		{"canceled", context.Canceled, "canceled"},
+		{"grpc unavailable", status.Error(codes.Unavailable, "down"), "unavailable"},
+		{"grpc resource exhausted", status.Error(codes.ResourceExhausted, "slow down"), "resource_exhausted"},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, "network"},
+		{"http retryable", errors.New("retry-able request failure"), "unavailable"},
+		{"other", errors.New("failed to send to http://x: 400 Bad Request"), "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err); got != tt.want {
+				t.Errorf("errorClass(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package selftelemetry reports metrics about the agent's own export
+// pipeline under the last9.agent.* namespace, so that missing data can be
+// traced to the agent or ruled out.
+//
+// A Telemetry counts spans as they start, end, wait for export and are
+// exported or dropped, and times every export call. Counters are kept in
+// memory and reported once RegisterMetrics is given the agent's
+// MeterProvider, so they carry the same resource as the application's own
+// metrics, including the telemetry.distro.* attributes.
+package selftelemetry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/last9/go-agent/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// scopeName is the instrumentation scope of the metrics reported by this package.
+const scopeName = "github.com/last9/go-agent/internal/selftelemetry"
+
+// Attribute keys used on the agent's metrics.
+const (
+	signalKey    = attribute.Key("signal")
+	reasonKey    = attribute.Key("reason")
+	errorTypeKey = attribute.Key("error.type")
+)
+
+// Values of the reason attribute of last9.agent.spans.dropped.
+const (
+	reasonQueueFull    = "queue_full"
+	reasonExportFailed = "export_failed"
+)
+
+// Telemetry holds the agent's self-telemetry counters.
+type Telemetry struct {
+	spansStarted  atomic.Int64
+	spansEnded    atomic.Int64
+	spansExported atomic.Int64
+	spansQueued   atomic.Int64 // handed to the batch processor, not yet exported
+
+	droppedQueueFull    atomic.Int64
+	droppedExportFailed atomic.Int64
+
+	sdkErrors atomic.Int64
+
+	// instruments holds the synchronous instruments, which record nothing
+	// until RegisterMetrics swaps in real ones.
+	instruments atomic.Pointer[instruments]
+}
+
+type instruments struct {
+	exportFailures metric.Int64Counter
+	exportDuration metric.Float64Histogram
+}
+
+// New returns a Telemetry that is not yet reporting.
+func New() *Telemetry {
+	t := &Telemetry{}
+	meter := noop.NewMeterProvider().Meter(scopeName)
+	failures, _ := meter.Int64Counter("")
+	duration, _ := meter.Float64Histogram("")
+	t.instruments.Store(&instruments{exportFailures: failures, exportDuration: duration})
+	return t
+}
+
+// Stats is a snapshot of the span counters.
+type Stats struct {
+	SpansStarted  int64
+	SpansEnded    int64
+	SpansExported int64
+	SpansDropped  int64
+	// SpansQueued is the number of spans waiting in the batch span
+	// processor, including the batch being exported.
+	SpansQueued int64
+}
+
+// Stats returns the current span counters.
+func (t *Telemetry) Stats() Stats {
+	return Stats{
+		SpansStarted:  t.spansStarted.Load(),
+		SpansEnded:    t.spansEnded.Load(),
+		SpansExported: t.spansExported.Load(),
+		SpansDropped:  t.droppedQueueFull.Load() + t.droppedExportFailed.Load(),
+		SpansQueued:   t.spansQueued.Load(),
+	}
+}
+
+// RegisterMetrics starts reporting through mp:
+//
+//   - last9.agent.spans.started, .ended and .exported: span counters
+//   - last9.agent.spans.dropped: spans lost by the agent, by reason
+//     (queue_full or export_failed)
+//   - last9.agent.span_queue.size: spans waiting for export
+//   - last9.agent.export.failures: failed export calls, by signal and error.type
+//   - last9.agent.export.duration: export call latency, by signal
+//   - last9.agent.sdk.errors: errors reported to the OpenTelemetry error
+//     handler, such as failed metric collections (see ErrorHandler)
+func (t *Telemetry) RegisterMetrics(mp metric.MeterProvider) error {
+	meter := mp.Meter(scopeName)
+
+	var errs []error
+	counter := func(name, desc, unit string) metric.Int64ObservableCounter {
+		c, err := meter.Int64ObservableCounter(name, metric.WithDescription(desc), metric.WithUnit(unit))
+		errs = append(errs, err)
+		return c
+	}
+	started := counter("last9.agent.spans.started", "Spans started and recorded", "{span}")
+	ended := counter("last9.agent.spans.ended", "Spans ended", "{span}")
+	exported := counter("last9.agent.spans.exported", "Spans handed to the exporter successfully", "{span}")
+	dropped := counter("last9.agent.spans.dropped", "Sampled spans lost before reaching the backend", "{span}")
+	sdkErrors := counter("last9.agent.sdk.errors", "Errors reported by the OpenTelemetry SDK, including failed metric collections", "{error}")
+	queueSize, err := meter.Int64ObservableUpDownCounter("last9.agent.span_queue.size",
+		metric.WithDescription("Spans waiting in the batch span processor, including the batch being exported"),
+		metric.WithUnit("{span}"))
+	errs = append(errs, err)
+
+	failures, err := meter.Int64Counter("last9.agent.export.failures",
+		metric.WithDescription("Export calls that failed"),
+		metric.WithUnit("{call}"))
+	errs = append(errs, err)
+	duration, err := meter.Float64Histogram("last9.agent.export.duration",
+		metric.WithDescription("Duration of export calls"),
+		metric.WithUnit("s"))
+	errs = append(errs, err)
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	t.instruments.Store(&instruments{exportFailures: failures, exportDuration: duration})
+
+	queueFull := metric.WithAttributes(reasonKey.String(reasonQueueFull))
+	exportFailed := metric.WithAttributes(reasonKey.String(reasonExportFailed))
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(started, t.spansStarted.Load())
+		o.ObserveInt64(ended, t.spansEnded.Load())
+		o.ObserveInt64(exported, t.spansExported.Load())
+		o.ObserveInt64(dropped, t.droppedQueueFull.Load(), queueFull)
+		o.ObserveInt64(dropped, t.droppedExportFailed.Load(), exportFailed)
+		o.ObserveInt64(queueSize, t.spansQueued.Load())
+		o.ObserveInt64(sdkErrors, t.sdkErrors.Load())
+		return nil
+	}, started, ended, exported, dropped, queueSize, sdkErrors)
+	return err
+}
+
+// ErrorHandler returns an otel.ErrorHandler that counts errors as
+// last9.agent.sdk.errors and then passes them to next, typically the
+// handler returned by otel.GetErrorHandler before installing this one.
+func (t *Telemetry) ErrorHandler(next otel.ErrorHandler) otel.ErrorHandler {
+	return &errorHandler{telemetry: t, next: next}
+}
+
+type errorHandler struct {
+	telemetry *Telemetry
+	next      otel.ErrorHandler
+}
+
+// forwardedError marks an error passed on to the next handler. The default
+// global handler delegates to the first handler ever set, which may be this
+// one, so a forwarded error coming back is logged instead of looping.
+type forwardedError struct{ error }
+
+func (e forwardedError) Unwrap() error { return e.error }
+
+// Handle implements otel.ErrorHandler.
+func (h *errorHandler) Handle(err error) {
+	var fwd forwardedError
+	if errors.As(err, &fwd) {
+		logging.Errorf("%v", fwd.error)
+		return
+	}
+	h.telemetry.sdkErrors.Add(1)
+	if h.next != nil {
+		h.next.Handle(forwardedError{err})
+	}
+}
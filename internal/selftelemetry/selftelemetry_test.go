@@ -0,0 +1,133 @@
+package selftelemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collect registers tel on a fresh MeterProvider and returns a function
+// that reads its current metrics by name.
+func collect(t *testing.T, tel *Telemetry) func() map[string]metricdata.Metrics {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() { _ = mp.Shutdown(context.Background()) })
+	if err := tel.RegisterMetrics(mp); err != nil {
+		t.Fatalf("RegisterMetrics() error = %v", err)
+	}
+	return func() map[string]metricdata.Metrics {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatalf("Collect() error = %v", err)
+		}
+		got := map[string]metricdata.Metrics{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				got[m.Name] = m
+			}
+		}
+		return got
+	}
+}
+
+// int64Value returns the value of the data point of m with the given
+// attribute, or of its only data point when key is empty.
+func int64Value(t *testing.T, m metricdata.Metrics, key attribute.Key, value string) int64 {
+	t.Helper()
+	var points []metricdata.DataPoint[int64]
+	switch d := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		points = d.DataPoints
+	default:
+		t.Fatalf("%s has data %T, want an int64 sum", m.Name, m.Data)
+	}
+	for _, p := range points {
+		if key == "" {
+			return p.Value
+		}
+		if v, ok := p.Attributes.Value(key); ok && v.AsString() == value {
+			return p.Value
+		}
+	}
+	t.Fatalf("%s has no data point with %s=%q", m.Name, key, value)
+	return 0
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	tel := New()
+	tel.spansStarted.Store(5)
+	tel.spansEnded.Store(4)
+	tel.spansExported.Store(3)
+	tel.spansQueued.Store(1)
+	tel.droppedQueueFull.Store(2)
+	tel.droppedExportFailed.Store(7)
+	tel.sdkErrors.Store(1)
+
+	got := collect(t, tel)()
+	tests := []struct {
+		name  string
+		key   attribute.Key
+		value string
+		want  int64
+	}{
+		{"last9.agent.spans.started", "", "", 5},
+		{"last9.agent.spans.ended", "", "", 4},
+		{"last9.agent.spans.exported", "", "", 3},
+		{"last9.agent.spans.dropped", reasonKey, reasonQueueFull, 2},
+		{"last9.agent.spans.dropped", reasonKey, reasonExportFailed, 7},
+		{"last9.agent.span_queue.size", "", "", 1},
+		{"last9.agent.sdk.errors", "", "", 1},
+	}
+	for _, tt := range tests {
+		m, ok := got[tt.name]
+		if !ok {
+			t.Errorf("%s not reported", tt.name)
+			continue
+		}
+		if v := int64Value(t, m, tt.key, tt.value); v != tt.want {
+			t.Errorf("%s{%s=%q} = %d, want %d", tt.name, tt.key, tt.value, v, tt.want)
+		}
+	}
+
+	if s := tel.Stats(); s.SpansDropped != 9 {
+		t.Errorf("Stats().SpansDropped = %d, want 9", s.SpansDropped)
+	}
+}
+
+type recordingHandler struct{ errs []error }
+
+func (h *recordingHandler) Handle(err error) { h.errs = append(h.errs, err) }
+
+func TestErrorHandler(t *testing.T) {
+	tel := New()
+	next := &recordingHandler{}
+	h := tel.ErrorHandler(next)
+
+	boom := errors.New("collection failed")
+	h.Handle(boom)
+	if got := tel.sdkErrors.Load(); got != 1 {
+		t.Errorf("sdkErrors = %d, want 1", got)
+	}
+	if len(next.errs) != 1 || !errors.Is(next.errs[0], boom) {
+		t.Errorf("next handler received %v, want [%v]", next.errs, boom)
+	}
+}
+
+func TestErrorHandlerDoesNotLoop(t *testing.T) {
+	tel := New()
+	// A handler that hands errors back to this one, as the default global
+	// handler does when this one was the first to be installed.
+	var h otel.ErrorHandler
+	h = tel.ErrorHandler(otel.ErrorHandlerFunc(func(err error) { h.Handle(err) }))
+
+	h.Handle(errors.New("boom"))
+	if got := tel.sdkErrors.Load(); got != 1 {
+		t.Errorf("sdkErrors = %d, want 1", got)
+	}
+}
@@ -0,0 +1,58 @@
+package selftelemetry
+
+import (
+	"context"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanCounter returns a SpanProcessor that counts started and ended spans.
+// Register it on the TracerProvider alongside the exporting processors.
+func (t *Telemetry) SpanCounter() sdktrace.SpanProcessor {
+	return &spanCounter{t: t}
+}
+
+type spanCounter struct {
+	t *Telemetry
+}
+
+func (c *spanCounter) OnStart(context.Context, sdktrace.ReadWriteSpan) { c.t.spansStarted.Add(1) }
+func (c *spanCounter) OnEnd(sdktrace.ReadOnlySpan)                     { c.t.spansEnded.Add(1) }
+func (c *spanCounter) Shutdown(context.Context) error                  { return nil }
+func (c *spanCounter) ForceFlush(context.Context) error                { return nil }
+
+// LimitQueue wraps a batch span processor whose exporter is wrapped with
+// WrapSpanExporter, tracking the spans it holds. Once capacity spans are
+// waiting, further spans are dropped and counted here rather than silently
+// inside the batch processor. capacity should be the batch processor's
+// maximum queue size plus its maximum export batch size, which is how many
+// spans it can hold before dropping.
+func (t *Telemetry) LimitQueue(bsp sdktrace.SpanProcessor, capacity int) sdktrace.SpanProcessor {
+	return &queueLimiter{t: t, next: bsp, capacity: int64(capacity)}
+}
+
+type queueLimiter struct {
+	t        *Telemetry
+	next     sdktrace.SpanProcessor
+	capacity int64
+}
+
+func (q *queueLimiter) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	q.next.OnStart(parent, s)
+}
+
+// OnEnd forwards sampled spans while the queue has room.
+func (q *queueLimiter) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	if q.t.spansQueued.Add(1) > q.capacity {
+		q.t.spansQueued.Add(-1)
+		q.t.droppedQueueFull.Add(1)
+		return
+	}
+	q.next.OnEnd(s)
+}
+
+func (q *queueLimiter) Shutdown(ctx context.Context) error   { return q.next.Shutdown(ctx) }
+func (q *queueLimiter) ForceFlush(ctx context.Context) error { return q.next.ForceFlush(ctx) }
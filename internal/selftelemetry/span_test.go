@@ -0,0 +1,76 @@
+package selftelemetry
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordingProcessor keeps the spans that reach it.
+type recordingProcessor struct {
+	sdktrace.SpanProcessor
+	ended []sdktrace.ReadOnlySpan
+}
+
+func (p *recordingProcessor) OnEnd(s sdktrace.ReadOnlySpan) { p.ended = append(p.ended, s) }
+
+func TestSpanCounter(t *testing.T) {
+	tel := New()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tel.SpanCounter()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	_, a := tp.Tracer("test").Start(context.Background(), "a")
+	_, b := tp.Tracer("test").Start(context.Background(), "b")
+	a.End()
+
+	if s := tel.Stats(); s.SpansStarted != 2 || s.SpansEnded != 1 {
+		t.Errorf("Stats() = %+v, want 2 started and 1 ended", s)
+	}
+	b.End()
+}
+
+func TestLimitQueue(t *testing.T) {
+	tel := New()
+	next := &recordingProcessor{SpanProcessor: sdktrace.NewSimpleSpanProcessor(tracetest.NewNoopExporter())}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tel.LimitQueue(next, 6)))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	for i := 0; i < 10; i++ {
+		_, s := tp.Tracer("test").Start(context.Background(), "span")
+		s.End()
+	}
+	if s := tel.Stats(); s.SpansQueued != 6 || s.SpansDropped != 4 || len(next.ended) != 6 {
+		t.Errorf("Stats() = %+v with %d forwarded, want 6 queued and forwarded, 4 dropped", s, len(next.ended))
+	}
+
+	// Exporting the queued spans makes room again.
+	exp := tel.WrapSpanExporter(tracetest.NewNoopExporter())
+	if err := exp.ExportSpans(context.Background(), next.ended); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+	_, s := tp.Tracer("test").Start(context.Background(), "span")
+	s.End()
+	if s := tel.Stats(); s.SpansQueued != 1 || s.SpansExported != 6 {
+		t.Errorf("Stats() after export = %+v, want 1 queued and 6 exported", s)
+	}
+}
+
+func TestLimitQueueIgnoresUnsampledSpans(t *testing.T) {
+	tel := New()
+	next := &recordingProcessor{SpanProcessor: sdktrace.NewSimpleSpanProcessor(tracetest.NewNoopExporter())}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.NeverSample()),
+		sdktrace.WithSpanProcessor(tel.LimitQueue(next, 1)),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	for i := 0; i < 3; i++ {
+		_, s := tp.Tracer("test").Start(context.Background(), "span")
+		s.End()
+	}
+	if s := tel.Stats(); s.SpansQueued != 0 || s.SpansDropped != 0 {
+		t.Errorf("Stats() = %+v, want nothing queued or dropped", s)
+	}
+}
@@ -1,5 +1,10 @@
 // Package metrics provides convenient helpers for custom application metrics.
 // Use this package to add counters, gauges, and histograms to your application.
+//
+// Pass the request's ctx to Add and Record: a measurement made while a
+// sampled span is active is kept as an exemplar carrying its trace and span
+// IDs, linking a point on a dashboard to an example trace
+// (OTEL_METRICS_EXEMPLAR_FILTER).
 package metrics
 
 import (
@@ -140,7 +145,8 @@ func NewHistogram(name, description, unit string) (*Histogram, error) {
 	return &Histogram{histogram: histogram}, nil
 }
 
-// Record records a value in the histogram.
+// Record records a value in the histogram. The span in ctx, if sampled, is
+// attached to the value as an exemplar.
 func (h *Histogram) Record(ctx context.Context, value int64, attrs ...attribute.KeyValue) {
 	if h.histogram != nil {
 		h.histogram.Record(ctx, value, metric.WithAttributes(attrs...))
@@ -187,7 +193,8 @@ func NewFloatHistogram(name, description, unit string) (*FloatHistogram, error)
 	return &FloatHistogram{histogram: histogram}, nil
 }
 
-// Record records a value in the histogram.
+// Record records a value in the histogram. The span in ctx, if sampled, is
+// attached to the value as an exemplar.
 func (h *FloatHistogram) Record(ctx context.Context, value float64, attrs ...attribute.KeyValue) {
 	if h.histogram != nil {
 		h.histogram.Record(ctx, value, metric.WithAttributes(attrs...))
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/last9/go-agent/internal/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// PrometheusHandler returns the handler serving the global agent's metrics
+// in the Prometheus exposition format, for mounting on an existing server:
+//
+//	mux.Handle("/metrics", agent.PrometheusHandler())
+//
+// It returns nil when the agent is not started or the Prometheus reader is
+// not enabled (LAST9_PROMETHEUS_ENABLED, LAST9_PROMETHEUS_ADDR).
+func PrometheusHandler() http.Handler {
+	return globalAgent.Load().PrometheusHandler()
+}
+
+// PrometheusHandler returns the handler serving a's metrics in the
+// Prometheus exposition format, or nil when its Prometheus reader is not
+// enabled. On a nil a, it returns the global agent's handler.
+func (a *Agent) PrometheusHandler() http.Handler {
+	if a == nil {
+		if a = globalAgent.Load(); a == nil {
+			return nil
+		}
+	}
+	return a.promHandler
+}
+
+// newPrometheusReader creates a Prometheus reader and the handler serving
+// it. Each reader has its own registry, so agents created with New do not
+// collide with each other or with prometheus.DefaultRegisterer.
+func newPrometheusReader() (metric.Reader, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	reader, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
+
+// servePrometheus serves handler at path on addr until the returned server
+// is shut down. It listens before returning so that an address already in
+// use fails agent startup.
+func servePrometheus(addr, path string, handler http.Handler) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for Prometheus scrapes on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Warnf("Prometheus endpoint stopped: %v", err)
+		}
+	}()
+	logging.Infof("Serving Prometheus metrics at http://%s%s", srv.Addr, path)
+	return srv, nil
+}
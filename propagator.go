@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/last9/go-agent/internal/logging"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/contrib/propagators/ot"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// propagators maps the OTEL_PROPAGATORS values to their propagators. "none"
+// contributes nothing, so a list of only "none" disables propagation.
+var propagators = map[string]func() propagation.TextMapPropagator{
+	"tracecontext": func() propagation.TextMapPropagator { return propagation.TraceContext{} },
+	"baggage":      func() propagation.TextMapPropagator { return propagation.Baggage{} },
+	"b3": func() propagation.TextMapPropagator {
+		return b3.New(b3.WithInjectEncoding(b3.B3SingleHeader))
+	},
+	"b3multi": func() propagation.TextMapPropagator {
+		return b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader))
+	},
+	"jaeger":  func() propagation.TextMapPropagator { return jaeger.Jaeger{} },
+	"xray":    func() propagation.TextMapPropagator { return xray.Propagator{} },
+	"ottrace": func() propagation.TextMapPropagator { return ot.OT{} },
+	"none":    nil,
+}
+
+// buildPropagator returns a propagator combining the named ones in order.
+// Unknown and repeated names are skipped with a warning. When extracting,
+// later propagators win over earlier ones that found the same fields.
+func buildPropagator(names []string) propagation.TextMapPropagator {
+	var list []propagation.TextMapPropagator
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		newPropagator, ok := propagators[name]
+		switch {
+		case !ok:
+			logging.Warnf("Unknown propagator %q in OTEL_PROPAGATORS (want tracecontext, baggage, b3, b3multi, jaeger, xray, ottrace or none), ignoring", name)
+		case seen[name]:
+			logging.Warnf("Propagator %q listed twice, ignoring the repeat", name)
+		case newPropagator != nil:
+			list = append(list, newPropagator())
+		}
+		seen[name] = true
+	}
+	return propagation.NewCompositeTextMapPropagator(list...)
+}
@@ -0,0 +1,65 @@
+//go:build test
+
+package agent
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestBuildPropagator(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{"default", []string{"tracecontext", "baggage"}, []string{"baggage", "traceparent", "tracestate"}},
+		{"b3 single header", []string{"b3"}, []string{"b3"}},
+		{"b3 multi header", []string{"B3Multi"}, []string{"x-b3-flags", "x-b3-sampled", "x-b3-spanid", "x-b3-traceid"}},
+		{"jaeger", []string{"jaeger"}, []string{"uber-trace-id"}},
+		{"xray", []string{"xray"}, []string{"X-Amzn-Trace-Id"}},
+		{"ottrace", []string{"ottrace"}, []string{"ot-tracer-sampled", "ot-tracer-spanid", "ot-tracer-traceid"}},
+		{"none", []string{"none"}, []string{}},
+		{"unknown and repeated skipped", []string{"tracecontext", "zipkin", "tracecontext"}, []string{"traceparent", "tracestate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPropagator(tt.names).Fields()
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartWithPropagators(t *testing.T) {
+	defer Reset()
+
+	if err := Start(WithServiceName("test-service"), WithPropagators("tracecontext", "b3")); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	// A request from a B3-only service continues its trace and is passed on
+	// in both formats.
+	incoming := http.Header{}
+	incoming.Set("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(incoming))
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.TraceID().String() != "80f198ee56343ba864fe8b2a57d3eff7" {
+		t.Fatalf("extracted trace ID = %s, want the B3 one", sc.TraceID())
+	}
+
+	outgoing := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(outgoing))
+	if outgoing.Get("traceparent") == "" || outgoing.Get("b3") == "" {
+		t.Errorf("outgoing headers = %v, want traceparent and b3", outgoing)
+	}
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/last9/go-agent/config"
+	"github.com/last9/go-agent/internal/logging"
+	"github.com/last9/go-agent/internal/routematcher"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// settings is the reloadable part of the agent state. It is replaced as a
+// whole so that a request never sees a config and route matcher from two
+// different reloads.
+type settings struct {
+	config       *config.Config
+	routeMatcher *routematcher.RouteMatcher
+}
+
+func newSettings(cfg *config.Config) *settings {
+	paths := cfg.ExcludedPaths
+	if cfg.PrometheusEnabled && !slices.Contains(paths, cfg.PrometheusPath) {
+		// Scrapes are never traced, even when LAST9_EXCLUDED_PATHS drops
+		// the default /metrics.
+		paths = append(paths[:len(paths):len(paths)], cfg.PrometheusPath)
+	}
+	return &settings{
+		config:       cfg,
+		routeMatcher: routematcher.New(paths, cfg.ExcludedPathPrefixes, cfg.ExcludedPathPatterns),
+	}
+}
+
+// reloadableSampler delegates to a sampler that Reload can swap while spans
+// are being started. The TracerProvider keeps a reference to it for its
+// whole lifetime.
+type reloadableSampler struct {
+	current atomic.Pointer[samplerBox]
+}
+
+// samplerBox wraps the sampler interface so it can be stored atomically.
+type samplerBox struct {
+	sdktrace.Sampler
+}
+
+func newReloadableSampler(s sdktrace.Sampler) *reloadableSampler {
+	r := &reloadableSampler{}
+	r.set(s)
+	return r
+}
+
+func (r *reloadableSampler) set(s sdktrace.Sampler) {
+	r.current.Store(&samplerBox{s})
+}
+
+// ShouldSample implements sdktrace.Sampler. At LAST9_LOG_LEVEL=debug it
+// logs every decision.
+func (r *reloadableSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	s := r.current.Load()
+	result := s.ShouldSample(p)
+	if logging.Enabled(slog.LevelDebug) {
+		logging.Debugf("Sampler %s: %s span %q (trace %s)", s.Description(), decisionName(result.Decision), p.Name, p.TraceID)
+	}
+	return result
+}
+
+// decisionName describes d in debug messages.
+func decisionName(d sdktrace.SamplingDecision) string {
+	switch d {
+	case sdktrace.RecordAndSample:
+		return "sampled"
+	case sdktrace.RecordOnly:
+		return "recorded"
+	default:
+		return "dropped"
+	}
+}
+
+// Description implements sdktrace.Sampler.
+func (r *reloadableSampler) Description() string {
+	return r.current.Load().Description()
+}
+
+// Reload re-reads the config file and environment variables, applies opts on
+// top, and atomically swaps in the new sampler, route exclusions and body
+// capture settings. Spans and requests already in flight finish with the
+// settings they started with.
+//
+// Options passed to Reload are applied after those passed to Start and
+// replace the ones passed to the previous Reload: call Reload() to go back to
+// the Start options. Reloads triggered by SIGHUP or the config file watcher
+// keep the options of the latest Reload.
+//
+// Only sampling (OTEL_TRACES_SAMPLER, OTEL_TRACES_SAMPLER_ARG,
+// LAST9_TRACE_SAMPLE_RATE, LAST9_SAMPLING_RULES), route exclusion (LAST9_EXCLUDED_PATH*) and body
+// capture (LAST9_BODY_CAPTURE*) settings are reloaded. Changes to the service
+// name, endpoints, headers or resource attributes require a restart.
+//
+// Example (raise sampling and capture bodies during an incident):
+//
+//	agent.Reload(agent.WithSamplingRate(1.0), agent.WithBodyCapture(true))
+func Reload(opts ...Option) error {
+	a := globalAgent.Load()
+	if a == nil {
+		return errors.New("agent not started")
+	}
+	return a.Reload(opts...)
+}
+
+// Reload is the Reload function for an agent created with New: it reloads
+// a's settings from the config file, environment variables, the options given
+// to New and opts.
+func (a *Agent) Reload(opts ...Option) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	return a.reload(opts)
+}
+
+// reload applies the Start options followed by opts, which become the
+// options of the latest Reload. a.reloadMu must be held.
+func (a *Agent) reload(opts []Option) error {
+	n := len(a.startOpts)
+	loaded, err := loadConfig(append(a.startOpts[:n:n], opts...))
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	a.reloadOpts = opts
+
+	// Start from the running config so settings that need a restart keep
+	// reflecting what is actually in use.
+	cfg := *a.settings.Load().config
+	cfg.Sampler = loaded.Sampler
+	cfg.SamplerRatio = loaded.SamplerRatio
+	cfg.SamplerRatioSet = loaded.SamplerRatioSet
+	cfg.SampleRate = loaded.SampleRate
+	cfg.SamplerRateLimit = loaded.SamplerRateLimit
+	cfg.SamplerRateLimitPerRoute = loaded.SamplerRateLimitPerRoute
+	cfg.SamplingRules = loaded.SamplingRules
+	cfg.ExcludedPaths = loaded.ExcludedPaths
+	cfg.ExcludedPathPrefixes = loaded.ExcludedPathPrefixes
+	cfg.ExcludedPathPatterns = loaded.ExcludedPathPatterns
+	cfg.BodyCaptureEnabled = loaded.BodyCaptureEnabled
+	cfg.BodyCaptureMaxBytes = loaded.BodyCaptureMaxBytes
+	cfg.BodyCaptureOnErrorOnly = loaded.BodyCaptureOnErrorOnly
+	cfg.BodyCaptureContentTypes = loaded.BodyCaptureContentTypes
+
+	a.sampler.set(buildSampler(&cfg))
+	a.settings.Store(newSettings(&cfg))
+
+	logging.Infof("Reloaded config (sampler: %s, body capture: %t)", a.sampler.Description(), cfg.BodyCaptureEnabled)
+	return nil
+}
+
+// startReloadTriggers starts the SIGHUP handler and config file watcher
+// enabled in cfg. The returned function stops them and waits for any reload
+// in progress to finish.
+func (a *Agent) startReloadTriggers(cfg *config.Config) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	if cfg.ReloadOnSIGHUP {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGHUP)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer signal.Stop(sigs)
+			for {
+				select {
+				case <-done:
+					return
+				case <-sigs:
+					a.triggerReload("SIGHUP")
+				}
+			}
+		}()
+	}
+
+	if cfg.ConfigWatchInterval > 0 {
+		if cfg.ConfigFile == "" {
+			logging.Warnf("LAST9_CONFIG_WATCH_INTERVAL is set but no config file is configured, not watching")
+		} else {
+			// Read the file before returning so that changes made right
+			// after Start are not mistaken for the initial contents.
+			initial, _ := os.ReadFile(cfg.ConfigFile)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.watchConfigFile(cfg.ConfigFile, initial, cfg.ConfigWatchInterval, done)
+			}()
+		}
+	}
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// watchConfigFile polls path every interval and reloads when its contents
+// differ from last. Polling rather than filesystem notifications keeps
+// working when the file is replaced through a symlink swap, as Kubernetes
+// does for mounted ConfigMaps.
+func (a *Agent) watchConfigFile(path string, last []byte, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			data, err := os.ReadFile(path)
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			a.triggerReload("config file change")
+		}
+	}
+}
+
+func (a *Agent) triggerReload(reason string) {
+	a.reloadMu.Lock()
+	err := a.reload(a.reloadOpts)
+	a.reloadMu.Unlock()
+	if err != nil {
+		logging.Warnf("Reload on %s failed, keeping previous settings: %v", reason, err)
+	}
+}
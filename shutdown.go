@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/last9/go-agent/internal/logging"
+)
+
+// exit is os.Exit, replaced in tests.
+var exit = os.Exit
+
+// Shutdown gracefully shuts down the agent, flushing any pending spans, metrics
+// and log records within the shutdown timeout (LAST9_SHUTDOWN_TIMEOUT,
+// default 5s).
+// It should be called before application exit, typically with defer.
+//
+// Example:
+//
+//	agent.Start()
+//	defer agent.Shutdown()
+func Shutdown() error {
+	a := globalAgent.Load()
+	if a == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.Config().ShutdownTimeout)
+	defer cancel()
+	return ShutdownWithContext(ctx)
+}
+
+// ShutdownWithContext is Shutdown with a caller-supplied deadline instead of
+// the configured shutdown timeout.
+//
+// Example (inside a SIGTERM handler with its own grace period):
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+//	defer cancel()
+//	agent.ShutdownWithContext(ctx)
+func ShutdownWithContext(ctx context.Context) error {
+	a := globalAgent.Load()
+	if a == nil {
+		return nil
+	}
+
+	if err := a.Shutdown(ctx); err != nil {
+		return fmt.Errorf("agent shutdown failed: %w", err)
+	}
+
+	logging.Infof("Shutdown complete")
+	return nil
+}
+
+// Flush exports pending spans, metrics and log records without shutting the
+// agent down. Short-lived jobs can call it at the end of each unit of work,
+// or before exiting through a path that skips deferred calls.
+func Flush(ctx context.Context) error {
+	a := globalAgent.Load()
+	if a == nil {
+		return nil
+	}
+	return a.Flush(ctx)
+}
+
+// OnShutdown registers hook to run when the global agent shuts down, either
+// through Shutdown or on a signal trapped by WithSignalHandling. See
+// (*Agent).OnShutdown.
+func OnShutdown(hook func(context.Context) error) {
+	globalAgent.Load().OnShutdown(hook)
+}
+
+// OnShutdown registers hook to run when a shuts down, before its telemetry
+// is flushed, so that spans ended by the hook are exported too. Hooks run in
+// registration order and share the shutdown deadline; their errors are
+// returned by Shutdown. A typical hook drains an HTTP server:
+//
+//	agent.OnShutdown(srv.Shutdown)
+//
+// On a nil a, the hook is registered with the global agent.
+func (a *Agent) OnShutdown(hook func(context.Context) error) {
+	if a == nil {
+		if a = globalAgent.Load(); a == nil {
+			logging.Warnf("OnShutdown called before Start, ignoring hook")
+			return
+		}
+	}
+	a.hooksMu.Lock()
+	defer a.hooksMu.Unlock()
+	a.hooks = append(a.hooks, hook)
+}
+
+// Flush exports a's pending spans, metrics and log records within ctx's
+// deadline. Traces are flushed first so that the agent's self-telemetry
+// metrics count them.
+func (a *Agent) Flush(ctx context.Context) error {
+	var errs []error
+	if err := a.tracerProvider.ForceFlush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("tracer provider flush: %w", err))
+	}
+	if err := a.meterProvider.ForceFlush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("meter provider flush: %w", err))
+	}
+	if err := a.loggerProvider.ForceFlush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("logger provider flush: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown runs the hooks registered with OnShutdown, then flushes and stops
+// a's providers, exporting pending spans, metrics and log records within
+// ctx's deadline. Later calls return the result of the first.
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		a.shutdownErr = a.shutdownProviders(ctx)
+	})
+	return a.shutdownErr
+}
+
+func (a *Agent) shutdownProviders(ctx context.Context) error {
+	if a.stopSignals != nil {
+		a.stopSignals()
+	}
+	a.stopReload()
+
+	var errs []error
+	a.hooksMu.Lock()
+	hooks := a.hooks
+	a.hooksMu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook: %w", err))
+		}
+	}
+
+	if a.promServer != nil {
+		if err := a.promServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("prometheus endpoint shutdown: %w", err))
+		}
+	}
+	if err := a.Flush(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := a.tracerProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("tracer provider shutdown: %w", err))
+	}
+	if err := a.meterProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("meter provider shutdown: %w", err))
+	}
+	if err := a.loggerProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("logger provider shutdown: %w", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown errors: %v", errs)
+	}
+	return nil
+}
+
+// handleSignals shuts a down and exits when the process receives SIGTERM or
+// SIGINT. The returned function stops handling signals; it does not wait and
+// is safe to call from the shutdown the handler itself started.
+func (a *Agent) handleSignals() func() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case sig := <-sigs:
+			logging.Infof("Received %s, flushing telemetry before exit", sig)
+			ctx, cancel := context.WithTimeout(context.Background(), a.Config().ShutdownTimeout)
+			if err := a.Shutdown(ctx); err != nil {
+				logging.Errorf("Shutdown on %s failed: %v", sig, err)
+			}
+			cancel()
+			exit(exitCode(sig))
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			// Unless the application traps the signals too, this restores
+			// their default action, so a second signal during shutdown
+			// terminates the process.
+			signal.Stop(sigs)
+			close(done)
+		})
+	}
+}
+
+// exitCode returns the conventional shell exit status for a process
+// terminated by sig.
+func exitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
@@ -21,6 +21,12 @@ func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption)
 	return otel.Tracer(tracerName).Start(ctx, name, opts...)
 }
 
+// StartSpan is StartSpan for an agent created with New: the span is recorded
+// by a's TracerProvider.
+func (a *Agent) StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return a.TracerProvider().Tracer(tracerName).Start(ctx, name, opts...)
+}
+
 // TraceFunction wraps a function with a span. The span is automatically ended
 // when the function returns. If fn returns an error, it is recorded on the span
 // and the span status is set to error.